@@ -2,12 +2,25 @@ package config
 
 import (
 	"flag"
+	"fmt"
+	"go-market/internal/common/accrualsystemprotocol"
 	"go-market/internal/gophermart"
 	"go-market/internal/gophermart/accrualsystem"
 	"go-market/internal/gophermart/data/database"
+	"go-market/internal/gophermart/expirymonitor"
 	"go-market/internal/gophermart/ordersmonitor"
+	"go-market/internal/gophermart/service"
+	"go-market/pkg/aimd"
+	"go-market/pkg/circuitbreaker"
+	"go-market/pkg/pgxstorage"
+	"go-market/pkg/timeutils"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 const (
@@ -20,23 +33,131 @@ const (
 	dbConnectionStringFlag      = "d"
 	dbConnectionStringEnv       = "DATABASE_URI"
 	dbConnectionStringDefault   = ""
+	skipMigrationsFlag          = "skip-migrations"
+	skipMigrationsEnv           = "SKIP_MIGRATIONS"
+	accrualSystemClientCertEnv  = "ACCRUAL_SYSTEM_CLIENT_CERT"
+	accrualSystemClientKeyEnv   = "ACCRUAL_SYSTEM_CLIENT_KEY"
+	accrualSystemCAEnv          = "ACCRUAL_SYSTEM_CA"
+	accrualSystemRateLimitEnv   = "ACCRUAL_SYSTEM_RATE_LIMIT"
+	accrualRoutesEnv            = "ACCRUAL_ROUTES"
+	accrualDefaultProviderEnv   = "ACCRUAL_DEFAULT_PROVIDER"
+	accrualStaticRulesEnv       = "ACCRUAL_STATIC_RULES"
+	accrualSystemGRPCAddressEnv = "ACCRUAL_SYSTEM_GRPC_ADDRESS"
+	instanceIDEnv               = "INSTANCE_ID"
+	dbReplicaURIEnv             = "DATABASE_REPLICA_URI"
+	dbReplicaMaxLagEnv          = "DATABASE_REPLICA_MAX_LAG"
+	dbMaxConnsEnv               = "DATABASE_MAX_CONNS"
+	dbMinConnsEnv               = "DATABASE_MIN_CONNS"
+	dbMaxConnLifetimeEnv        = "DATABASE_MAX_CONN_LIFETIME"
+	dbMaxConnIdleTimeEnv        = "DATABASE_MAX_CONN_IDLE_TIME"
+	dbHealthCheckPeriodEnv      = "DATABASE_HEALTH_CHECK_PERIOD"
+	dbQueryExecModeEnv          = "DATABASE_QUERY_EXEC_MODE"
+	dbApplicationNameEnv        = "DATABASE_APPLICATION_NAME"
+	dbStatementTimeoutEnv       = "DATABASE_STATEMENT_TIMEOUT"
+	pointsLifetimeMonthsEnv     = "POINTS_LIFETIME_MONTHS"
+	pointsExpiryNoticeEnv       = "POINTS_EXPIRY_NOTICE"
+	holdDefaultTTLEnv           = "HOLD_DEFAULT_TTL"
+	holdMaxTTLEnv               = "HOLD_MAX_TTL"
+	transferDailyLimitEnv       = "TRANSFER_DAILY_LIMIT"
+	adminTokenEnv               = "ADMIN_TOKEN"
+	negativeBalancePolicyEnv    = "NEGATIVE_BALANCE_POLICY"
+	loginPatternEnv             = "LOGIN_PATTERN"
+	loginMinLengthEnv           = "LOGIN_MIN_LENGTH"
+	loginMaxLengthEnv           = "LOGIN_MAX_LENGTH"
+	passwordMinLengthEnv        = "PASSWORD_MIN_LENGTH"
+	passwordMinClassesEnv       = "PASSWORD_MIN_CHARACTER_CLASSES"
+	breachedPasswordsFileEnv    = "BREACHED_PASSWORDS_FILE"
 
 	defaultWorkersCount     = 5
 	defaultTaskBufferLength = 10
 
-	defaultShutdownTimeout = 5 * time.Second
-	defaultTickPeriod      = 3 * time.Second
+	defaultShutdownTimeout  = 5 * time.Second
+	defaultTickPeriod       = 3 * time.Second
+	defaultMaxOrderAge      = 72 * time.Hour
+	defaultStuckCheckPeriod = 10 * time.Minute
+	defaultLeaseDuration    = 2 * time.Minute
+
+	defaultPointsLifetimeMonths = 12
+	defaultPointsExpiryNotice   = 30 * 24 * time.Hour
+	defaultExpiryTickPeriod     = time.Minute
+	defaultExpiryBatchSize      = 100
+	defaultHoldTTL              = 15 * time.Minute
+	defaultHoldMaxTTL           = 7 * 24 * time.Hour
+	defaultTransferDailyLimit   = 10000
+
+	defaultLoginPattern       = `^[a-zA-Z0-9._-]+$`
+	defaultLoginMinLength     = 3
+	defaultLoginMaxLength     = service.MaxLoginLength
+	defaultPasswordMinLength  = 8
+	defaultPasswordMinClasses = 2
+
+	defaultReplicaMaxLag         = 5 * time.Second
+	defaultReplicaLagCheckPeriod = time.Second
+
+	defaultDBMaxConns          = 2*defaultWorkersCount + 10
+	defaultDBMinConns          = 2
+	defaultDBMaxConnLifetime   = time.Hour
+	defaultDBMaxConnIdleTime   = 5 * time.Minute
+	defaultDBHealthCheckPeriod = time.Minute
+	defaultDBQueryExecMode     = "cache_statement"
+	defaultDBApplicationName   = "gophermart"
+	defaultDBStatementTimeout  = 30 * time.Second
+
+	defaultPollBackoffInitialInterval = defaultTickPeriod
+	defaultPollBackoffMaxInterval     = 10 * time.Minute
+	defaultPollBackoffMultiplier      = 2
+
+	defaultCircuitBreakerWindow              = 10 * time.Second
+	defaultCircuitBreakerMinRequests         = 5
+	defaultCircuitBreakerFailureRatio        = 0.5
+	defaultCircuitBreakerOpenTimeout         = 15 * time.Second
+	defaultCircuitBreakerHalfOpenMaxRequests = 1
+
+	defaultConcurrencyInitialLimit = defaultWorkersCount
+	defaultConcurrencyMinLimit     = 1
+	defaultConcurrencyMaxLimit     = 2 * defaultWorkersCount
+	defaultConcurrencyBackoffRatio = 0.5
+
+	defaultAccrualRequestTimeout  = 10 * time.Second
+	defaultAccrualDialTimeout     = 3 * time.Second
+	defaultAccrualKeepAlive       = 30 * time.Second
+	defaultAccrualIdleConnTimeout = 90 * time.Second
+	defaultAccrualMaxIdleConns    = 2 * defaultWorkersCount
+	defaultAccrualMaxConnsPerHost = 2 * defaultWorkersCount
 )
 
-var defaultRetryAttempts = []time.Duration{time.Second, 3 * time.Second, 5 * time.Second}
+const (
+	defaultRetryInitialInterval = time.Second
+	defaultRetryMaxInterval     = 5 * time.Second
+	defaultRetryMaxElapsedTime  = 15 * time.Second
+	defaultRetryMultiplier      = 3
+	defaultRetryMaxAttempts     = 3
+	defaultRetryBudgetRatio     = 0.2
+	defaultRetryBudgetMaxTokens = 10
+
+	defaultTxRetryInitialInterval = 10 * time.Millisecond
+	defaultTxRetryMaxInterval     = 200 * time.Millisecond
+	defaultTxRetryMultiplier      = 2
+	defaultTxRetryMaxAttempts     = 10
+)
 
 type Config struct {
-	DB              database.Config
-	AccrualSystem   accrualsystem.Config
-	JWTConfig       JWTConfig
-	Server          gophermart.Config
-	OrdersMonitor   ordersmonitor.Config
-	ShutdownTimeout time.Duration
+	DB                  database.Config
+	AccrualSystem       accrualsystem.Config
+	StaticAccrualSystem accrualsystem.StaticConfig
+	GRPCAccrualSystem   accrualsystem.GRPCConfig
+	JWTConfig           JWTConfig
+	Server              gophermart.Config
+	OrdersMonitor       ordersmonitor.Config
+	ExpiryMonitor       expirymonitor.Config
+	Wallet              service.WalletConfig
+	Holds               service.HoldsConfig
+	Transfers           service.TransfersConfig
+	Adjustments         service.AdjustmentsConfig
+	Authorization       service.AuthorizationConfig
+	// BreachedPasswordsFile is a sorted list of SHA-1 hashes of leaked passwords, empty disables the check.
+	BreachedPasswordsFile string
+	ShutdownTimeout       time.Duration
 }
 
 type JWTConfig struct {
@@ -64,6 +185,12 @@ func Load() (*Config, error) {
 		"PostgreSQL connection string",
 	)
 
+	skipMigrations := flag.Bool(
+		skipMigrationsFlag,
+		false,
+		"Do not apply DB migrations on start",
+	)
+
 	flag.Parse()
 
 	if valStr, ok := os.LookupEnv(serverAddressEnv); ok {
@@ -78,10 +205,108 @@ func Load() (*Config, error) {
 		*dbConnectionString = valStr
 	}
 
+	if valStr, ok := os.LookupEnv(skipMigrationsEnv); ok {
+		val, err := strconv.ParseBool(valStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", skipMigrationsEnv, err)
+		}
+		*skipMigrations = val
+	}
+
+	accrualSystemRateLimit := 0
+	if valStr, ok := os.LookupEnv(accrualSystemRateLimitEnv); ok {
+		val, err := strconv.Atoi(valStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", accrualSystemRateLimitEnv, err)
+		}
+		accrualSystemRateLimit = val
+	}
+
+	accrualRoutes, err := parseAccrualRoutes(os.Getenv(accrualRoutesEnv))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", accrualRoutesEnv, err)
+	}
+
+	accrualDefaultProvider := accrualsystem.HTTPProviderName
+	if valStr, ok := os.LookupEnv(accrualDefaultProviderEnv); ok {
+		accrualDefaultProvider = valStr
+	}
+
+	staticAccrualRules, err := parseStaticAccrualRules(os.Getenv(accrualStaticRulesEnv))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", accrualStaticRulesEnv, err)
+	}
+
+	instanceID, err := defaultInstanceID()
+	if err != nil {
+		return nil, err
+	}
+	if valStr, ok := os.LookupEnv(instanceIDEnv); ok {
+		instanceID = valStr
+	}
+
+	replicaMaxLag, err := lookupDurationEnv(dbReplicaMaxLagEnv, defaultReplicaMaxLag)
+	if err != nil {
+		return nil, err
+	}
+
+	pointsLifetimeMonths, err := lookupIntEnv(pointsLifetimeMonthsEnv, defaultPointsLifetimeMonths)
+	if err != nil {
+		return nil, err
+	}
+	pointsExpiryNotice, err := lookupDurationEnv(pointsExpiryNoticeEnv, defaultPointsExpiryNotice)
+	if err != nil {
+		return nil, err
+	}
+
+	holdDefaultTTL, err := lookupDurationEnv(holdDefaultTTLEnv, defaultHoldTTL)
+	if err != nil {
+		return nil, err
+	}
+	holdMaxTTL, err := lookupDurationEnv(holdMaxTTLEnv, defaultHoldMaxTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	transferDailyLimit, err := lookupDecimalEnv(transferDailyLimitEnv, decimal.NewFromInt(defaultTransferDailyLimit))
+	if err != nil {
+		return nil, err
+	}
+
+	negativeBalancePolicy := service.DenyNegativeBalance
+	if valStr, ok := os.LookupEnv(negativeBalancePolicyEnv); ok {
+		negativeBalancePolicy = service.NegativeBalancePolicy(valStr)
+		switch negativeBalancePolicy {
+		case service.DenyNegativeBalance, service.AllowNegativeBalance:
+		default:
+			return nil, fmt.Errorf("failed to parse %s: unknown policy %q", negativeBalancePolicyEnv, valStr)
+		}
+	}
+
+	credentialsPolicy, err := loadCredentialsPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	poolConfig, err := loadPoolConfig(fmt.Sprintf("%s-%s", defaultDBApplicationName, instanceID))
+	if err != nil {
+		return nil, err
+	}
+
+	// the DB and the accrual system share one budget, so retries stay within the ratio for the process as a whole
+	retryBudget := timeutils.NewRetryBudget(defaultRetryBudgetRatio, defaultRetryBudgetMaxTokens)
+
+	accrualSystemTLS := accrualsystem.TLSConfig{
+		CertFile: os.Getenv(accrualSystemClientCertEnv),
+		KeyFile:  os.Getenv(accrualSystemClientKeyEnv),
+		CAFile:   os.Getenv(accrualSystemCAEnv),
+	}
+
 	return &Config{
 		Server: gophermart.Config{
 			ServerAddress:   *serverAddress,
 			ShutdownTimeout: defaultShutdownTimeout,
+			AdminToken:      os.Getenv(adminTokenEnv),
 		},
 		JWTConfig: JWTConfig{
 			Algorithm:      "HS256",
@@ -89,18 +314,330 @@ func Load() (*Config, error) {
 			ExpirationTime: time.Hour,
 		},
 		DB: database.Config{
-			ConnectionString:   *dbConnectionString,
-			RetryAttemptDelays: defaultRetryAttempts,
+			ConnectionString:        *dbConnectionString,
+			ReplicaConnectionString: os.Getenv(dbReplicaURIEnv),
+			Replica: pgxstorage.ReplicaConfig{
+				MaxLag:         replicaMaxLag,
+				LagCheckPeriod: defaultReplicaLagCheckPeriod,
+			},
+			RetryPolicy:    newDefaultRetryPolicy(retryBudget),
+			Pool:           poolConfig,
+			SkipMigrations: *skipMigrations,
+			TransactionRetryPolicy: timeutils.RetryPolicy{
+				InitialInterval: defaultTxRetryInitialInterval,
+				MaxInterval:     defaultTxRetryMaxInterval,
+				Multiplier:      defaultTxRetryMultiplier,
+				Jitter:          timeutils.FullJitter,
+				MaxAttempts:     defaultTxRetryMaxAttempts,
+			},
 		},
 		ShutdownTimeout: defaultShutdownTimeout,
 		OrdersMonitor: ordersmonitor.Config{
-			TickPeriod:        defaultTickPeriod,
-			WorkersCount:      defaultWorkersCount,
-			TasksBufferLength: defaultTaskBufferLength,
+			TickPeriod:           defaultTickPeriod,
+			WorkersCount:         defaultWorkersCount,
+			TasksBufferLength:    defaultTaskBufferLength,
+			MaxOrderAge:          defaultMaxOrderAge,
+			StuckCheckPeriod:     defaultStuckCheckPeriod,
+			InstanceID:           instanceID,
+			LeaseDuration:        defaultLeaseDuration,
+			PointsLifetimeMonths: pointsLifetimeMonths,
+			PollBackoff: timeutils.RetryPolicy{
+				InitialInterval: defaultPollBackoffInitialInterval,
+				MaxInterval:     defaultPollBackoffMaxInterval,
+				Multiplier:      defaultPollBackoffMultiplier,
+				Jitter:          timeutils.NoJitter,
+			},
+			Router: ordersmonitor.RouterConfig{
+				Routes:          accrualRoutes,
+				DefaultProvider: accrualDefaultProvider,
+			},
+		},
+		ExpiryMonitor: expirymonitor.Config{
+			TickPeriod: defaultExpiryTickPeriod,
+			BatchSize:  defaultExpiryBatchSize,
+		},
+		Wallet: service.WalletConfig{
+			ExpiryNotice: pointsExpiryNotice,
+		},
+		Holds: service.HoldsConfig{
+			DefaultTTL: holdDefaultTTL,
+			MaxTTL:     holdMaxTTL,
+		},
+		Transfers: service.TransfersConfig{
+			DailyLimit: transferDailyLimit,
+		},
+		Adjustments: service.AdjustmentsConfig{
+			NegativeBalancePolicy: negativeBalancePolicy,
+			PointsLifetimeMonths:  pointsLifetimeMonths,
+		},
+		Authorization: service.AuthorizationConfig{
+			Credentials: credentialsPolicy,
+		},
+		BreachedPasswordsFile: os.Getenv(breachedPasswordsFileEnv),
+		StaticAccrualSystem: accrualsystem.StaticConfig{
+			Rules: staticAccrualRules,
+		},
+		GRPCAccrualSystem: accrualsystem.GRPCConfig{
+			Address:        os.Getenv(accrualSystemGRPCAddressEnv),
+			RequestTimeout: defaultAccrualRequestTimeout,
+			TLS:            accrualSystemTLS,
 		},
 		AccrualSystem: accrualsystem.Config{
 			ServerAddress:      *accrualSystemAddress,
-			RetryAttemptDelays: defaultRetryAttempts,
+			RetryPolicy:        newDefaultRetryPolicy(retryBudget),
+			RateLimitPerMinute: accrualSystemRateLimit,
+			HTTPClient: accrualsystem.HTTPClientConfig{
+				RequestTimeout:      defaultAccrualRequestTimeout,
+				DialTimeout:         defaultAccrualDialTimeout,
+				KeepAlive:           defaultAccrualKeepAlive,
+				IdleConnTimeout:     defaultAccrualIdleConnTimeout,
+				MaxIdleConns:        defaultAccrualMaxIdleConns,
+				MaxIdleConnsPerHost: defaultAccrualMaxIdleConns,
+				MaxConnsPerHost:     defaultAccrualMaxConnsPerHost,
+				TLS:                 accrualSystemTLS,
+			},
+			CircuitBreaker: circuitbreaker.Config{
+				Window:              defaultCircuitBreakerWindow,
+				MinRequests:         defaultCircuitBreakerMinRequests,
+				FailureRatio:        defaultCircuitBreakerFailureRatio,
+				OpenTimeout:         defaultCircuitBreakerOpenTimeout,
+				HalfOpenMaxRequests: defaultCircuitBreakerHalfOpenMaxRequests,
+			},
+			ConcurrencyLimit: aimd.Config{
+				InitialLimit: defaultConcurrencyInitialLimit,
+				MinLimit:     defaultConcurrencyMinLimit,
+				MaxLimit:     defaultConcurrencyMaxLimit,
+				BackoffRatio: defaultConcurrencyBackoffRatio,
+			},
 		},
 	}, nil
 }
+
+type MigrationConfig struct {
+	ConnectionString string
+	// Args are the subcommand and its arguments, e.g. "down 1".
+	Args []string
+}
+
+func LoadMigration(args []string) (*MigrationConfig, error) {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dbConnectionString := flags.String(
+		dbConnectionStringFlag,
+		dbConnectionStringDefault,
+		"PostgreSQL connection string",
+	)
+	if err := flags.Parse(args); err != nil {
+		return nil, fmt.Errorf("failed to parse migrate flags: %w", err)
+	}
+	if valStr, ok := os.LookupEnv(dbConnectionStringEnv); ok {
+		*dbConnectionString = valStr
+	}
+	return &MigrationConfig{
+		ConnectionString: *dbConnectionString,
+		Args:             flags.Args(),
+	}, nil
+}
+
+// parseAccrualRoutes parses routes in "prefix=provider,prefix=provider" format.
+func parseAccrualRoutes(value string) ([]ordersmonitor.AccrualRoute, error) {
+	res := make([]ordersmonitor.AccrualRoute, 0)
+	if value == "" {
+		return res, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		prefix, provider, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok || provider == "" {
+			return nil, fmt.Errorf("invalid route %q", item)
+		}
+		res = append(res, ordersmonitor.AccrualRoute{
+			Prefix:   prefix,
+			Provider: provider,
+		})
+	}
+	return res, nil
+}
+
+// parseStaticAccrualRules parses rules in "prefix=STATUS:accrual:program,prefix=STATUS" format.
+func parseStaticAccrualRules(value string) ([]accrualsystem.StaticRule, error) {
+	res := make([]accrualsystem.StaticRule, 0)
+	if value == "" {
+		return res, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		prefix, outcome, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule %q", item)
+		}
+		status, accrualStr, _ := strings.Cut(outcome, ":")
+		accrualStr, program, _ := strings.Cut(accrualStr, ":")
+		rule := accrualsystem.StaticRule{
+			Prefix:  prefix,
+			Status:  accrualsystemprotocol.OrderStatus(status),
+			Accrual: decimal.Zero,
+			Program: program,
+		}
+		switch rule.Status {
+		case accrualsystemprotocol.Registered,
+			accrualsystemprotocol.Invalid,
+			accrualsystemprotocol.Processing,
+			accrualsystemprotocol.Processed:
+		default:
+			return nil, fmt.Errorf("invalid status in rule %q", item)
+		}
+		if accrualStr != "" {
+			accrual, err := decimal.NewFromString(accrualStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid accrual in rule %q: %w", item, err)
+			}
+			rule.Accrual = accrual
+		}
+		res = append(res, rule)
+	}
+	return res, nil
+}
+
+func loadCredentialsPolicy() (service.CredentialsPolicy, error) {
+	loginPattern := defaultLoginPattern
+	if valStr, ok := os.LookupEnv(loginPatternEnv); ok {
+		loginPattern = valStr
+	}
+	var loginRegexp *regexp.Regexp
+	if loginPattern != "" {
+		var err error
+		loginRegexp, err = regexp.Compile(loginPattern)
+		if err != nil {
+			return service.CredentialsPolicy{}, fmt.Errorf("failed to parse %s: %w", loginPatternEnv, err)
+		}
+	}
+	loginMinLength, err := lookupIntEnv(loginMinLengthEnv, defaultLoginMinLength)
+	if err != nil {
+		return service.CredentialsPolicy{}, err
+	}
+	loginMaxLength, err := lookupIntEnv(loginMaxLengthEnv, defaultLoginMaxLength)
+	if err != nil {
+		return service.CredentialsPolicy{}, err
+	}
+	if loginMaxLength > service.MaxLoginLength {
+		return service.CredentialsPolicy{}, fmt.Errorf(
+			"failed to parse %s: logins longer than %d characters can't be stored",
+			loginMaxLengthEnv,
+			service.MaxLoginLength,
+		)
+	}
+	passwordMinLength, err := lookupIntEnv(passwordMinLengthEnv, defaultPasswordMinLength)
+	if err != nil {
+		return service.CredentialsPolicy{}, err
+	}
+	passwordMinClasses, err := lookupIntEnv(passwordMinClassesEnv, defaultPasswordMinClasses)
+	if err != nil {
+		return service.CredentialsPolicy{}, err
+	}
+	return service.CredentialsPolicy{
+		LoginPattern:                loginRegexp,
+		LoginMinLength:              loginMinLength,
+		LoginMaxLength:              loginMaxLength,
+		PasswordMinLength:           passwordMinLength,
+		PasswordMinCharacterClasses: passwordMinClasses,
+	}, nil
+}
+
+func loadPoolConfig(defaultApplicationName string) (database.PoolConfig, error) {
+	maxConns, err := lookupIntEnv(dbMaxConnsEnv, defaultDBMaxConns)
+	if err != nil {
+		return database.PoolConfig{}, err
+	}
+	minConns, err := lookupIntEnv(dbMinConnsEnv, defaultDBMinConns)
+	if err != nil {
+		return database.PoolConfig{}, err
+	}
+	maxConnLifetime, err := lookupDurationEnv(dbMaxConnLifetimeEnv, defaultDBMaxConnLifetime)
+	if err != nil {
+		return database.PoolConfig{}, err
+	}
+	maxConnIdleTime, err := lookupDurationEnv(dbMaxConnIdleTimeEnv, defaultDBMaxConnIdleTime)
+	if err != nil {
+		return database.PoolConfig{}, err
+	}
+	healthCheckPeriod, err := lookupDurationEnv(dbHealthCheckPeriodEnv, defaultDBHealthCheckPeriod)
+	if err != nil {
+		return database.PoolConfig{}, err
+	}
+	statementTimeout, err := lookupDurationEnv(dbStatementTimeoutEnv, defaultDBStatementTimeout)
+	if err != nil {
+		return database.PoolConfig{}, err
+	}
+	queryExecMode := defaultDBQueryExecMode
+	if valStr, ok := os.LookupEnv(dbQueryExecModeEnv); ok {
+		queryExecMode = valStr
+	}
+	applicationName := defaultApplicationName
+	if valStr, ok := os.LookupEnv(dbApplicationNameEnv); ok {
+		applicationName = valStr
+	}
+	return database.PoolConfig{
+		MaxConns:          int32(maxConns), //nolint:gosec // small config value
+		MinConns:          int32(minConns), //nolint:gosec // small config value
+		MaxConnLifetime:   maxConnLifetime,
+		MaxConnIdleTime:   maxConnIdleTime,
+		HealthCheckPeriod: healthCheckPeriod,
+		QueryExecMode:     queryExecMode,
+		ApplicationName:   applicationName,
+		StatementTimeout:  statementTimeout,
+	}, nil
+}
+
+func lookupIntEnv(name string, defaultValue int) (int, error) {
+	valStr, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultValue, nil
+	}
+	val, err := strconv.Atoi(valStr)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return val, nil
+}
+
+func lookupDurationEnv(name string, defaultValue time.Duration) (time.Duration, error) {
+	valStr, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultValue, nil
+	}
+	val, err := time.ParseDuration(valStr)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return val, nil
+}
+
+func lookupDecimalEnv(name string, defaultValue decimal.Decimal) (decimal.Decimal, error) {
+	valStr, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultValue, nil
+	}
+	val, err := decimal.NewFromString(valStr)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return val, nil
+}
+
+func newDefaultRetryPolicy(budget *timeutils.RetryBudget) timeutils.RetryPolicy {
+	return timeutils.RetryPolicy{
+		Budget:          budget,
+		InitialInterval: defaultRetryInitialInterval,
+		MaxInterval:     defaultRetryMaxInterval,
+		MaxElapsedTime:  defaultRetryMaxElapsedTime,
+		Multiplier:      defaultRetryMultiplier,
+		Jitter:          timeutils.FullJitter,
+		MaxAttempts:     defaultRetryMaxAttempts,
+	}
+}
+
+func defaultInstanceID() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to get hostname: %w", err)
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid()), nil
+}
@@ -9,14 +9,18 @@ import (
 	"go-market/internal/gophermart/accrualsystem"
 	"go-market/internal/gophermart/data/database"
 	"go-market/internal/gophermart/data/dbrepository"
+	"go-market/internal/gophermart/expirymonitor"
 	"go-market/internal/gophermart/ordersmonitor"
 	"go-market/internal/gophermart/service"
+	"go-market/pkg/hashlist"
 	"go-market/pkg/jwtfactory"
 	"go-market/pkg/logging"
 	"go-market/pkg/pgxstorage"
 	"log"
+	"os"
 	"os/signal"
 	"syscall"
+	_ "time/tzdata" // Accept-Timezone must resolve without system zoneinfo
 
 	"github.com/go-chi/jwtauth/v5"
 	"go.uber.org/zap"
@@ -25,6 +29,16 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == migrateCommand {
+		if err := migrateMain(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	serve()
+}
+
+func serve() {
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatal(err)
@@ -43,30 +57,96 @@ func main() {
 	logger.InfoCtx(context.Background(), "Configuration", zap.String("config", string(jsCfg)))
 
 	dbFactory := database.NewPgxDatabaseFactory(cfg.DB)
-	storage, err := pgxstorage.New(dbFactory, cfg.DB.RetryAttemptDelays)
+	storage, err := pgxstorage.New(dbFactory, cfg.DB.RetryPolicy, cfg.DB.Replica)
 	if err != nil {
 		log.Fatal(err)
 	}
+	// closed after run returns, when the server and the orders monitor have drained
+	defer func() {
+		logger.InfoCtx(context.Background(), "Closing database connections")
+		storage.Close()
+	}()
 	repository := dbrepository.New(storage, logger)
-	transactionManager := pgxstorage.NewTransactionsManager(storage)
+	transactionManager := pgxstorage.NewTransactionsManager(storage, cfg.DB.TransactionRetryPolicy)
 
 	tokenAuth := jwtauth.New(cfg.JWTConfig.Algorithm, []byte(cfg.JWTConfig.Secret), nil)
 	tokenFactory := jwtfactory.New(tokenAuth, cfg.JWTConfig.ExpirationTime)
 
-	authorization := service.NewAuthorization(repository, transactionManager, tokenFactory)
+	var breachedPasswords service.BreachedPasswords
+	if cfg.BreachedPasswordsFile != "" {
+		hashList, err := hashlist.Open(cfg.BreachedPasswordsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer func() {
+			if err := hashList.Close(); err != nil {
+				logger.ErrorCtx(context.Background(), "Failed to close breached passwords file", zap.Error(err))
+			}
+		}()
+		breachedPasswords = hashList
+	}
+	authorization := service.NewAuthorization(
+		cfg.Authorization,
+		repository,
+		transactionManager,
+		tokenFactory,
+		breachedPasswords,
+	)
 	orders := service.NewOrders(transactionManager, repository)
-	wallet := service.NewWallet(transactionManager, repository, logger)
-	accrualSystem := accrualsystem.NewAccrualSystem(cfg.AccrualSystem, logger)
+	wallet := service.NewWallet(cfg.Wallet, transactionManager, repository, logger)
+	holds := service.NewHolds(cfg.Holds, transactionManager, repository, logger)
+	transfers := service.NewTransfers(cfg.Transfers, transactionManager, repository, logger)
+	adjustments := service.NewAdjustments(cfg.Adjustments, transactionManager, repository, logger)
+	statement := service.NewStatement(repository)
+	accrualSystem, err := accrualsystem.NewAccrualSystem(cfg.AccrualSystem, logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+	accrualProviders := map[string]ordersmonitor.AccrualSystem{
+		accrualsystem.HTTPProviderName:   accrualSystem,
+		accrualsystem.StaticProviderName: accrualsystem.NewStaticAccrualSystem(cfg.StaticAccrualSystem),
+	}
+	if cfg.GRPCAccrualSystem.Address != "" {
+		grpcAccrualSystem, err := accrualsystem.NewGRPCAccrualSystem(cfg.GRPCAccrualSystem, logger)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer func() {
+			if err := grpcAccrualSystem.Close(); err != nil {
+				logger.ErrorCtx(context.Background(), "Failed to close grpc accrual system", zap.Error(err))
+			}
+		}()
+		accrualProviders[accrualsystem.GRPCProviderName] = grpcAccrualSystem
+	}
+	accrualRouter, err := ordersmonitor.NewAccrualRouter(cfg.OrdersMonitor.Router, accrualProviders)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	server := gophermart.NewServer(cfg.Server, tokenAuth, authorization, orders, wallet, logger)
+	server, err := gophermart.NewServer(
+		cfg.Server,
+		tokenAuth,
+		authorization,
+		orders,
+		wallet,
+		holds,
+		transfers,
+		adjustments,
+		statement,
+		logger,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
 	ordersMonitor := ordersmonitor.NewOrdersMonitor(
 		cfg.OrdersMonitor,
 		repository,
 		repository,
 		transactionManager,
-		accrualSystem,
+		accrualRouter,
 		logger,
 	)
+	expiryMonitor := expirymonitor.NewExpiryMonitor(cfg.ExpiryMonitor, repository, logger)
 
 	rootCtx, cancelCtx := signal.NotifyContext(
 		context.Background(),
@@ -78,7 +158,7 @@ func main() {
 	)
 	defer cancelCtx()
 
-	if err := run(rootCtx, cfg, server, ordersMonitor, logger); err != nil {
+	if err := run(rootCtx, cfg, server, ordersMonitor, expiryMonitor, logger); err != nil {
 		logger.ErrorCtx(rootCtx, "Server shutdown with error", zap.Error(err))
 	} else {
 		logger.InfoCtx(rootCtx, "Server shutdown gracefully")
@@ -90,6 +170,7 @@ func run(
 	cfg *config.Config,
 	server *gophermart.Server,
 	ordersMonitor *ordersmonitor.OrdersMonitor,
+	expiryMonitor *expirymonitor.ExpiryMonitor,
 	logger *logging.ZapLogger,
 ) error {
 	g, ctx := errgroup.WithContext(rootCtx)
@@ -130,6 +211,18 @@ func run(
 		return nil
 	})
 
+	g.Go(func() error {
+		expiryMonitor.Run()
+		return nil
+	})
+
+	g.Go(func() error {
+		defer logger.InfoCtx(ctx, "Shutting down expiry monitor")
+		<-ctx.Done()
+		expiryMonitor.Stop()
+		return nil
+	})
+
 	if err := g.Wait(); err != nil {
 		return fmt.Errorf("goroutine error occured: %w", err)
 	}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-market/cmd/gophermart/config"
+	"go-market/internal/gophermart/data/database"
+	"os/signal"
+	"strconv"
+	"syscall"
+)
+
+const migrateCommand = "migrate"
+
+const migrateUsage = "usage: gophermart migrate [-d dsn] up | down [steps] | status | force <version>"
+
+var errMigrateUsage = errors.New(migrateUsage)
+
+func migrateMain(args []string) error {
+	cfg, err := config.LoadMigration(args)
+	if err != nil {
+		return err //nolint:wrapcheck // unnecessary
+	}
+	if len(cfg.Args) == 0 {
+		return errMigrateUsage
+	}
+
+	ctx, cancelCtx := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancelCtx()
+
+	migrator := database.NewMigrator(cfg.ConnectionString)
+	command, commandArgs := cfg.Args[0], cfg.Args[1:]
+	switch command {
+	case "up":
+		return migrator.Up(ctx) //nolint:wrapcheck // unnecessary
+	case "down":
+		steps := 1
+		if len(commandArgs) > 0 {
+			steps, err = strconv.Atoi(commandArgs[0])
+			if err != nil {
+				return fmt.Errorf("invalid number of steps: %w", err)
+			}
+		}
+		return migrator.Down(ctx, steps) //nolint:wrapcheck // unnecessary
+	case "force":
+		if len(commandArgs) == 0 {
+			return errMigrateUsage
+		}
+		version, err := strconv.Atoi(commandArgs[0])
+		if err != nil {
+			return fmt.Errorf("invalid version: %w", err)
+		}
+		return migrator.Force(ctx, version) //nolint:wrapcheck // unnecessary
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err //nolint:wrapcheck // unnecessary
+		}
+		if !status.Applied {
+			fmt.Printf("version: none, latest: %d\n", status.Latest)
+			return nil
+		}
+		fmt.Printf("version: %d, dirty: %t, latest: %d\n", status.Version, status.Dirty, status.Latest)
+		return nil
+	default:
+		return errMigrateUsage
+	}
+}
@@ -0,0 +1,129 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: accrual_system.proto
+
+package accrualsystempb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AccrualSystem_GetOrder_FullMethodName = "/gophermart.accrualsystem.v1.AccrualSystem/GetOrder"
+)
+
+// AccrualSystemClient is the client API for AccrualSystem service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AccrualSystem mirrors the HTTP accrual system API.
+type AccrualSystemClient interface {
+	// GetOrder returns NOT_FOUND for unknown orders
+	// and RESOURCE_EXHAUSTED with google.rpc.RetryInfo when the rate limit is hit.
+	GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error)
+}
+
+type accrualSystemClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAccrualSystemClient(cc grpc.ClientConnInterface) AccrualSystemClient {
+	return &accrualSystemClient{cc}
+}
+
+func (c *accrualSystemClient) GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Order)
+	err := c.cc.Invoke(ctx, AccrualSystem_GetOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AccrualSystemServer is the server API for AccrualSystem service.
+// All implementations must embed UnimplementedAccrualSystemServer
+// for forward compatibility.
+//
+// AccrualSystem mirrors the HTTP accrual system API.
+type AccrualSystemServer interface {
+	// GetOrder returns NOT_FOUND for unknown orders
+	// and RESOURCE_EXHAUSTED with google.rpc.RetryInfo when the rate limit is hit.
+	GetOrder(context.Context, *GetOrderRequest) (*Order, error)
+	mustEmbedUnimplementedAccrualSystemServer()
+}
+
+// UnimplementedAccrualSystemServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAccrualSystemServer struct{}
+
+func (UnimplementedAccrualSystemServer) GetOrder(context.Context, *GetOrderRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrder not implemented")
+}
+func (UnimplementedAccrualSystemServer) mustEmbedUnimplementedAccrualSystemServer() {}
+func (UnimplementedAccrualSystemServer) testEmbeddedByValue()                       {}
+
+// UnsafeAccrualSystemServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AccrualSystemServer will
+// result in compilation errors.
+type UnsafeAccrualSystemServer interface {
+	mustEmbedUnimplementedAccrualSystemServer()
+}
+
+func RegisterAccrualSystemServer(s grpc.ServiceRegistrar, srv AccrualSystemServer) {
+	// If the following call pancis, it indicates UnimplementedAccrualSystemServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AccrualSystem_ServiceDesc, srv)
+}
+
+func _AccrualSystem_GetOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AccrualSystemServer).GetOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AccrualSystem_GetOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AccrualSystemServer).GetOrder(ctx, req.(*GetOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AccrualSystem_ServiceDesc is the grpc.ServiceDesc for AccrualSystem service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AccrualSystem_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gophermart.accrualsystem.v1.AccrualSystem",
+	HandlerType: (*AccrualSystemServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetOrder",
+			Handler:    _AccrualSystem_GetOrder_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "accrual_system.proto",
+}
@@ -15,4 +15,6 @@ type Order struct {
 	Number  string          `json:"order"`
 	Status  OrderStatus     `json:"status"`
 	Accrual decimal.Decimal `json:"accrual"`
+	// Program is the loyalty program the accrual belongs to, empty when the accrual system does not tell.
+	Program string `json:"program,omitempty"`
 }
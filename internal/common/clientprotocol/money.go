@@ -0,0 +1,91 @@
+package clientprotocol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// MoneyScale is the number of decimal places of money amounts, it matches DECIMAL(16, 3) columns.
+const MoneyScale = 3
+
+// MoneyFormatParam is the Accept media type parameter selecting the money format, e.g. "application/json; money=string".
+const MoneyFormatParam = "money"
+
+type MoneyFormat int
+
+const (
+	// MoneyNumber renders amounts as exact JSON numbers, it is the default.
+	MoneyNumber MoneyFormat = iota
+	// MoneyString renders amounts as decimal strings with MoneyScale places.
+	MoneyString
+	// MoneyMinor renders amounts as integer thousandths.
+	MoneyMinor
+)
+
+var (
+	ErrUnknownMoneyFormat = errors.New("unknown money format")
+	ErrNegativeMoney      = errors.New("negative money amount")
+	ErrMoneyPrecision     = fmt.Errorf("money amount has more than %d decimal places", MoneyScale)
+)
+
+func ParseMoneyFormat(value string) (MoneyFormat, error) {
+	switch value {
+	case "", "number":
+		return MoneyNumber, nil
+	case "string":
+		return MoneyString, nil
+	case "minor":
+		return MoneyMinor, nil
+	default:
+		return MoneyNumber, fmt.Errorf("%w %q", ErrUnknownMoneyFormat, value)
+	}
+}
+
+// Money is an exact amount rendered in the format it was created with.
+// Decoded amounts are never negative and have at most MoneyScale decimal places.
+type Money struct {
+	amount decimal.Decimal
+	format MoneyFormat
+}
+
+func (f MoneyFormat) Money(amount decimal.Decimal) Money {
+	return Money{
+		amount: amount,
+		format: f,
+	}
+}
+
+func (m Money) Decimal() decimal.Decimal {
+	return m.amount
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	amount := m.amount.Round(MoneyScale)
+	switch m.format {
+	case MoneyString:
+		return []byte(`"` + amount.StringFixed(MoneyScale) + `"`), nil
+	case MoneyMinor:
+		return []byte(amount.Shift(MoneyScale).String()), nil
+	default:
+		return []byte(amount.String()), nil
+	}
+}
+
+// UnmarshalJSON accepts a JSON number or a decimal string.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	amount, err := decimal.NewFromString(string(bytes.Trim(b, `"`)))
+	if err != nil {
+		return fmt.Errorf("invalid money amount: %w", err)
+	}
+	if amount.IsNegative() {
+		return ErrNegativeMoney
+	}
+	if !amount.Equal(amount.Truncate(MoneyScale)) {
+		return ErrMoneyPrecision
+	}
+	m.amount = amount
+	return nil
+}
@@ -0,0 +1,51 @@
+package clientprotocol
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoneyMarshalJSON(t *testing.T) {
+	amount := decimal.RequireFromString("0.1").Add(decimal.RequireFromString("0.2"))
+	tests := []struct {
+		format   MoneyFormat
+		expected string
+	}{
+		{format: MoneyNumber, expected: `0.3`},
+		{format: MoneyString, expected: `"0.300"`},
+		{format: MoneyMinor, expected: `300`},
+	}
+	for _, test := range tests {
+		res, err := json.Marshal(test.format.Money(amount))
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, string(res))
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    string
+		expectedErr error
+	}{
+		{input: `751`, expected: "751"},
+		{input: `"729.98"`, expected: "729.98"},
+		{input: `1.2300`, expected: "1.23"},
+		{input: `0.0001`, expectedErr: ErrMoneyPrecision},
+		{input: `-5`, expectedErr: ErrNegativeMoney},
+	}
+	for _, test := range tests {
+		var money Money
+		err := json.Unmarshal([]byte(test.input), &money)
+		if test.expectedErr != nil {
+			assert.ErrorIs(t, err, test.expectedErr, test.input)
+			continue
+		}
+		require.NoError(t, err, test.input)
+		assert.True(t, decimal.RequireFromString(test.expected).Equal(money.Decimal()), test.input)
+	}
+}
@@ -18,5 +18,5 @@ type Order struct {
 	UploadedAt time.Time   `json:"uploaded_at"`
 	Number     string      `json:"number"`
 	Status     OrderStatus `json:"status"`
-	Accrual    float64     `json:"accrual"`
+	Accrual    Money       `json:"accrual"`
 }
@@ -6,9 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"go-market/internal/common/accrualsystemprotocol"
+	"go-market/pkg/aimd"
+	"go-market/pkg/circuitbreaker"
 	"go-market/pkg/logging"
 	"go-market/pkg/threadsafe"
 	"go-market/pkg/timeutils"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
@@ -20,39 +23,100 @@ import (
 var (
 	ErrNoOrderFound    = errors.New("no order found")
 	ErrTooManyRequests = errors.New("too many requests")
+	ErrCircuitOpen     = errors.New("accrual system circuit is open")
 )
 
 type Config struct {
-	ServerAddress      string
-	RetryAttemptDelays []time.Duration
+	ServerAddress string
+	RetryPolicy   timeutils.RetryPolicy
+	// RateLimitPerMinute is the known accrual system quota, zero if it should be learned from 429 responses.
+	RateLimitPerMinute int
+	HTTPClient         HTTPClientConfig
+	CircuitBreaker     circuitbreaker.Config
+	ConcurrencyLimit   aimd.Config
+}
+
+type Status struct {
+	AwakeTime          time.Time
+	CircuitState       circuitbreaker.State
+	ConcurrencyLimit   int
+	InFlight           int
+	RateLimitPerMinute int
 }
 
 type AccrualSystem struct {
 	logger                 *logging.ZapLogger
 	remoteServiceAwakeTime *threadsafe.Time
+	breaker                *circuitbreaker.CircuitBreaker
+	limiter                *aimd.Limiter
+	budget                 *requestBudget
+	client                 *resty.Client
 	cfg                    Config
 }
 
-func NewAccrualSystem(cfg Config, logger *logging.ZapLogger) *AccrualSystem {
+func NewAccrualSystem(cfg Config, logger *logging.ZapLogger) (*AccrualSystem, error) {
+	client, err := newRestyClient(cfg.ServerAddress, cfg.HTTPClient, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create http client: %w", err)
+	}
 	return &AccrualSystem{
 		cfg:                    cfg,
+		client:                 client,
 		logger:                 logger,
 		remoteServiceAwakeTime: threadsafe.NewTime(time.Now()),
-	}
+		breaker: circuitbreaker.New(cfg.CircuitBreaker, func(from, to circuitbreaker.State) {
+			logger.WarnCtx(
+				context.Background(),
+				"accrual system circuit breaker state changed",
+				zap.Stringer("from", from),
+				zap.Stringer("to", to),
+			)
+		}),
+		limiter: aimd.New(cfg.ConcurrencyLimit),
+		budget:  newRequestBudget(cfg.RateLimitPerMinute),
+	}, nil
 }
 
+// GetServiceAwakeTime returns the moment the accrual system is expected to accept requests again.
 func (as *AccrualSystem) GetServiceAwakeTime() time.Time {
-	return as.remoteServiceAwakeTime.Get()
+	return as.Status().AwakeTime
+}
+
+func (as *AccrualSystem) Status() Status {
+	awakeTime := as.remoteServiceAwakeTime.Get()
+	if openUntil := as.breaker.OpenUntil(); openUntil.After(awakeTime) {
+		awakeTime = openUntil
+	}
+	return Status{
+		AwakeTime:          awakeTime,
+		CircuitState:       as.breaker.State(),
+		ConcurrencyLimit:   as.limiter.Limit(),
+		InFlight:           as.limiter.InFlight(),
+		RateLimitPerMinute: as.budget.PerMinute(),
+	}
 }
 
 func (as *AccrualSystem) GetOrderStatus(ctx context.Context, orderNumber string) (accrualsystemprotocol.Order, error) {
 	if time.Now().Before(as.remoteServiceAwakeTime.Get()) {
 		return accrualsystemprotocol.Order{}, ErrTooManyRequests
 	}
+	if err := as.limiter.Acquire(ctx); err != nil {
+		return accrualsystemprotocol.Order{}, fmt.Errorf("concurrency limit wait failed: %w", err)
+	}
+	report, err := as.breaker.Allow()
+	if err != nil {
+		as.limiter.Release(aimd.Ignored)
+		return accrualsystemprotocol.Order{}, ErrCircuitOpen
+	}
 	resp, err := as.getOrderWithRetry(ctx, orderNumber)
 	if err != nil {
+		as.limiter.Release(limiterOutcome(ctx, nil, err))
+		report(breakerOutcome(ctx, nil, err))
 		return accrualsystemprotocol.Order{}, fmt.Errorf("get request failed: %w", err)
 	}
+	as.limiter.Release(limiterOutcome(ctx, resp, nil))
+	report(breakerOutcome(ctx, resp, nil))
+
 	statusCode := resp.StatusCode()
 	switch statusCode {
 	case http.StatusNoContent:
@@ -69,44 +133,106 @@ func (as *AccrualSystem) GetOrderStatus(ctx context.Context, orderNumber string)
 		as.logger.DebugCtx(ctx, "Order found", zap.Any("order", res))
 		return res, nil
 	case http.StatusTooManyRequests:
-		as.logger.DebugCtx(ctx, "Too many requests")
-		retryAfterSeconds, err := strconv.Atoi(resp.Header().Get("Retry-After"))
-		if err != nil {
-			return accrualsystemprotocol.Order{}, fmt.Errorf("error converting retry-after header: %w", err)
-		}
-		retryAfter := time.Duration(retryAfterSeconds) * time.Second
-		newRemoveServiceAwakeTime := time.Now().Add(retryAfter)
-		as.remoteServiceAwakeTime.SetIf(
-			newRemoveServiceAwakeTime,
-			newRemoveServiceAwakeTime.After,
-		)
+		as.handleTooManyRequests(ctx, resp)
 		return accrualsystemprotocol.Order{}, ErrTooManyRequests
 	default:
 		return accrualsystemprotocol.Order{}, fmt.Errorf("unexpected status code %v", statusCode)
 	}
 }
 
+func (as *AccrualSystem) handleTooManyRequests(ctx context.Context, resp *resty.Response) {
+	if perMinute, ok := parseRateLimit(resp.Body()); ok {
+		as.budget.Learn(perMinute)
+	}
+	retryAfter := as.budget.Interval()
+	if retryAfterSeconds, err := strconv.Atoi(resp.Header().Get("Retry-After")); err == nil {
+		retryAfter = max(retryAfter, time.Duration(retryAfterSeconds)*time.Second)
+	}
+	if retryAfter == 0 {
+		retryAfter = rateLimitWindow
+	}
+	as.logger.DebugCtx(
+		ctx,
+		"Too many requests",
+		zap.Duration("retryAfter", retryAfter),
+		zap.Int("rateLimitPerMinute", as.budget.PerMinute()),
+	)
+	newRemoteServiceAwakeTime := time.Now().Add(retryAfter)
+	as.remoteServiceAwakeTime.SetIf(
+		newRemoteServiceAwakeTime,
+		newRemoteServiceAwakeTime.After,
+	)
+}
+
 func (as *AccrualSystem) getOrderWithRetry(ctx context.Context, orderNumber string) (*resty.Response, error) {
 	return timeutils.Retry[*resty.Response](
 		ctx,
-		as.cfg.RetryAttemptDelays,
+		as.cfg.RetryPolicy,
 		func(ctx context.Context) (*resty.Response, error) {
 			return as.getOrder(ctx, orderNumber)
 		},
 		func(response *resty.Response, err error) (needRetry bool) {
-			code := response.StatusCode()
-			return code == http.StatusGatewayTimeout || code == http.StatusServiceUnavailable
+			if err != nil {
+				return ctx.Err() == nil && isNetworkError(err)
+			}
+			switch response.StatusCode() {
+			case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+				return true
+			default:
+				return false
+			}
 		},
 	)
 }
 
 //nolint:wrapcheck // wrapping unnecessary
 func (as *AccrualSystem) getOrder(ctx context.Context, orderNumber string) (*resty.Response, error) {
-	url := as.cfg.ServerAddress + "/api/orders/{number}"
-	return resty.
-		New().
+	if err := as.budget.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return as.client.
 		R().
 		SetContext(ctx).
 		SetPathParam("number", orderNumber).
-		Get(url)
+		Get("/api/orders/{number}")
+}
+
+func breakerOutcome(ctx context.Context, resp *resty.Response, err error) circuitbreaker.Outcome {
+	if err != nil {
+		if ctx.Err() != nil && !isTimeout(err) {
+			return circuitbreaker.Ignored
+		}
+		return circuitbreaker.Failure
+	}
+	if resp.StatusCode() >= http.StatusInternalServerError {
+		return circuitbreaker.Failure
+	}
+	return circuitbreaker.Success
+}
+
+func limiterOutcome(ctx context.Context, resp *resty.Response, err error) aimd.Outcome {
+	if err != nil {
+		if ctx.Err() != nil && !isTimeout(err) {
+			return aimd.Ignored
+		}
+		return aimd.Overload
+	}
+	code := resp.StatusCode()
+	if code == http.StatusTooManyRequests || code >= http.StatusInternalServerError {
+		return aimd.Overload
+	}
+	return aimd.Success
+}
+
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
@@ -0,0 +1,107 @@
+package accrualsystem
+
+import (
+	"context"
+	"go-market/pkg/aimd"
+	"go-market/pkg/circuitbreaker"
+	"go-market/pkg/logging"
+	"go-market/pkg/timeutils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func newAccrualSystemStub() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"order":"12345678903","status":"PROCESSED","accrual":500}`))
+	}))
+}
+
+func newTestAccrualSystem(tb testing.TB, serverAddress string) *AccrualSystem {
+	tb.Helper()
+	logger, err := logging.NewZapLogger(zapcore.ErrorLevel)
+	require.NoError(tb, err)
+	as, err := NewAccrualSystem(Config{
+		ServerAddress: serverAddress,
+		RetryPolicy:   timeutils.RetryPolicy{MaxAttempts: 1},
+		HTTPClient: HTTPClientConfig{
+			RequestTimeout:      time.Second,
+			DialTimeout:         time.Second,
+			KeepAlive:           30 * time.Second,
+			IdleConnTimeout:     90 * time.Second,
+			MaxIdleConns:        16,
+			MaxIdleConnsPerHost: 16,
+		},
+		CircuitBreaker: circuitbreaker.Config{
+			MinRequests:  1,
+			FailureRatio: 1,
+			OpenTimeout:  time.Second,
+		},
+		ConcurrencyLimit: aimd.Config{
+			InitialLimit: 16,
+			MinLimit:     1,
+			MaxLimit:     16,
+			BackoffRatio: 0.5,
+		},
+	}, logger)
+	require.NoError(tb, err)
+	return as
+}
+
+func BenchmarkGetOrder(b *testing.B) {
+	server := newAccrualSystemStub()
+	defer server.Close()
+
+	b.Run("shared client", func(b *testing.B) {
+		as := newTestAccrualSystem(b, server.URL)
+		b.ResetTimer()
+		for range b.N {
+			_, err := as.GetOrderStatus(context.Background(), "12345678903")
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("client per request", func(b *testing.B) {
+		for range b.N {
+			_, err := resty.
+				New().
+				R().
+				SetContext(context.Background()).
+				SetPathParam("number", "12345678903").
+				Get(server.URL + "/api/orders/{number}")
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestGetOrderStatusTooManyRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("No more than 20 requests per minute allowed"))
+	}))
+	defer server.Close()
+
+	as := newTestAccrualSystem(t, server.URL)
+	start := time.Now()
+	_, err := as.GetOrderStatus(context.Background(), "12345678903")
+	require.ErrorIs(t, err, ErrTooManyRequests)
+
+	status := as.Status()
+	assert.Equal(t, 20, status.RateLimitPerMinute)
+	assert.WithinDuration(t, start.Add(3*time.Second), status.AwakeTime, time.Second)
+
+	_, err = as.GetOrderStatus(context.Background(), "12345678903")
+	assert.ErrorIs(t, err, ErrTooManyRequests, "requests are parked until awake time")
+}
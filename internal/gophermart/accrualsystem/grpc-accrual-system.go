@@ -0,0 +1,174 @@
+package accrualsystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-market/internal/common/accrualsystemprotocol"
+	"go-market/internal/common/accrualsystemprotocol/accrualsystempb"
+	"go-market/pkg/logging"
+	"go-market/pkg/threadsafe"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const GRPCProviderName = "grpc"
+
+type GRPCConfig struct {
+	Address        string
+	RequestTimeout time.Duration
+	// TLS is used when any of its files is set, otherwise the connection is plaintext.
+	TLS TLSConfig
+}
+
+type GRPCAccrualSystem struct {
+	logger                 *logging.ZapLogger
+	remoteServiceAwakeTime *threadsafe.Time
+	conn                   *grpc.ClientConn
+	client                 accrualsystempb.AccrualSystemClient
+	cfg                    GRPCConfig
+}
+
+func NewGRPCAccrualSystem(
+	cfg GRPCConfig,
+	logger *logging.ZapLogger,
+	opts ...grpc.DialOption,
+) (*GRPCAccrualSystem, error) {
+	transportCredentials := insecure.NewCredentials()
+	if cfg.TLS != (TLSConfig{}) {
+		tlsConfig, err := newTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create TLS config: %w", err)
+		}
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(transportCredentials)}, opts...)
+	conn, err := grpc.NewClient(cfg.Address, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create grpc client: %w", err)
+	}
+	return &GRPCAccrualSystem{
+		cfg:                    cfg,
+		logger:                 logger,
+		conn:                   conn,
+		client:                 accrualsystempb.NewAccrualSystemClient(conn),
+		remoteServiceAwakeTime: threadsafe.NewTime(time.Now()),
+	}, nil
+}
+
+func (as *GRPCAccrualSystem) Close() error {
+	return as.conn.Close() //nolint:wrapcheck // unnecessary
+}
+
+func (as *GRPCAccrualSystem) GetServiceAwakeTime() time.Time {
+	return as.remoteServiceAwakeTime.Get()
+}
+
+func (as *GRPCAccrualSystem) GetOrderStatus(
+	ctx context.Context,
+	orderNumber string,
+) (accrualsystemprotocol.Order, error) {
+	if time.Now().Before(as.remoteServiceAwakeTime.Get()) {
+		return accrualsystemprotocol.Order{}, ErrTooManyRequests
+	}
+	if as.cfg.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, as.cfg.RequestTimeout)
+		defer cancel()
+	}
+	resp, err := as.client.GetOrder(ctx, &accrualsystempb.GetOrderRequest{Number: orderNumber})
+	if err != nil {
+		st := status.Convert(err)
+		switch st.Code() { //nolint:exhaustive // other codes are unexpected
+		case codes.NotFound:
+			as.logger.DebugCtx(ctx, "No order found")
+			return accrualsystemprotocol.Order{}, ErrNoOrderFound
+		case codes.ResourceExhausted:
+			as.handleResourceExhausted(ctx, st)
+			return accrualsystemprotocol.Order{}, ErrTooManyRequests
+		default:
+			return accrualsystemprotocol.Order{}, fmt.Errorf("get order request failed: %w", err)
+		}
+	}
+	res, err := fromProtoOrder(resp)
+	if err != nil {
+		return accrualsystemprotocol.Order{}, fmt.Errorf("error converting order response: %w", err)
+	}
+	as.logger.DebugCtx(ctx, "Order found", zap.Any("order", res))
+	return res, nil
+}
+
+func (as *GRPCAccrualSystem) handleResourceExhausted(ctx context.Context, st *status.Status) {
+	retryAfter := rateLimitWindow
+	for _, detail := range st.Details() {
+		if retryInfo, ok := detail.(*errdetails.RetryInfo); ok && retryInfo.GetRetryDelay() != nil {
+			retryAfter = retryInfo.GetRetryDelay().AsDuration()
+			break
+		}
+	}
+	as.logger.DebugCtx(ctx, "Too many requests", zap.Duration("retryAfter", retryAfter))
+	newRemoteServiceAwakeTime := time.Now().Add(retryAfter)
+	as.remoteServiceAwakeTime.SetIf(
+		newRemoteServiceAwakeTime,
+		newRemoteServiceAwakeTime.After,
+	)
+}
+
+func fromProtoOrder(order *accrualsystempb.Order) (accrualsystemprotocol.Order, error) {
+	res := accrualsystemprotocol.Order{
+		Number:  order.GetNumber(),
+		Accrual: decimal.Zero,
+		Program: order.GetProgram(),
+	}
+	switch order.GetStatus() {
+	case accrualsystempb.OrderStatus_ORDER_STATUS_REGISTERED:
+		res.Status = accrualsystemprotocol.Registered
+	case accrualsystempb.OrderStatus_ORDER_STATUS_INVALID:
+		res.Status = accrualsystemprotocol.Invalid
+	case accrualsystempb.OrderStatus_ORDER_STATUS_PROCESSING:
+		res.Status = accrualsystemprotocol.Processing
+	case accrualsystempb.OrderStatus_ORDER_STATUS_PROCESSED:
+		res.Status = accrualsystemprotocol.Processed
+	case accrualsystempb.OrderStatus_ORDER_STATUS_UNSPECIFIED:
+		return accrualsystemprotocol.Order{}, errors.New("unspecified order status")
+	default:
+		return accrualsystemprotocol.Order{}, fmt.Errorf("unknown order status %v", order.GetStatus())
+	}
+	if order.GetAccrual() != "" {
+		accrual, err := decimal.NewFromString(order.GetAccrual())
+		if err != nil {
+			return accrualsystemprotocol.Order{}, fmt.Errorf("invalid accrual: %w", err)
+		}
+		res.Accrual = accrual
+	}
+	return res, nil
+}
+
+func toProtoOrder(order accrualsystemprotocol.Order) (*accrualsystempb.Order, error) {
+	res := &accrualsystempb.Order{
+		Number:  order.Number,
+		Accrual: order.Accrual.String(),
+		Program: order.Program,
+	}
+	switch order.Status {
+	case accrualsystemprotocol.Registered:
+		res.Status = accrualsystempb.OrderStatus_ORDER_STATUS_REGISTERED
+	case accrualsystemprotocol.Invalid:
+		res.Status = accrualsystempb.OrderStatus_ORDER_STATUS_INVALID
+	case accrualsystemprotocol.Processing:
+		res.Status = accrualsystempb.OrderStatus_ORDER_STATUS_PROCESSING
+	case accrualsystemprotocol.Processed:
+		res.Status = accrualsystempb.OrderStatus_ORDER_STATUS_PROCESSED
+	default:
+		return nil, fmt.Errorf("unknown order status %q", order.Status)
+	}
+	return res, nil
+}
@@ -0,0 +1,139 @@
+package accrualsystem
+
+import (
+	"context"
+	"go-market/internal/common/accrualsystemprotocol"
+	"go-market/internal/common/accrualsystemprotocol/accrualsystempb"
+	"go-market/pkg/logging"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type rateLimitedSource struct {
+	awakeTime time.Time
+}
+
+func (s *rateLimitedSource) GetServiceAwakeTime() time.Time {
+	return s.awakeTime
+}
+
+func (s *rateLimitedSource) GetOrderStatus(context.Context, string) (accrualsystemprotocol.Order, error) {
+	return accrualsystemprotocol.Order{}, ErrTooManyRequests
+}
+
+func newBufconnAccrualSystem(t *testing.T, source OrderStatusSource) *GRPCAccrualSystem {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	accrualsystempb.RegisterAccrualSystemServer(server, NewGRPCServer(source))
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	logger, err := logging.NewZapLogger(zapcore.ErrorLevel)
+	require.NoError(t, err)
+	as, err := NewGRPCAccrualSystem(
+		GRPCConfig{
+			Address:        "passthrough:///bufconn",
+			RequestTimeout: time.Second,
+		},
+		logger,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = as.Close()
+	})
+	return as
+}
+
+func TestGRPCAccrualSystem(t *testing.T) {
+	as := newBufconnAccrualSystem(t, NewStaticAccrualSystem(StaticConfig{
+		Rules: []StaticRule{
+			{Prefix: "1", Status: accrualsystemprotocol.Processed, Accrual: decimal.RequireFromString("729.98")},
+			{Prefix: "2", Status: accrualsystemprotocol.Processing},
+			{
+				Prefix:  "3",
+				Status:  accrualsystemprotocol.Processed,
+				Accrual: decimal.RequireFromString("100"),
+				Program: "partner",
+			},
+		},
+	}))
+
+	tests := []struct {
+		name        string
+		orderNumber string
+		expected    accrualsystemprotocol.Order
+		expectedErr error
+	}{
+		{
+			name:        "processed",
+			orderNumber: "12345678903",
+			expected: accrualsystemprotocol.Order{
+				Number:  "12345678903",
+				Status:  accrualsystemprotocol.Processed,
+				Accrual: decimal.RequireFromString("729.98"),
+			},
+		},
+		{
+			name:        "processing",
+			orderNumber: "2377225624",
+			expected: accrualsystemprotocol.Order{
+				Number:  "2377225624",
+				Status:  accrualsystemprotocol.Processing,
+				Accrual: decimal.Zero,
+			},
+		},
+		{
+			name:        "processed for program",
+			orderNumber: "346436439",
+			expected: accrualsystemprotocol.Order{
+				Number:  "346436439",
+				Status:  accrualsystemprotocol.Processed,
+				Accrual: decimal.RequireFromString("100"),
+				Program: "partner",
+			},
+		},
+		{
+			name:        "not found",
+			orderNumber: "9278923470",
+			expectedErr: ErrNoOrderFound,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			order, err := as.GetOrderStatus(context.Background(), test.orderNumber)
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected.Number, order.Number)
+			assert.Equal(t, test.expected.Status, order.Status)
+			assert.True(t, test.expected.Accrual.Equal(order.Accrual))
+			assert.Equal(t, test.expected.Program, order.Program)
+		})
+	}
+}
+
+func TestGRPCAccrualSystemResourceExhausted(t *testing.T) {
+	awakeTime := time.Now().Add(30 * time.Second)
+	as := newBufconnAccrualSystem(t, &rateLimitedSource{awakeTime: awakeTime})
+
+	_, err := as.GetOrderStatus(context.Background(), "12345678903")
+	require.ErrorIs(t, err, ErrTooManyRequests)
+	assert.WithinDuration(t, awakeTime, as.GetServiceAwakeTime(), time.Second)
+}
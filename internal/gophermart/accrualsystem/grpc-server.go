@@ -0,0 +1,59 @@
+package accrualsystem
+
+import (
+	"context"
+	"errors"
+	"go-market/internal/common/accrualsystemprotocol"
+	"go-market/internal/common/accrualsystemprotocol/accrualsystempb"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type OrderStatusSource interface {
+	GetServiceAwakeTime() time.Time
+	GetOrderStatus(ctx context.Context, orderNumber string) (accrualsystemprotocol.Order, error)
+}
+
+// GRPCServer exposes any accrual provider, e.g. StaticAccrualSystem, through the gRPC accrual system API.
+type GRPCServer struct {
+	accrualsystempb.UnimplementedAccrualSystemServer
+	source OrderStatusSource
+}
+
+func NewGRPCServer(source OrderStatusSource) *GRPCServer {
+	return &GRPCServer{
+		source: source,
+	}
+}
+
+func (s *GRPCServer) GetOrder(
+	ctx context.Context,
+	req *accrualsystempb.GetOrderRequest,
+) (*accrualsystempb.Order, error) {
+	order, err := s.source.GetOrderStatus(ctx, req.GetNumber())
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNoOrderFound):
+			return nil, status.Error(codes.NotFound, err.Error())
+		case errors.Is(err, ErrTooManyRequests), errors.Is(err, ErrCircuitOpen):
+			st, detailsErr := status.New(codes.ResourceExhausted, err.Error()).WithDetails(&errdetails.RetryInfo{
+				RetryDelay: durationpb.New(max(time.Until(s.source.GetServiceAwakeTime()), 0)),
+			})
+			if detailsErr != nil {
+				return nil, status.Error(codes.Internal, detailsErr.Error())
+			}
+			return nil, st.Err() //nolint:wrapcheck // status error must be returned as is
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	res, err := toProtoOrder(order)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return res, nil
+}
@@ -0,0 +1,74 @@
+package accrualsystem
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const rateLimitWindow = time.Minute
+
+var rateLimitMessageRegexp = regexp.MustCompile(`No more than (\d+) requests per minute allowed`)
+
+// requestBudget paces requests of all workers so the published per-minute quota is spread evenly.
+type requestBudget struct {
+	limiter   *rate.Limiter
+	perMinute *atomic.Int64
+}
+
+func newRequestBudget(perMinute int) *requestBudget {
+	b := &requestBudget{
+		limiter:   rate.NewLimiter(rate.Inf, 1),
+		perMinute: &atomic.Int64{},
+	}
+	b.Learn(perMinute)
+	return b
+}
+
+func (b *requestBudget) Wait(ctx context.Context) error {
+	if err := b.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("request budget wait failed: %w", err)
+	}
+	return nil
+}
+
+// Learn applies a new per-minute limit. Non-positive values are ignored.
+func (b *requestBudget) Learn(perMinute int) {
+	if perMinute <= 0 {
+		return
+	}
+	if b.perMinute.Swap(int64(perMinute)) == int64(perMinute) {
+		return
+	}
+	b.limiter.SetLimit(rate.Every(rateLimitWindow / time.Duration(perMinute)))
+}
+
+func (b *requestBudget) PerMinute() int {
+	return int(b.perMinute.Load())
+}
+
+// Interval returns the pause between two requests allowed by the known limit, zero if the limit is unknown.
+func (b *requestBudget) Interval() time.Duration {
+	perMinute := b.PerMinute()
+	if perMinute <= 0 {
+		return 0
+	}
+	return rateLimitWindow / time.Duration(perMinute)
+}
+
+func parseRateLimit(body []byte) (perMinute int, ok bool) {
+	match := rateLimitMessageRegexp.FindSubmatch(body)
+	if match == nil {
+		return 0, false
+	}
+	perMinute, err := strconv.Atoi(string(match[1]))
+	if err != nil || perMinute <= 0 {
+		return 0, false
+	}
+	return perMinute, true
+}
@@ -0,0 +1,92 @@
+package accrualsystem
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"go-market/pkg/logging"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap/zapcore"
+)
+
+type HTTPClientConfig struct {
+	TLS                 TLSConfig
+	RequestTimeout      time.Duration
+	DialTimeout         time.Duration
+	KeepAlive           time.Duration
+	IdleConnTimeout     time.Duration
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+}
+
+type TLSConfig struct {
+	// CertFile and KeyFile enable mTLS when both are set.
+	CertFile string
+	KeyFile  string
+	// CAFile replaces system root CAs when set.
+	CAFile string
+}
+
+func newRestyClient(serverAddress string, cfg HTTPClientConfig, logger *logging.ZapLogger) (*resty.Client, error) {
+	tlsConfig, err := newTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TLS config: %w", err)
+	}
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: cfg.DialTimeout,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        cfg.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     cfg.MaxConnsPerHost,
+		IdleConnTimeout:     cfg.IdleConnTimeout,
+	}
+	client := resty.New().
+		SetTransport(transport).
+		SetTimeout(cfg.RequestTimeout).
+		SetBaseURL(serverAddress).
+		SetLogger(NewRestyLogger(logger.Raw())).
+		SetDebug(logger.Raw().Core().Enabled(zapcore.DebugLevel))
+	return client, nil
+}
+
+func newTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, errors.New("both client certificate and key must be set")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in CA file")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,68 @@
+package accrualsystem
+
+import (
+	"context"
+	"go-market/internal/common/accrualsystemprotocol"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	HTTPProviderName   = "http"
+	StaticProviderName = "static"
+)
+
+type StaticRule struct {
+	Prefix  string
+	Status  accrualsystemprotocol.OrderStatus
+	Accrual decimal.Decimal
+	// Program is the loyalty program to credit, empty means the one of the order.
+	Program string
+}
+
+type StaticConfig struct {
+	// Rules are checked in order, the first rule with a matching prefix wins.
+	Rules []StaticRule
+}
+
+// StaticAccrualSystem is an in-process accrual calculator answering with preconfigured rules.
+type StaticAccrualSystem struct {
+	cfg StaticConfig
+}
+
+func NewStaticAccrualSystem(cfg StaticConfig) *StaticAccrualSystem {
+	return &StaticAccrualSystem{
+		cfg: cfg,
+	}
+}
+
+func (s *StaticAccrualSystem) GetServiceAwakeTime() time.Time {
+	return time.Time{}
+}
+
+func (s *StaticAccrualSystem) GetOrderStatus(
+	ctx context.Context,
+	orderNumber string,
+) (accrualsystemprotocol.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return accrualsystemprotocol.Order{}, err //nolint:wrapcheck // unnecessary
+	}
+	for _, rule := range s.cfg.Rules {
+		if !strings.HasPrefix(orderNumber, rule.Prefix) {
+			continue
+		}
+		accrual := decimal.Zero
+		if rule.Status == accrualsystemprotocol.Processed {
+			accrual = rule.Accrual
+		}
+		return accrualsystemprotocol.Order{
+			Number:  orderNumber,
+			Status:  rule.Status,
+			Accrual: accrual,
+			Program: rule.Program,
+		}, nil
+	}
+	return accrualsystemprotocol.Order{}, ErrNoOrderFound
+}
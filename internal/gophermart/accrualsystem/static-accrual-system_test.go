@@ -0,0 +1,102 @@
+package accrualsystem
+
+import (
+	"context"
+	"go-market/internal/common/accrualsystemprotocol"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticAccrualSystem(t *testing.T) {
+	as := NewStaticAccrualSystem(StaticConfig{
+		Rules: []StaticRule{
+			{Prefix: "12", Status: accrualsystemprotocol.Processed, Accrual: decimal.RequireFromString("729.98")},
+			{Prefix: "1", Status: accrualsystemprotocol.Invalid},
+			{Prefix: "2", Status: accrualsystemprotocol.Processing, Accrual: decimal.NewFromInt(100)},
+			{
+				Prefix:  "3",
+				Status:  accrualsystemprotocol.Processed,
+				Accrual: decimal.NewFromInt(50),
+				Program: "partner",
+			},
+		},
+	})
+
+	tests := []struct {
+		name        string
+		orderNumber string
+		expected    accrualsystemprotocol.Order
+		expectedErr error
+	}{
+		{
+			name:        "first matching rule wins",
+			orderNumber: "12345678903",
+			expected: accrualsystemprotocol.Order{
+				Number:  "12345678903",
+				Status:  accrualsystemprotocol.Processed,
+				Accrual: decimal.RequireFromString("729.98"),
+			},
+		},
+		{
+			name:        "shorter prefix",
+			orderNumber: "18",
+			expected: accrualsystemprotocol.Order{
+				Number:  "18",
+				Status:  accrualsystemprotocol.Invalid,
+				Accrual: decimal.Zero,
+			},
+		},
+		{
+			name:        "accrual only for processed orders",
+			orderNumber: "2377225624",
+			expected: accrualsystemprotocol.Order{
+				Number:  "2377225624",
+				Status:  accrualsystemprotocol.Processing,
+				Accrual: decimal.Zero,
+			},
+		},
+		{
+			name:        "program",
+			orderNumber: "346436439",
+			expected: accrualsystemprotocol.Order{
+				Number:  "346436439",
+				Status:  accrualsystemprotocol.Processed,
+				Accrual: decimal.NewFromInt(50),
+				Program: "partner",
+			},
+		},
+		{
+			name:        "no matching rule",
+			orderNumber: "9278923470",
+			expectedErr: ErrNoOrderFound,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			order, err := as.GetOrderStatus(context.Background(), test.orderNumber)
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected.Number, order.Number)
+			assert.Equal(t, test.expected.Status, order.Status)
+			assert.True(t, test.expected.Accrual.Equal(order.Accrual), "accrual %s", order.Accrual)
+			assert.Equal(t, test.expected.Program, order.Program)
+		})
+	}
+}
+
+func TestStaticAccrualSystemCancelled(t *testing.T) {
+	as := NewStaticAccrualSystem(StaticConfig{
+		Rules: []StaticRule{{Prefix: "", Status: accrualsystemprotocol.Processed}},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := as.GetOrderStatus(ctx, "12345678903")
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,345 @@
+package apiv2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-market/internal/common/clientprotocol"
+	servicePackage "go-market/internal/gophermart/service"
+	"go-market/pkg/logging"
+	"go-market/pkg/lunh"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+type AuthorizationService interface {
+	Register(ctx context.Context, login string, password string) (string, error)
+	Login(ctx context.Context, login string, password string) (string, error)
+}
+
+type OrdersService interface {
+	RegisterOrder(ctx context.Context, userID int, orderNumber string, programID string) error
+	GetAllOrders(ctx context.Context, userID int) ([]servicePackage.Order, error)
+}
+
+type WalletService interface {
+	GetUserBalanceInfo(ctx context.Context, userID int) (servicePackage.BalanceInfo, error)
+	GetAllUserWithdrawals(ctx context.Context, userID int) ([]servicePackage.Withdrawal, error)
+	Withdraw(ctx context.Context, userID int, orderNumber string, programID string, amount decimal.Decimal) error
+}
+
+var _ StrictServerInterface = (*Server)(nil)
+
+// Server implements the v2 API generated from openapi.yaml.
+type Server struct {
+	authorizationService AuthorizationService
+	ordersService        OrdersService
+	walletService        WalletService
+	logger               *logging.ZapLogger
+}
+
+func NewServer(
+	authorizationService AuthorizationService,
+	ordersService OrdersService,
+	walletService WalletService,
+	logger *logging.ZapLogger,
+) *Server {
+	return &Server{
+		authorizationService: authorizationService,
+		ordersService:        ordersService,
+		walletService:        walletService,
+		logger:               logger,
+	}
+}
+
+// NewHandler registers the v2 routes on router, it expects jwtauth.Verifier to be applied.
+func NewHandler(server *Server, router chi.Router) http.Handler {
+	return HandlerWithOptions(
+		NewStrictHandlerWithOptions(server, nil, StrictHTTPServerOptions{
+			RequestErrorHandlerFunc:  server.handleRequestError,
+			ResponseErrorHandlerFunc: server.handleResponseError,
+		}),
+		ChiServerOptions{
+			BaseRouter:       router,
+			Middlewares:      []MiddlewareFunc{authenticator},
+			ErrorHandlerFunc: server.handleRequestError,
+		},
+	)
+}
+
+func (s *Server) Register(ctx context.Context, request RegisterRequestObject) (RegisterResponseObject, error) {
+	tkn, err := s.authorizationService.Register(ctx, request.Body.Login, request.Body.Password)
+	if err != nil {
+		var validationErr *servicePackage.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			return Register400JSONResponse{ErrorJSONResponse(newValidationError(validationErr))}, nil
+		case errors.Is(err, servicePackage.ErrLoginTaken):
+			return Register409JSONResponse(newError(err)), nil
+		default:
+			return nil, fmt.Errorf("registration failed: %w", err)
+		}
+	}
+	return Register200JSONResponse{AuthorizedJSONResponse{Token: tkn}}, nil
+}
+
+func (s *Server) Login(ctx context.Context, request LoginRequestObject) (LoginResponseObject, error) {
+	tkn, err := s.authorizationService.Login(ctx, request.Body.Login, request.Body.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, servicePackage.ErrInvalidCredentials):
+			return Login401JSONResponse(newError(err)), nil
+		default:
+			return nil, fmt.Errorf("login failed: %w", err)
+		}
+	}
+	return Login200JSONResponse{AuthorizedJSONResponse{Token: tkn}}, nil
+}
+
+func (s *Server) UploadOrder(ctx context.Context, request UploadOrderRequestObject) (UploadOrderResponseObject, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orderNumber := request.Body.Number
+	if !lunh.Validate(orderNumber) {
+		return UploadOrder422JSONResponse(newError(errInvalidOrderNumber)), nil
+	}
+	err = s.ordersService.RegisterOrder(ctx, userID, orderNumber, programID(request.Body.Program))
+	if err != nil {
+		switch {
+		case errors.Is(err, servicePackage.ErrUnknownProgram):
+			return UploadOrder400JSONResponse{ErrorJSONResponse(newError(err))}, nil
+		case errors.Is(err, servicePackage.ErrOrderRegistered):
+			return UploadOrder200JSONResponse(*request.Body), nil
+		case errors.Is(err, servicePackage.ErrOrderRegisteredByAnotherUser):
+			return UploadOrder409JSONResponse(newError(err)), nil
+		default:
+			return nil, fmt.Errorf("order registration failed: %w", err)
+		}
+	}
+	return UploadOrder202JSONResponse(*request.Body), nil
+}
+
+func (s *Server) GetOrders(ctx context.Context, request GetOrdersRequestObject) (GetOrdersResponseObject, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	location, err := loadLocation(request.Params.AcceptTimezone)
+	if err != nil {
+		return GetOrders400JSONResponse{ErrorJSONResponse(newError(err))}, nil
+	}
+	orders, err := s.ordersService.GetAllOrders(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting orders failed: %w", err)
+	}
+	res := GetOrders200JSONResponse{Orders: make([]Order, len(orders))}
+	for i, order := range orders {
+		res.Orders[i] = Order{
+			Number:          order.Number,
+			Program:         order.Program,
+			Status:          OrderStatus(order.Status),
+			Accrual:         clientprotocol.MoneyString.Money(order.Accrual),
+			AdjustedAccrual: money(order.AdjustedAccrual),
+			UploadedAt:      order.UploadedAt.In(location),
+		}
+	}
+	return res, nil
+}
+
+func (s *Server) GetBalance(ctx context.Context, request GetBalanceRequestObject) (GetBalanceResponseObject, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	location, err := loadLocation(request.Params.AcceptTimezone)
+	if err != nil {
+		return GetBalance400JSONResponse{ErrorJSONResponse(newError(err))}, nil
+	}
+	balanceInfo, err := s.walletService.GetUserBalanceInfo(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting balance failed: %w", err)
+	}
+	res := GetBalance200JSONResponse{
+		Current:    clientprotocol.MoneyString.Money(balanceInfo.Balance),
+		OnHold:     clientprotocol.MoneyString.Money(balanceInfo.OnHold),
+		Withdrawn:  clientprotocol.MoneyString.Money(balanceInfo.Withdrawals),
+		Expiring:   clientprotocol.MoneyString.Money(balanceInfo.Expiring),
+		ExpiringAt: expiringAt(balanceInfo.ExpiringAt, location),
+		Programs:   make([]ProgramBalance, len(balanceInfo.Programs)),
+	}
+	for i, program := range balanceInfo.Programs {
+		res.Programs[i] = ProgramBalance{
+			Program:    program.Program,
+			Name:       program.Name,
+			Current:    clientprotocol.MoneyString.Money(program.Balance),
+			OnHold:     clientprotocol.MoneyString.Money(program.OnHold),
+			Withdrawn:  clientprotocol.MoneyString.Money(program.Withdrawals),
+			Expiring:   clientprotocol.MoneyString.Money(program.Expiring),
+			ExpiringAt: expiringAt(program.ExpiringAt, location),
+		}
+	}
+	return res, nil
+}
+
+func (s *Server) Withdraw(ctx context.Context, request WithdrawRequestObject) (WithdrawResponseObject, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	amount := request.Body.Sum.Decimal()
+	if amount.IsZero() {
+		return Withdraw400JSONResponse{ErrorJSONResponse(newError(errZeroWithdrawal))}, nil
+	}
+	if !lunh.Validate(request.Body.Order) {
+		return Withdraw422JSONResponse(newError(errInvalidOrderNumber)), nil
+	}
+	err = s.walletService.Withdraw(ctx, userID, request.Body.Order, programID(request.Body.Program), amount)
+	if err != nil {
+		switch {
+		case errors.Is(err, servicePackage.ErrNotEnoughBalance):
+			return Withdraw402JSONResponse(newError(err)), nil
+		case errors.Is(err, servicePackage.ErrUnknownProgram):
+			return Withdraw400JSONResponse{ErrorJSONResponse(newError(err))}, nil
+		default:
+			return nil, fmt.Errorf("withdrawal failed: %w", err)
+		}
+	}
+	return Withdraw200JSONResponse{
+		Order:   request.Body.Order,
+		Program: request.Body.Program,
+		Sum:     clientprotocol.MoneyString.Money(amount),
+	}, nil
+}
+
+func (s *Server) GetWithdrawals(
+	ctx context.Context,
+	request GetWithdrawalsRequestObject,
+) (GetWithdrawalsResponseObject, error) {
+	userID, err := userIDFromCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	location, err := loadLocation(request.Params.AcceptTimezone)
+	if err != nil {
+		return GetWithdrawals400JSONResponse{ErrorJSONResponse(newError(err))}, nil
+	}
+	withdrawals, err := s.walletService.GetAllUserWithdrawals(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting withdrawals failed: %w", err)
+	}
+	res := GetWithdrawals200JSONResponse{Withdrawals: make([]Withdrawal, len(withdrawals))}
+	for i, withdrawal := range withdrawals {
+		res.Withdrawals[i] = Withdrawal{
+			Order:       withdrawal.OrderNumber,
+			Program:     withdrawal.Program,
+			Sum:         clientprotocol.MoneyString.Money(withdrawal.Amount),
+			ProcessedAt: withdrawal.ProcessTime.In(location),
+		}
+	}
+	return res, nil
+}
+
+func (s *Server) handleRequestError(w http.ResponseWriter, r *http.Request, err error) {
+	s.logger.DebugCtx(r.Context(), "invalid request", zap.Error(err))
+	writeError(w, http.StatusBadRequest, err)
+}
+
+func (s *Server) handleResponseError(w http.ResponseWriter, r *http.Request, err error) {
+	s.logger.ErrorCtx(r.Context(), "request failed", zap.Error(err))
+	writeError(w, http.StatusInternalServerError, errors.New(http.StatusText(http.StatusInternalServerError)))
+}
+
+var (
+	errInvalidOrderNumber = errors.New("invalid order number")
+	errZeroWithdrawal     = errors.New("withdrawal sum must be positive")
+	errUnauthorized       = errors.New("unauthorized")
+)
+
+// authenticator rejects requests without a valid token to operations declaring a security requirement.
+func authenticator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(BearerAuthScopes) != nil {
+			token, _, err := jwtauth.FromContext(r.Context())
+			if err != nil || token == nil {
+				writeError(w, http.StatusUnauthorized, errUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newError(err error) Error {
+	return Error{Error: err.Error()}
+}
+
+func newValidationError(err *servicePackage.ValidationError) Error {
+	fields := make([]FieldError, len(err.Errors))
+	for i, fieldError := range err.Errors {
+		fields[i] = FieldError{Field: fieldError.Field, Message: fieldError.Message}
+	}
+	return Error{Error: "credentials violate the policy", Fields: &fields}
+}
+
+func writeError(w http.ResponseWriter, statusCode int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(newError(err))
+}
+
+// programID returns the requested program, empty means the default one.
+func programID(program *ProgramID) string {
+	if program == nil {
+		return ""
+	}
+	return *program
+}
+
+func expiringAt(t time.Time, location *time.Location) *ExpiringAt {
+	if t.IsZero() {
+		return nil
+	}
+	res := t.In(location)
+	return &res
+}
+
+func money(amount decimal.NullDecimal) *Money {
+	if !amount.Valid {
+		return nil
+	}
+	res := clientprotocol.MoneyString.Money(amount.Decimal)
+	return &res
+}
+
+func loadLocation(name *string) (*time.Location, error) {
+	if name == nil || *name == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(*name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone: %w", err)
+	}
+	return location, nil
+}
+
+func userIDFromCtx(ctx context.Context) (int, error) {
+	_, claims, _ := jwtauth.FromContext(ctx)
+	userIDStr, ok := claims[servicePackage.UserIDClaimName].(string)
+	if !ok {
+		return 0, errors.New("invalid user id type")
+	}
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse user id: %w", err)
+	}
+	return userID, nil
+}
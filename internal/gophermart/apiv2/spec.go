@@ -0,0 +1,33 @@
+package apiv2
+
+import (
+	_ "embed"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+// LoadSpec parses openapi.yaml, which documents both v1 and v2 routes.
+func LoadSpec() (*openapi3.T, error) {
+	spec, err := openapi3.NewLoader().LoadFromData(specYAML)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OpenAPI spec: %w", err)
+	}
+	return spec, nil
+}
+
+// SpecJSON returns the spec served at /api/openapi.json.
+func SpecJSON() ([]byte, error) {
+	spec, err := LoadSpec()
+	if err != nil {
+		return nil, err
+	}
+	res, err := spec.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal OpenAPI spec: %w", err)
+	}
+	return res, nil
+}
@@ -5,4 +5,7 @@ import "time"
 type Config struct {
 	ServerAddress   string
 	ShutdownTimeout time.Duration
+	// AdminToken authenticates administrative routes, they are not served when it is empty.
+	// It is kept out of the logged configuration.
+	AdminToken string `json:"-"`
 }
@@ -2,21 +2,41 @@ package database
 
 import (
 	"context"
-	"embed"
-	"errors"
 	"fmt"
+	"go-market/pkg/pgxstorage"
+	"go-market/pkg/timeutils"
+	"strconv"
 	"time"
 
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
 type Config struct {
-	ConnectionString   string
-	RetryAttemptDelays []time.Duration
+	ConnectionString string
+	// ReplicaConnectionString is optional, reads outside transactions go to the replica when it is set.
+	ReplicaConnectionString string
+	Replica                 pgxstorage.ReplicaConfig
+	RetryPolicy             timeutils.RetryPolicy
+	// TransactionRetryPolicy is used to retry whole transactions failed due to concurrent updates.
+	TransactionRetryPolicy timeutils.RetryPolicy
+	Pool                   PoolConfig
+	// SkipMigrations disables applying migrations on start, when they run as a separate job.
+	SkipMigrations bool
+}
+
+// PoolConfig tunes both primary and replica pools, zero values keep pgxpool defaults.
+type PoolConfig struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	// QueryExecMode is one of cache_statement, cache_describe, describe_exec, exec and simple_protocol.
+	QueryExecMode    string
+	ApplicationName  string
+	StatementTimeout time.Duration
 }
 
 type PgxDatabaseFactory struct {
@@ -30,33 +50,89 @@ func NewPgxDatabaseFactory(cfg Config) *PgxDatabaseFactory {
 }
 
 func (f *PgxDatabaseFactory) Create() (*pgxpool.Pool, error) {
-	if err := runMigrations(f.cfg.ConnectionString); err != nil {
-		return nil, fmt.Errorf("failed to run DB migrations: %w", err)
+	if !f.cfg.SkipMigrations {
+		if err := NewMigrator(f.cfg.ConnectionString).Up(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to run DB migrations: %w", err)
+		}
 	}
-	pool, err := pgxpool.New(context.Background(), f.cfg.ConnectionString)
+	pool, err := newPool(f.cfg.ConnectionString, f.cfg.Pool)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a connection pool: %w", err)
 	}
 	return pool, nil
 }
 
-//go:embed migrations/*.sql
-var migrationsDir embed.FS
-
-func runMigrations(dsn string) error {
-	d, err := iofs.New(migrationsDir, "migrations")
+func (f *PgxDatabaseFactory) CreateReplica() (*pgxpool.Pool, error) {
+	if f.cfg.ReplicaConnectionString == "" {
+		return nil, nil //nolint:nilnil // replica is optional
+	}
+	pool, err := newPool(f.cfg.ReplicaConnectionString, f.cfg.Pool)
 	if err != nil {
-		return fmt.Errorf("failed to return an iofs driver: %w", err)
+		return nil, fmt.Errorf("failed to create a replica connection pool: %w", err)
 	}
+	return pool, nil
+}
 
-	m, err := migrate.NewWithSourceInstance("iofs", d, dsn)
+func newPool(connectionString string, cfg PoolConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(connectionString)
 	if err != nil {
-		return fmt.Errorf("failed to get a new migrate instance: %w", err)
+		return nil, fmt.Errorf("failed to parse connection string: %w", err)
+	}
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		poolConfig.MinConns = cfg.MinConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
 	}
-	if err := m.Up(); err != nil {
-		if !errors.Is(err, migrate.ErrNoChange) {
-			return fmt.Errorf("failed to apply migrations to the DB: %w", err)
+	if cfg.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+	if cfg.QueryExecMode != "" {
+		mode, err := parseQueryExecMode(cfg.QueryExecMode)
+		if err != nil {
+			return nil, err
 		}
+		poolConfig.ConnConfig.DefaultQueryExecMode = mode
+	}
+	if cfg.ApplicationName != "" {
+		poolConfig.ConnConfig.RuntimeParams["application_name"] = cfg.ApplicationName
+	}
+	if cfg.StatementTimeout > 0 {
+		statementTimeout := strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
+		poolConfig.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+			_, err := conn.Exec(ctx, "SELECT set_config('statement_timeout', $1, false)", statementTimeout)
+			if err != nil {
+				return fmt.Errorf("failed to set statement timeout: %w", err)
+			}
+			return nil
+		}
+	}
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // unnecessary
+	}
+	return pool, nil
+}
+
+func parseQueryExecMode(value string) (pgx.QueryExecMode, error) {
+	switch value {
+	case "cache_statement":
+		return pgx.QueryExecModeCacheStatement, nil
+	case "cache_describe":
+		return pgx.QueryExecModeCacheDescribe, nil
+	case "describe_exec":
+		return pgx.QueryExecModeDescribeExec, nil
+	case "exec":
+		return pgx.QueryExecModeExec, nil
+	case "simple_protocol":
+		return pgx.QueryExecModeSimpleProtocol, nil
+	default:
+		return 0, fmt.Errorf("invalid query exec mode %q", value)
 	}
-	return nil
 }
@@ -0,0 +1,9 @@
+BEGIN TRANSACTION;
+
+DROP TABLE orders;
+
+DROP TABLE users;
+
+DROP EXTENSION pgcrypto;
+
+COMMIT;
\ No newline at end of file
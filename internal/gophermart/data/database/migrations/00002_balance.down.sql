@@ -0,0 +1,6 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE users
+    DROP COLUMN balance;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+DROP TABLE withdrawals;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,13 @@
+BEGIN TRANSACTION;
+
+-- fractional points are rounded, the revert is lossy
+ALTER TABLE withdrawals
+    ALTER COLUMN amount TYPE BIGINT;
+
+ALTER TABLE users
+    ALTER COLUMN balance TYPE BIGINT;
+
+ALTER TABLE orders
+    ALTER COLUMN accrual TYPE BIGINT;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,17 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE users
+    ALTER COLUMN id DROP IDENTITY;
+
+CREATE SEQUENCE users_id_seq OWNED BY users.id;
+
+ALTER TABLE users
+    ALTER COLUMN id SET DEFAULT nextval('users_id_seq');
+
+SELECT setval(
+               'users_id_seq',
+               coalesce((SELECT MAX(id) + 1 FROM users), 1),
+               false
+       );
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,6 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE orders
+    DROP COLUMN accrual_provider;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,10 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE orders
+    ADD COLUMN accrual_provider VARCHAR(64);
+
+UPDATE orders
+SET accrual_provider = 'http'
+WHERE status IN ('PROCESSED', 'INVALID');
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,14 @@
+BEGIN TRANSACTION;
+
+DROP INDEX orders_next_poll_at_idx;
+
+UPDATE orders
+SET status = 'PROCESSING'
+WHERE status = 'STUCK';
+
+ALTER TABLE orders
+    DROP COLUMN next_poll_at,
+    DROP COLUMN attempts,
+    DROP COLUMN last_error;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,12 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE orders
+    ADD COLUMN next_poll_at TIMESTAMP NOT NULL DEFAULT now(),
+    ADD COLUMN attempts     INTEGER   NOT NULL DEFAULT 0,
+    ADD COLUMN last_error   TEXT;
+
+CREATE INDEX orders_next_poll_at_idx
+    ON orders (next_poll_at)
+    WHERE status IN ('NEW', 'PROCESSING');
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE orders
+    DROP COLUMN lease_owner,
+    DROP COLUMN lease_expires_at;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE orders
+    ADD COLUMN lease_owner      VARCHAR(128),
+    ADD COLUMN lease_expires_at TIMESTAMP;
+
+COMMIT;
\ No newline at end of file
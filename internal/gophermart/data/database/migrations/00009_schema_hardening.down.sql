@@ -0,0 +1,30 @@
+BEGIN TRANSACTION;
+
+DROP INDEX withdrawals_user_id_idx;
+
+DROP INDEX orders_status_idx;
+
+DROP INDEX orders_user_id_idx;
+
+ALTER TABLE users
+    DROP CONSTRAINT users_balance_check,
+    ALTER COLUMN balance DROP DEFAULT,
+    ALTER COLUMN balance DROP NOT NULL;
+
+ALTER TABLE orders
+    DROP CONSTRAINT orders_status_check,
+    DROP CONSTRAINT orders_user_id_fkey,
+    ALTER COLUMN accrual DROP DEFAULT,
+    ALTER COLUMN accrual DROP NOT NULL,
+    ALTER COLUMN upload_time DROP NOT NULL,
+    ALTER COLUMN status DROP NOT NULL,
+    ALTER COLUMN user_id DROP NOT NULL;
+
+INSERT
+INTO orders
+SELECT *
+FROM orders_quarantine;
+
+DROP TABLE orders_quarantine;
+
+COMMIT;
\ No newline at end of file
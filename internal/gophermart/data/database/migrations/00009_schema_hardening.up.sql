@@ -0,0 +1,59 @@
+BEGIN TRANSACTION;
+
+-- orders of nonexistent users can't be served by any endpoint, they are kept aside for manual review
+-- instead of being lost
+CREATE TABLE orders_quarantine
+(
+    LIKE orders
+);
+
+WITH orphans AS (
+    DELETE
+    FROM orders
+    WHERE user_id IS NULL
+       OR user_id NOT IN (SELECT id FROM users)
+    RETURNING *)
+INSERT
+INTO orders_quarantine
+SELECT *
+FROM orphans;
+
+UPDATE orders
+SET status = 'NEW'
+WHERE status IS NULL;
+
+UPDATE orders
+SET upload_time = now()
+WHERE upload_time IS NULL;
+
+UPDATE orders
+SET accrual = 0
+WHERE accrual IS NULL;
+
+UPDATE users
+SET balance = 0
+WHERE balance IS NULL;
+
+-- negative balances and unknown statuses are not backfilled, the checks below fail on them
+-- to be fixed manually
+ALTER TABLE orders
+    ALTER COLUMN user_id SET NOT NULL,
+    ALTER COLUMN status SET NOT NULL,
+    ALTER COLUMN upload_time SET NOT NULL,
+    ALTER COLUMN accrual SET NOT NULL,
+    ALTER COLUMN accrual SET DEFAULT 0,
+    ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id),
+    ADD CONSTRAINT orders_status_check CHECK (status IN ('NEW', 'PROCESSING', 'PROCESSED', 'INVALID', 'STUCK'));
+
+ALTER TABLE users
+    ALTER COLUMN balance SET NOT NULL,
+    ALTER COLUMN balance SET DEFAULT 0,
+    ADD CONSTRAINT users_balance_check CHECK (balance >= 0);
+
+CREATE INDEX orders_user_id_idx ON orders (user_id);
+
+CREATE INDEX orders_status_idx ON orders (status);
+
+CREATE INDEX withdrawals_user_id_idx ON withdrawals (user_id);
+
+COMMIT;
\ No newline at end of file
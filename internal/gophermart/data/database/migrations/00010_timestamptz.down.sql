@@ -0,0 +1,13 @@
+BEGIN TRANSACTION;
+
+ALTER TABLE withdrawals
+    ALTER COLUMN process_time TYPE TIMESTAMP
+        USING process_time AT TIME ZONE COALESCE(NULLIF(current_setting('gophermart.legacy_timezone', true), ''), 'UTC');
+
+ALTER TABLE orders
+    ALTER COLUMN lease_expires_at TYPE TIMESTAMP,
+    ALTER COLUMN next_poll_at TYPE TIMESTAMP,
+    ALTER COLUMN upload_time TYPE TIMESTAMP
+        USING upload_time AT TIME ZONE COALESCE(NULLIF(current_setting('gophermart.legacy_timezone', true), ''), 'UTC');
+
+COMMIT;
\ No newline at end of file
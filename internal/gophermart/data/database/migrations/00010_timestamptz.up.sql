@@ -0,0 +1,19 @@
+BEGIN TRANSACTION;
+
+-- upload_time and process_time were written as the wall clock of the application server.
+-- Before migrating set gophermart.legacy_timezone to the time zone that server ran in, e.g.
+--   ALTER DATABASE gophermart SET gophermart.legacy_timezone = 'Europe/Moscow';
+-- or add "options=-c gophermart.legacy_timezone=Europe/Moscow" to the connection string.
+-- Without the setting the values are taken as UTC.
+-- next_poll_at and lease_expires_at were written by now() and are converted using the session time zone.
+ALTER TABLE orders
+    ALTER COLUMN upload_time TYPE TIMESTAMPTZ
+        USING upload_time AT TIME ZONE COALESCE(NULLIF(current_setting('gophermart.legacy_timezone', true), ''), 'UTC'),
+    ALTER COLUMN next_poll_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN lease_expires_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE withdrawals
+    ALTER COLUMN process_time TYPE TIMESTAMPTZ
+        USING process_time AT TIME ZONE COALESCE(NULLIF(current_setting('gophermart.legacy_timezone', true), ''), 'UTC');
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,25 @@
+BEGIN TRANSACTION;
+
+DROP INDEX withdrawals_user_id_program_id_idx;
+
+ALTER TABLE withdrawals
+    DROP COLUMN program_id;
+
+ALTER TABLE orders
+    DROP COLUMN program_id;
+
+-- points of other programs are merged into the single balance
+ALTER TABLE users
+    ADD COLUMN balance DECIMAL(16, 3) NOT NULL DEFAULT 0,
+    ADD CONSTRAINT users_balance_check CHECK (balance >= 0);
+
+UPDATE users
+SET balance = totals.balance
+FROM (SELECT user_id, SUM(balance) AS balance FROM user_balances GROUP BY user_id) AS totals
+WHERE users.id = totals.user_id;
+
+DROP TABLE user_balances;
+
+DROP TABLE programs;
+
+COMMIT;
\ No newline at end of file
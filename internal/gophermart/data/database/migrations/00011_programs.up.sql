@@ -0,0 +1,39 @@
+BEGIN TRANSACTION;
+
+-- programs are managed by operators, points of different programs never mix
+CREATE TABLE programs
+(
+    id   VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(256) NOT NULL
+);
+
+INSERT INTO programs (id, name)
+VALUES ('default', 'Gophermart points');
+
+CREATE TABLE user_balances
+(
+    user_id    INTEGER        NOT NULL REFERENCES users (id),
+    program_id VARCHAR(64)    NOT NULL REFERENCES programs (id),
+    balance    DECIMAL(16, 3) NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, program_id),
+    CONSTRAINT user_balances_balance_check CHECK (balance >= 0)
+);
+
+INSERT INTO user_balances (user_id, program_id, balance)
+SELECT id, 'default', balance
+FROM users
+WHERE balance <> 0;
+
+ALTER TABLE users
+    DROP COLUMN balance;
+
+-- program_id of an order is the requested program until it is processed, then the credited one
+ALTER TABLE orders
+    ADD COLUMN program_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES programs (id);
+
+ALTER TABLE withdrawals
+    ADD COLUMN program_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES programs (id);
+
+CREATE INDEX withdrawals_user_id_program_id_idx ON withdrawals (user_id, program_id);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,7 @@
+BEGIN TRANSACTION;
+
+DROP TABLE point_expirations;
+
+DROP TABLE point_lots;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,40 @@
+BEGIN TRANSACTION;
+
+-- every credit is a lot, user_balances.balance is the sum of remaining amounts of the user lots
+CREATE TABLE point_lots
+(
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      INTEGER        NOT NULL REFERENCES users (id),
+    program_id   VARCHAR(64)    NOT NULL REFERENCES programs (id),
+    order_number VARCHAR(1024) REFERENCES orders (number),
+    amount       DECIMAL(16, 3) NOT NULL,
+    remaining    DECIMAL(16, 3) NOT NULL,
+    credited_at  TIMESTAMPTZ    NOT NULL,
+    -- NULL means the lot never expires
+    expires_at   TIMESTAMPTZ,
+    CONSTRAINT point_lots_remaining_check CHECK (remaining >= 0 AND remaining <= amount)
+);
+
+CREATE INDEX point_lots_user_id_program_id_idx ON point_lots (user_id, program_id, credited_at, id) WHERE remaining > 0;
+CREATE INDEX point_lots_expires_at_idx ON point_lots (expires_at) WHERE remaining > 0;
+CREATE INDEX point_lots_order_number_idx ON point_lots (order_number, credited_at) WHERE order_number IS NOT NULL;
+
+CREATE TABLE point_expirations
+(
+    id         BIGSERIAL PRIMARY KEY,
+    lot_id     BIGINT         NOT NULL REFERENCES point_lots (id),
+    user_id    INTEGER        NOT NULL REFERENCES users (id),
+    program_id VARCHAR(64)    NOT NULL REFERENCES programs (id),
+    amount     DECIMAL(16, 3) NOT NULL,
+    expired_at TIMESTAMPTZ    NOT NULL
+);
+
+CREATE INDEX point_expirations_user_id_idx ON point_expirations (user_id);
+
+-- credit dates of existing balances are unknown, they are kept as lots that never expire
+INSERT INTO point_lots (user_id, program_id, amount, remaining, credited_at)
+SELECT user_id, program_id, balance, balance, now()
+FROM user_balances
+WHERE balance > 0;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,8 @@
+BEGIN TRANSACTION;
+
+DROP TABLE holds;
+
+ALTER TABLE user_balances
+    DROP COLUMN on_hold;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,28 @@
+BEGIN TRANSACTION;
+
+-- on_hold is the part of balance reserved by active holds, it is not available for withdrawals
+ALTER TABLE user_balances
+    ADD COLUMN on_hold DECIMAL(16, 3) NOT NULL DEFAULT 0,
+    ADD CONSTRAINT user_balances_on_hold_check CHECK (on_hold >= 0);
+
+CREATE TABLE holds
+(
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      INTEGER        NOT NULL REFERENCES users (id),
+    program_id   VARCHAR(64)    NOT NULL REFERENCES programs (id),
+    order_number VARCHAR(1024)  NOT NULL,
+    amount       DECIMAL(16, 3) NOT NULL,
+    status       VARCHAR(32)    NOT NULL,
+    created_at   TIMESTAMPTZ    NOT NULL,
+    expires_at   TIMESTAMPTZ    NOT NULL,
+    closed_at    TIMESTAMPTZ,
+    CONSTRAINT holds_amount_check CHECK (amount > 0),
+    CONSTRAINT holds_status_check CHECK (status IN ('ACTIVE', 'CAPTURED', 'RELEASED', 'EXPIRED'))
+);
+
+CREATE INDEX holds_user_id_idx ON holds (user_id);
+CREATE INDEX holds_expires_at_idx ON holds (expires_at) WHERE status = 'ACTIVE';
+-- an order is paid by one hold at most, captured holds become withdrawals of the same order
+CREATE UNIQUE INDEX holds_order_number_idx ON holds (order_number) WHERE status IN ('ACTIVE', 'CAPTURED');
+
+COMMIT;
\ No newline at end of file
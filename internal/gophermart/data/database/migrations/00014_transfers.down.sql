@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+DROP TABLE transfers;
+
+COMMIT;
\ No newline at end of file
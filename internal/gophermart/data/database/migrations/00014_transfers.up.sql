@@ -0,0 +1,21 @@
+BEGIN TRANSACTION;
+
+CREATE TABLE transfers
+(
+    id           BIGSERIAL PRIMARY KEY,
+    sender_id    INTEGER        NOT NULL REFERENCES users (id),
+    recipient_id INTEGER        NOT NULL REFERENCES users (id),
+    program_id   VARCHAR(64)    NOT NULL REFERENCES programs (id),
+    amount       DECIMAL(16, 3) NOT NULL,
+    status       VARCHAR(32)    NOT NULL,
+    created_at   TIMESTAMPTZ    NOT NULL,
+    closed_at    TIMESTAMPTZ,
+    CONSTRAINT transfers_amount_check CHECK (amount > 0),
+    CONSTRAINT transfers_users_check CHECK (sender_id <> recipient_id),
+    CONSTRAINT transfers_status_check CHECK (status IN ('PENDING', 'COMPLETED', 'DECLINED', 'CANCELLED'))
+);
+
+CREATE INDEX transfers_sender_id_idx ON transfers (sender_id, created_at);
+CREATE INDEX transfers_recipient_id_idx ON transfers (recipient_id, created_at);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,12 @@
+BEGIN TRANSACTION;
+
+DROP TABLE accrual_adjustments;
+
+ALTER TABLE orders
+    DROP COLUMN adjusted_accrual;
+
+-- negative balances are not written off, the check fails on them
+ALTER TABLE user_balances
+    ADD CONSTRAINT user_balances_balance_check CHECK (balance >= 0);
+
+COMMIT;
\ No newline at end of file
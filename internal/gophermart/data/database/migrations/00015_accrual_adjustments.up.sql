@@ -0,0 +1,27 @@
+BEGIN TRANSACTION;
+
+-- clawbacks may leave a negative balance, credits cover it before new points are kept in lots
+ALTER TABLE user_balances
+    DROP CONSTRAINT user_balances_balance_check;
+
+-- NULL means the accrual was never adjusted
+ALTER TABLE orders
+    ADD COLUMN adjusted_accrual DECIMAL(16, 3);
+
+CREATE TABLE accrual_adjustments
+(
+    id               BIGSERIAL PRIMARY KEY,
+    order_number     VARCHAR(1024)  NOT NULL REFERENCES orders (number),
+    user_id          INTEGER        NOT NULL REFERENCES users (id),
+    program_id       VARCHAR(64)    NOT NULL REFERENCES programs (id),
+    previous_accrual DECIMAL(16, 3) NOT NULL,
+    accrual          DECIMAL(16, 3) NOT NULL,
+    reason           TEXT           NOT NULL,
+    created_at       TIMESTAMPTZ    NOT NULL,
+    CONSTRAINT accrual_adjustments_accrual_check CHECK (accrual >= 0),
+    CONSTRAINT accrual_adjustments_reason_check CHECK (reason <> '')
+);
+
+CREATE INDEX accrual_adjustments_order_number_idx ON accrual_adjustments (order_number);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,5 @@
+BEGIN TRANSACTION;
+
+DROP INDEX accrual_adjustments_user_id_idx;
+
+COMMIT;
\ No newline at end of file
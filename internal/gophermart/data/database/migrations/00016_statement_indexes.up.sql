@@ -0,0 +1,6 @@
+BEGIN TRANSACTION;
+
+-- statements read adjustments of one user
+CREATE INDEX accrual_adjustments_user_id_idx ON accrual_adjustments (user_id, program_id);
+
+COMMIT;
\ No newline at end of file
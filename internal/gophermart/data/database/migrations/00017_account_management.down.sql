@@ -0,0 +1,13 @@
+BEGIN TRANSACTION;
+
+-- erased users keep their anonymized logins, an empty password never matches
+UPDATE users
+SET password = ''
+WHERE password IS NULL;
+
+ALTER TABLE users
+    DROP COLUMN session_version,
+    DROP COLUMN deleted_at,
+    ALTER COLUMN password SET NOT NULL;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,9 @@
+BEGIN TRANSACTION;
+
+-- tokens carry the session version they were issued for, bumping it revokes all tokens of the user
+ALTER TABLE users
+    ADD COLUMN session_version INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN deleted_at      TIMESTAMPTZ,
+    ALTER COLUMN password DROP NOT NULL;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+)
+
+// migrationLockKey is the key of the advisory lock held while migrating, it spells "gomarket".
+const migrationLockKey int64 = 0x676f6d61726b6574
+
+//go:embed migrations/*.sql
+var migrationsDir embed.FS
+
+type MigrationStatus struct {
+	Version uint
+	Latest  uint
+	Dirty   bool
+	// Applied is false when the database has no migrations yet.
+	Applied bool
+}
+
+// Migrator applies the embedded migrations holding an advisory lock,
+// so replicas started together wait for each other instead of racing.
+type Migrator struct {
+	connectionString string
+}
+
+func NewMigrator(connectionString string) *Migrator {
+	return &Migrator{
+		connectionString: connectionString,
+	}
+}
+
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(instance *migrate.Migrate, _ source.Driver) error {
+		if err := instance.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("failed to apply migrations to the DB: %w", err)
+		}
+		return nil
+	})
+}
+
+// Down reverts the given number of the latest applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("invalid number of steps %d", steps)
+	}
+	return m.withLock(ctx, func(instance *migrate.Migrate, _ source.Driver) error {
+		if err := instance.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("failed to revert migrations: %w", err)
+		}
+		return nil
+	})
+}
+
+// Force sets the version without running migrations and clears the dirty flag.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	return m.withLock(ctx, func(instance *migrate.Migrate, _ source.Driver) error {
+		if err := instance.Force(version); err != nil {
+			return fmt.Errorf("failed to force version %d: %w", version, err)
+		}
+		return nil
+	})
+}
+
+func (m *Migrator) Status(ctx context.Context) (MigrationStatus, error) {
+	res := MigrationStatus{}
+	err := m.withLock(ctx, func(instance *migrate.Migrate, sourceDriver source.Driver) error {
+		latest, err := latestVersion(sourceDriver)
+		if err != nil {
+			return err
+		}
+		res.Latest = latest
+		version, dirty, err := instance.Version()
+		if err != nil {
+			if errors.Is(err, migrate.ErrNilVersion) {
+				return nil
+			}
+			return fmt.Errorf("failed to get the DB version: %w", err)
+		}
+		res.Version = version
+		res.Dirty = dirty
+		res.Applied = true
+		return nil
+	})
+	return res, err
+}
+
+func (m *Migrator) withLock(ctx context.Context, f func(*migrate.Migrate, source.Driver) error) (err error) {
+	conn, err := pgx.Connect(ctx, m.connectionString)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the DB: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, conn.Close(context.Background()))
+	}()
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire the migration lock: %w", err)
+	}
+	defer func() {
+		_, unlockErr := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+		if unlockErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to release the migration lock: %w", unlockErr))
+		}
+	}()
+
+	sourceDriver, err := iofs.New(migrationsDir, "migrations")
+	if err != nil {
+		return fmt.Errorf("failed to return an iofs driver: %w", err)
+	}
+	instance, err := migrate.NewWithSourceInstance("iofs", sourceDriver, m.connectionString)
+	if err != nil {
+		return fmt.Errorf("failed to get a new migrate instance: %w", err)
+	}
+	defer func() {
+		sourceErr, dbErr := instance.Close()
+		err = errors.Join(err, sourceErr, dbErr)
+	}()
+	return f(instance, sourceDriver)
+}
+
+func latestVersion(sourceDriver source.Driver) (uint, error) {
+	version, err := sourceDriver.First()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	for {
+		next, err := sourceDriver.Next(version)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return version, nil
+			}
+			return 0, fmt.Errorf("failed to read migrations: %w", err)
+		}
+		version = next
+	}
+}
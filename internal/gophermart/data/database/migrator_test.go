@@ -0,0 +1,27 @@
+package database
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationsAreReversible(t *testing.T) {
+	ups, err := fs.Glob(migrationsDir, "migrations/*.up.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, ups)
+	for _, up := range ups {
+		_, err := fs.Stat(migrationsDir, strings.TrimSuffix(up, ".up.sql")+".down.sql")
+		assert.NoError(t, err, "no down migration for %s", up)
+	}
+
+	sourceDriver, err := iofs.New(migrationsDir, "migrations")
+	require.NoError(t, err)
+	latest, err := latestVersion(sourceDriver)
+	require.NoError(t, err)
+	assert.Equal(t, uint(len(ups)), latest)
+}
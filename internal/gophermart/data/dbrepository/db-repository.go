@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"go-market/internal/gophermart/data"
 	"go-market/pkg/logging"
+	"go-market/pkg/pgxstorage"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -42,7 +44,12 @@ func New(storage DBStorage, logger *logging.ZapLogger) *DBRepository {
 var insertUserQuery string
 
 func (db *DBRepository) InsertUser(ctx context.Context, login, password string) (userID int, err error) {
-	err = db.storage.QueryValue(ctx, insertUserQuery, []any{login, password}, []any{&userID})
+	err = db.storage.QueryValue(
+		ctx,
+		insertUserQuery,
+		[]any{login, password},
+		[]any{&userID},
+	)
 	if err != nil {
 		return invalidUserID, handleSQLError(err)
 	}
@@ -52,29 +59,147 @@ func (db *DBRepository) InsertUser(ctx context.Context, login, password string)
 //go:embed sql/validate_user.sql
 var validateUserQuery string
 
-func (db *DBRepository) ValidateUser(ctx context.Context, login, password string) (userID int, err error) {
+func (db *DBRepository) ValidateUser(
+	ctx context.Context,
+	login, password string,
+) (userID int, sessionVersion int, err error) {
 	result := struct {
 		userID          int
+		sessionVersion  int
 		passwordMatches bool
 	}{}
+	// a changed password or login must be effective right away, the replica may still have the old ones
 	err = db.storage.QueryValue(
 		ctx,
 		validateUserQuery,
 		[]any{login, password},
-		[]any{&result.userID, &result.passwordMatches},
+		[]any{&result.userID, &result.sessionVersion, &result.passwordMatches},
 	)
 	if err != nil {
 		switch {
 		case errors.Is(err, pgx.ErrNoRows):
-			return invalidUserID, data.ErrInvalidLogin
+			return invalidUserID, 0, data.ErrInvalidLogin
 		default:
-			return invalidUserID, fmt.Errorf("failed to validate user: %w", err)
+			return invalidUserID, 0, fmt.Errorf("failed to validate user: %w", err)
 		}
 	}
 	if !result.passwordMatches {
-		return invalidUserID, data.ErrInvalidPassword
+		return invalidUserID, 0, data.ErrInvalidPassword
+	}
+	return result.userID, result.sessionVersion, nil
+}
+
+//go:embed sql/select_user_session_version.sql
+var selectUserSessionVersionQuery string
+
+// GetUserSessionVersion returns ErrNotFound for erased users.
+func (db *DBRepository) GetUserSessionVersion(ctx context.Context, userID int) (sessionVersion int, err error) {
+	// sessions must be revoked right away, the replica may still have the old version
+	err = db.storage.QueryValue(
+		ctx,
+		selectUserSessionVersionQuery,
+		[]any{userID},
+		[]any{&sessionVersion},
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return 0, data.ErrNotFound
+		default:
+			return 0, handleSQLError(err)
+		}
+	}
+	return sessionVersion, nil
+}
+
+//go:embed sql/update_user_password.sql
+var updateUserPasswordQuery string
+
+// UpdateUserPassword replaces the password if oldPassword matches and bumps the session version,
+// it returns ErrInvalidPassword otherwise.
+func (db *DBRepository) UpdateUserPassword(
+	ctx context.Context,
+	userID int,
+	oldPassword, newPassword string,
+) (sessionVersion int, err error) {
+	err = db.storage.QueryValue(
+		ctx,
+		updateUserPasswordQuery,
+		[]any{userID, oldPassword, newPassword},
+		[]any{&sessionVersion},
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return 0, data.ErrInvalidPassword
+		default:
+			return 0, handleSQLError(err)
+		}
+	}
+	return sessionVersion, nil
+}
+
+//go:embed sql/update_user_login.sql
+var updateUserLoginQuery string
+
+func (db *DBRepository) UpdateUserLogin(ctx context.Context, userID int, login string) error {
+	tag, err := db.storage.Exec(ctx, updateUserLoginQuery, userID, login)
+	if err != nil {
+		return handleSQLError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return data.ErrNotFound
+	}
+	return nil
+}
+
+//go:embed sql/erase_user.sql
+var eraseUserQuery string
+
+// EraseUser replaces the login with anonymizedLogin and drops the password,
+// orders, withdrawals and other financial rows of the user are kept.
+func (db *DBRepository) EraseUser(ctx context.Context, userID int, anonymizedLogin string, erasedAt time.Time) error {
+	tag, err := db.storage.Exec(ctx, eraseUserQuery, userID, anonymizedLogin, erasedAt)
+	if err != nil {
+		return handleSQLError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return data.ErrNotFound
+	}
+	return nil
+}
+
+//go:embed sql/select_user_id.sql
+var selectUserIDQuery string
+
+func (db *DBRepository) GetUserIDByLogin(ctx context.Context, login string) (userID int, err error) {
+	err = db.storage.QueryValue(ctx, selectUserIDQuery, []any{login}, []any{&userID})
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return invalidUserID, data.ErrNotFound
+		default:
+			return invalidUserID, handleSQLError(err)
+		}
+	}
+	return userID, nil
+}
+
+//go:embed sql/lock_users.sql
+var lockUsersQuery string
+
+// LockUsers locks the users rows in the id order until the end of the transaction,
+// so that concurrent transactions locking the same users do not deadlock.
+func (db *DBRepository) LockUsers(ctx context.Context, userIDs ...int) error {
+	rows, err := db.storage.Query(ctx, lockUsersQuery, userIDs)
+	if err != nil {
+		return handleSQLError(err)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return handleSQLError(err)
 	}
-	return result.userID, nil
+	return nil
 }
 
 //go:embed sql/insert_order.sql
@@ -89,6 +214,7 @@ func (db *DBRepository) InsertOrder(ctx context.Context, order *data.Order) erro
 		order.UserID,
 		order.Accrual,
 		order.UploadTime,
+		order.ProgramID,
 	)
 	if err != nil {
 		return handleSQLError(err)
@@ -100,7 +226,13 @@ func (db *DBRepository) InsertOrder(ctx context.Context, order *data.Order) erro
 var selectOrderOwnerQuery string
 
 func (db *DBRepository) GetOrderOwner(ctx context.Context, orderNumber string) (userID int, err error) {
-	err = db.storage.QueryValue(ctx, selectOrderOwnerQuery, []any{orderNumber}, []any{&userID})
+	// the owner is checked right after a conflicting insert, so the replica may not have the order yet
+	err = db.storage.QueryValue(
+		ctx,
+		selectOrderOwnerQuery,
+		[]any{orderNumber},
+		[]any{&userID},
+	)
 	if err != nil {
 		return invalidUserID, handleSQLError(err)
 	}
@@ -111,7 +243,7 @@ func (db *DBRepository) GetOrderOwner(ctx context.Context, orderNumber string) (
 var selectOrdersQuery string
 
 func (db *DBRepository) GetAllUserOrders(ctx context.Context, userID int) ([]data.Order, error) {
-	rows, err := db.storage.Query(ctx, selectOrdersQuery, userID)
+	rows, err := db.storage.Query(pgxstorage.WithReplica(ctx), selectOrdersQuery, userID)
 	if err != nil {
 		return nil, handleSQLError(err)
 	}
@@ -134,37 +266,47 @@ func (db *DBRepository) GetAllUserOrders(ctx context.Context, userID int) ([]dat
 		err := rows.Scan(
 			&order.OrderNumber,
 			&order.Accrual,
+			&order.AdjustedAccrual,
 			&order.UploadTime,
 			&order.Status,
+			&order.ProgramID,
 		)
 		if err != nil {
 			return nil, handleSQLError(err)
 		}
+		order.UploadTime = order.UploadTime.UTC()
 		result = append(result, order)
 	}
 	return result, nil
 }
 
+// GetOrders claims due orders for the lease owner. Orders leased by others are skipped until their lease expires.
 func (db *DBRepository) GetOrders(
 	ctx context.Context,
+	lease data.Lease,
 	limit int,
 	allowedStatuses ...data.Status,
 ) ([]data.Order, error) {
-	query := "SELECT number, user_id, accrual, upload_time, status FROM orders"
+	args := []any{lease.Owner, lease.Duration.Seconds()}
+	dueQuery := "SELECT number FROM orders" +
+		" WHERE next_poll_at <= now() AND (lease_expires_at IS NULL OR lease_expires_at <= now())"
 	if len(allowedStatuses) > 0 {
-		//nolint:gomnd // param number start with 2 because 1st is taken by limit
-		query += fmt.Sprintf(" WHERE status IN (%s)", formatParams(2, len(allowedStatuses)))
-	}
-	if limit > 0 {
-		query += " LIMIT $1"
+		dueQuery += fmt.Sprintf(" AND status IN (%s)", formatParams(len(args)+1, len(allowedStatuses)))
+		for _, allowedStatus := range allowedStatuses {
+			args = append(args, string(allowedStatus))
+		}
 	}
-	args := make([]any, 0)
+	dueQuery += " ORDER BY next_poll_at"
 	if limit > 0 {
 		args = append(args, limit)
+		dueQuery += fmt.Sprintf(" LIMIT $%v", len(args))
 	}
-	for _, allowedStatus := range allowedStatuses {
-		args = append(args, string(allowedStatus))
-	}
+	dueQuery += " FOR UPDATE SKIP LOCKED"
+	query := "WITH due AS (" + dueQuery + ")" +
+		" UPDATE orders SET lease_owner = $1, lease_expires_at = now() + make_interval(secs => $2)" +
+		" FROM due WHERE orders.number = due.number" +
+		" RETURNING orders.number, orders.user_id, orders.accrual, orders.upload_time, orders.status, orders.attempts," +
+		" orders.program_id, orders.lease_expires_at"
 	rows, err := db.storage.Query(ctx, query, args...)
 	if err != nil {
 		return nil, handleSQLError(err)
@@ -189,82 +331,315 @@ func (db *DBRepository) GetOrders(
 			&order.Accrual,
 			&order.UploadTime,
 			&order.Status,
+			&order.Attempts,
+			&order.ProgramID,
+			&order.LeaseExpiresAt,
 		)
 		if err != nil {
 			return nil, handleSQLError(err)
 		}
+		order.UploadTime = order.UploadTime.UTC()
 		result = append(result, order)
 	}
 	return result, nil
 }
 
+//go:embed sql/schedule_order_poll.sql
+var scheduleOrderPollQuery string
+
+func (db *DBRepository) ScheduleOrderPoll(
+	ctx context.Context,
+	orderNumber string,
+	status data.Status,
+	accrualProvider string,
+	delay time.Duration,
+	lastError string,
+) error {
+	_, err := db.storage.Exec(
+		ctx,
+		scheduleOrderPollQuery,
+		orderNumber,
+		status,
+		accrualProvider,
+		delay.Seconds(),
+		lastError,
+	)
+	if err != nil {
+		return handleSQLError(err)
+	}
+	return nil
+}
+
+//go:embed sql/update_stuck_orders.sql
+var updateStuckOrdersQuery string
+
+func (db *DBRepository) MarkStuckOrders(ctx context.Context, maxAge time.Duration) (count int64, err error) {
+	tag, err := db.storage.Exec(ctx, updateStuckOrdersQuery, maxAge.Seconds())
+	if err != nil {
+		return 0, handleSQLError(err)
+	}
+	return tag.RowsAffected(), nil
+}
+
 //go:embed sql/select_user_balance.sql
 var selectUserBalanceQuery string
 
-func (db *DBRepository) GetUserBalance(ctx context.Context, userID int) (decimal.Decimal, error) {
-	var t *decimal.Decimal
-	err := db.storage.QueryValue(ctx, selectUserBalanceQuery, []any{userID}, []any{&t})
+func (db *DBRepository) GetUserBalance(ctx context.Context, userID int, programID string) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+	err := db.storage.QueryValue(ctx, selectUserBalanceQuery, []any{userID, programID}, []any{&balance})
 	if err != nil {
-		return decimal.Decimal{}, handleSQLError(err)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return decimal.Zero, nil
+		default:
+			return decimal.Zero, handleSQLError(err)
+		}
 	}
-	if t == nil {
-		return decimal.Zero, nil
+	return balance, nil
+}
+
+//go:embed sql/select_user_balances.sql
+var selectUserBalancesQuery string
+
+// GetUserBalances returns balances of programs the user has points or withdrawals in, and of requiredProgramID.
+// Points of lots expiring before expiringBefore are reported as expiring.
+func (db *DBRepository) GetUserBalances(
+	ctx context.Context,
+	userID int,
+	requiredProgramID string,
+	expiringBefore time.Time,
+) ([]data.ProgramBalance, error) {
+	rows, err := db.storage.Query(
+		pgxstorage.WithReplica(ctx),
+		selectUserBalancesQuery,
+		userID,
+		requiredProgramID,
+		expiringBefore,
+	)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	result := make([]data.ProgramBalance, 0)
+	for rows.Next() {
+		var balance data.ProgramBalance
+		var expiringAt *time.Time
+		err := rows.Scan(
+			&balance.ProgramID,
+			&balance.ProgramName,
+			&balance.Balance,
+			&balance.OnHold,
+			&balance.Withdrawals,
+			&balance.Expiring,
+			&expiringAt,
+		)
+		if err != nil {
+			return nil, handleSQLError(err)
+		}
+		if expiringAt != nil {
+			balance.ExpiringAt = expiringAt.UTC()
+		}
+		result = append(result, balance)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err)
+	}
+	return result, nil
+}
+
+//go:embed sql/select_program_exists.sql
+var selectProgramExistsQuery string
+
+func (db *DBRepository) ProgramExists(ctx context.Context, programID string) (exists bool, err error) {
+	err = db.storage.QueryValue(ctx, selectProgramExistsQuery, []any{programID}, []any{&exists})
+	if err != nil {
+		return false, handleSQLError(err)
+	}
+	return exists, nil
+}
+
+//go:embed sql/increment_user_balance.sql
+var incrementUserBalanceQuery string
+
+func (db *DBRepository) IncrementUserBalance(
+	ctx context.Context,
+	userID int,
+	programID string,
+	delta decimal.Decimal,
+) (balance decimal.Decimal, err error) {
+	err = db.storage.QueryValue(
+		ctx,
+		incrementUserBalanceQuery,
+		[]any{userID, programID, delta},
+		[]any{&balance},
+	)
+	if err != nil {
+		return decimal.Zero, handleSQLError(err)
 	}
-	return *t, nil
+	return balance, nil
 }
 
-//go:embed sql/update_user_balance.sql
-var updateUserBalanceQuery string
+//go:embed sql/debit_user_balance.sql
+var debitUserBalanceQuery string
 
-func (db *DBRepository) SetUserBalance(ctx context.Context, userID int, value decimal.Decimal) error {
-	_, err := db.storage.Exec(ctx, updateUserBalanceQuery, userID, value)
+// DebitUserBalanceIfSufficient returns data.ErrInsufficientBalance and keeps the balance if it is less than amount.
+func (db *DBRepository) DebitUserBalanceIfSufficient(
+	ctx context.Context,
+	userID int,
+	programID string,
+	amount decimal.Decimal,
+) (balance decimal.Decimal, err error) {
+	err = db.storage.QueryValue(
+		ctx,
+		debitUserBalanceQuery,
+		[]any{userID, programID, amount},
+		[]any{&balance},
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return decimal.Zero, data.ErrInsufficientBalance
+		default:
+			return decimal.Zero, handleSQLError(err)
+		}
+	}
+	return balance, nil
+}
+
+//go:embed sql/select_order_for_update.sql
+var selectOrderForUpdateQuery string
+
+// GetOrderForUpdate returns the order locked until the end of the transaction regardless of its lease.
+func (db *DBRepository) GetOrderForUpdate(ctx context.Context, orderNumber string) (data.Order, error) {
+	order := data.Order{
+		OrderNumber: orderNumber,
+	}
+	err := db.storage.QueryValue(
+		ctx,
+		selectOrderForUpdateQuery,
+		[]any{orderNumber},
+		[]any{&order.UserID, &order.Accrual, &order.AdjustedAccrual, &order.Status, &order.ProgramID},
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return data.Order{}, data.ErrNotFound
+		default:
+			return data.Order{}, handleSQLError(err)
+		}
+	}
+	return order, nil
+}
+
+//go:embed sql/update_order_adjusted_accrual.sql
+var updateOrderAdjustedAccrualQuery string
+
+func (db *DBRepository) SetOrderAdjustedAccrual(
+	ctx context.Context,
+	orderNumber string,
+	accrual decimal.Decimal,
+) error {
+	_, err := db.storage.Exec(ctx, updateOrderAdjustedAccrualQuery, orderNumber, accrual)
 	if err != nil {
 		return handleSQLError(err)
 	}
 	return nil
 }
 
+//go:embed sql/insert_accrual_adjustment.sql
+var insertAccrualAdjustmentQuery string
+
+func (db *DBRepository) InsertAccrualAdjustment(
+	ctx context.Context,
+	adjustment data.AccrualAdjustment,
+) (id int64, err error) {
+	err = db.storage.QueryValue(
+		ctx,
+		insertAccrualAdjustmentQuery,
+		[]any{
+			adjustment.OrderNumber,
+			adjustment.UserID,
+			adjustment.ProgramID,
+			adjustment.PreviousAccrual,
+			adjustment.Accrual,
+			adjustment.Reason,
+			adjustment.CreatedAt,
+		},
+		[]any{&id},
+	)
+	if err != nil {
+		return 0, handleSQLError(err)
+	}
+	return id, nil
+}
+
 //go:embed sql/select_order.sql
 var selectOrderQuery string
 
-func (db *DBRepository) GetOrder(ctx context.Context, orderNumber string) (userID int, status data.Status, err error) {
+// GetOrder locks the order leased by leaseOwner till the end of the transaction.
+func (db *DBRepository) GetOrder(
+	ctx context.Context,
+	orderNumber string,
+	leaseOwner string,
+) (userID int, status data.Status, err error) {
 	db.logger.DebugCtx(ctx, "getting order", zap.String("orderNumber", orderNumber))
-	err = db.storage.QueryValue(ctx, selectOrderQuery, []any{orderNumber}, []any{&userID, &status})
+	err = db.storage.QueryValue(ctx, selectOrderQuery, []any{orderNumber, leaseOwner}, []any{&userID, &status})
 	if err != nil {
-		return invalidUserID, data.NullStatus, handleSQLError(err)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return invalidUserID, data.NullStatus, data.ErrLeaseLost
+		default:
+			return invalidUserID, data.NullStatus, handleSQLError(err)
+		}
 	}
 	return
 }
 
-//go:embed sql/update_order_status.sql
-var updateOrderStatusQuery string
+//go:embed sql/release_order_lease.sql
+var releaseOrderLeaseQuery string
 
-func (db *DBRepository) SetOrderStatus(
+func (db *DBRepository) ReleaseOrderLease(ctx context.Context, orderNumber string, leaseOwner string) error {
+	_, err := db.storage.Exec(ctx, releaseOrderLeaseQuery, orderNumber, leaseOwner)
+	if err != nil {
+		return handleSQLError(err)
+	}
+	return nil
+}
+
+//go:embed sql/defer_order_poll.sql
+var deferOrderPollQuery string
+
+// DeferOrderPoll releases the lease and makes the order due at pollAt without counting a poll attempt.
+func (db *DBRepository) DeferOrderPoll(
 	ctx context.Context,
 	orderNumber string,
-	accrual decimal.Decimal,
-	status data.Status,
+	leaseOwner string,
+	pollAt time.Time,
 ) error {
-	_, err := db.storage.Exec(ctx, updateOrderStatusQuery, orderNumber, status, accrual)
+	_, err := db.storage.Exec(ctx, deferOrderPollQuery, orderNumber, leaseOwner, pollAt)
 	if err != nil {
 		return handleSQLError(err)
 	}
 	return nil
 }
 
-//go:embed sql/select_user_withdrawals_sum.sql
-var selectUserWithdrawalsSumQuery string
+//go:embed sql/update_order_status.sql
+var updateOrderStatusQuery string
 
-func (db *DBRepository) GetTotalUserWithdraw(ctx context.Context, userID int) (value decimal.Decimal, err error) {
-	var t *decimal.Decimal
-	err = db.storage.QueryValue(ctx, selectUserWithdrawalsSumQuery, []any{userID}, []any{&t})
+func (db *DBRepository) SetOrderStatus(
+	ctx context.Context,
+	orderNumber string,
+	accrual decimal.Decimal,
+	status data.Status,
+	accrualProvider string,
+	programID string,
+) error {
+	_, err := db.storage.Exec(ctx, updateOrderStatusQuery, orderNumber, status, accrual, accrualProvider, programID)
 	if err != nil {
-		return decimal.Zero, handleSQLError(err)
-	}
-	if t == nil {
-		return decimal.Zero, nil
+		return handleSQLError(err)
 	}
-	return *t, nil
+	return nil
 }
 
 //go:embed sql/insert_withdrawal.sql
@@ -278,18 +653,394 @@ func (db *DBRepository) InsertWithdrawal(ctx context.Context, withdrawal data.Wi
 		withdrawal.UserID,
 		withdrawal.Amount,
 		withdrawal.ProcessTime,
+		withdrawal.ProgramID,
+	)
+	if err != nil {
+		return handleSQLError(err)
+	}
+	return nil
+}
+
+//go:embed sql/insert_point_lot.sql
+var insertPointLotQuery string
+
+// InsertPointLot keeps in the lot only the part of the credit not covering a negative balance,
+// the user balance must be credited before.
+func (db *DBRepository) InsertPointLot(ctx context.Context, lot data.PointLot) error {
+	var expiresAt *time.Time
+	if !lot.ExpiresAt.IsZero() {
+		expiresAt = &lot.ExpiresAt
+	}
+	var orderNumber *string
+	if lot.OrderNumber != "" {
+		orderNumber = &lot.OrderNumber
+	}
+	_, err := db.storage.Exec(
+		ctx,
+		insertPointLotQuery,
+		lot.UserID,
+		lot.ProgramID,
+		orderNumber,
+		lot.Amount,
+		lot.CreditedAt,
+		expiresAt,
+	)
+	if err != nil {
+		return handleSQLError(err)
+	}
+	return nil
+}
+
+//go:embed sql/consume_point_lots.sql
+var consumePointLotsQuery string
+
+// ConsumePointLots takes up to amount from lots not expired at now, the oldest first, and returns the taken amount.
+func (db *DBRepository) ConsumePointLots(
+	ctx context.Context,
+	userID int,
+	programID string,
+	amount decimal.Decimal,
+	now time.Time,
+) (consumed decimal.Decimal, err error) {
+	err = db.storage.QueryValue(
+		ctx,
+		consumePointLotsQuery,
+		[]any{userID, programID, amount, now},
+		[]any{&consumed},
+	)
+	if err != nil {
+		return decimal.Zero, handleSQLError(err)
+	}
+	return consumed, nil
+}
+
+//go:embed sql/move_point_lots.sql
+var movePointLotsQuery string
+
+// MovePointLots takes up to amount from the sender lots like ConsumePointLots and credits the taken points
+// to the recipient as lots with the same expiration, returns the moved amount. The recipient balance must be
+// credited before, like for InsertPointLot.
+func (db *DBRepository) MovePointLots(
+	ctx context.Context,
+	senderID int,
+	recipientID int,
+	programID string,
+	amount decimal.Decimal,
+	now time.Time,
+) (moved decimal.Decimal, err error) {
+	err = db.storage.QueryValue(
+		ctx,
+		movePointLotsQuery,
+		[]any{senderID, programID, amount, now, recipientID},
+		[]any{&moved},
+	)
+	if err != nil {
+		return decimal.Zero, handleSQLError(err)
+	}
+	return moved, nil
+}
+
+//go:embed sql/expire_point_lots.sql
+var expirePointLotsQuery string
+
+// ExpirePointLots writes off up to limit lots expired at now and returns the number of expired lots.
+func (db *DBRepository) ExpirePointLots(ctx context.Context, now time.Time, limit int) (count int64, err error) {
+	err = db.storage.QueryValue(
+		ctx,
+		expirePointLotsQuery,
+		[]any{now, limit},
+		[]any{&count},
+	)
+	if err != nil {
+		return 0, handleSQLError(err)
+	}
+	return count, nil
+}
+
+//go:embed sql/reserve_user_balance.sql
+var reserveUserBalanceQuery string
+
+// ReserveUserBalanceIfSufficient moves amount of the available balance on hold and returns the available rest.
+func (db *DBRepository) ReserveUserBalanceIfSufficient(
+	ctx context.Context,
+	userID int,
+	programID string,
+	amount decimal.Decimal,
+) (available decimal.Decimal, err error) {
+	err = db.storage.QueryValue(
+		ctx,
+		reserveUserBalanceQuery,
+		[]any{userID, programID, amount},
+		[]any{&available},
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return decimal.Zero, data.ErrInsufficientBalance
+		default:
+			return decimal.Zero, handleSQLError(err)
+		}
+	}
+	return available, nil
+}
+
+//go:embed sql/unreserve_user_balance.sql
+var unreserveUserBalanceQuery string
+
+func (db *DBRepository) UnreserveUserBalance(
+	ctx context.Context,
+	userID int,
+	programID string,
+	amount decimal.Decimal,
+) error {
+	_, err := db.storage.Exec(ctx, unreserveUserBalanceQuery, userID, programID, amount)
+	if err != nil {
+		return handleSQLError(err)
+	}
+	return nil
+}
+
+//go:embed sql/insert_hold.sql
+var insertHoldQuery string
+
+func (db *DBRepository) InsertHold(ctx context.Context, hold data.Hold) (id int64, err error) {
+	err = db.storage.QueryValue(
+		ctx,
+		insertHoldQuery,
+		[]any{
+			hold.UserID,
+			hold.ProgramID,
+			hold.OrderNumber,
+			hold.Amount,
+			hold.Status,
+			hold.CreatedAt,
+			hold.ExpiresAt,
+		},
+		[]any{&id},
+	)
+	if err != nil {
+		return 0, handleSQLError(err)
+	}
+	return id, nil
+}
+
+//go:embed sql/select_hold_for_update.sql
+var selectHoldForUpdateQuery string
+
+// GetHoldForUpdate returns the hold locked until the end of the transaction.
+func (db *DBRepository) GetHoldForUpdate(ctx context.Context, holdID int64) (data.Hold, error) {
+	var hold data.Hold
+	var closedAt *time.Time
+	err := db.storage.QueryValue(
+		ctx,
+		selectHoldForUpdateQuery,
+		[]any{holdID},
+		[]any{
+			&hold.ID,
+			&hold.UserID,
+			&hold.ProgramID,
+			&hold.OrderNumber,
+			&hold.Amount,
+			&hold.Status,
+			&hold.CreatedAt,
+			&hold.ExpiresAt,
+			&closedAt,
+		},
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return data.Hold{}, data.ErrNotFound
+		default:
+			return data.Hold{}, handleSQLError(err)
+		}
+	}
+	hold.CreatedAt = hold.CreatedAt.UTC()
+	hold.ExpiresAt = hold.ExpiresAt.UTC()
+	if closedAt != nil {
+		hold.ClosedAt = closedAt.UTC()
+	}
+	return hold, nil
+}
+
+//go:embed sql/close_hold.sql
+var closeHoldQuery string
+
+func (db *DBRepository) CloseHold(ctx context.Context, holdID int64, status data.HoldStatus, closedAt time.Time) error {
+	_, err := db.storage.Exec(ctx, closeHoldQuery, holdID, status, closedAt)
+	if err != nil {
+		return handleSQLError(err)
+	}
+	return nil
+}
+
+//go:embed sql/expire_holds.sql
+var expireHoldsQuery string
+
+// ExpireHolds releases up to limit active holds expired at now and returns the number of released holds.
+func (db *DBRepository) ExpireHolds(ctx context.Context, now time.Time, limit int) (count int64, err error) {
+	err = db.storage.QueryValue(
+		ctx,
+		expireHoldsQuery,
+		[]any{now, limit},
+		[]any{&count},
+	)
+	if err != nil {
+		return 0, handleSQLError(err)
+	}
+	return count, nil
+}
+
+//go:embed sql/select_user_transfers_sum.sql
+var selectUserTransfersSumQuery string
+
+// GetUserTransfersSum returns the amount of pending and completed transfers sent by the user since the time.
+func (db *DBRepository) GetUserTransfersSum(
+	ctx context.Context,
+	userID int,
+	programID string,
+	since time.Time,
+) (sum decimal.Decimal, err error) {
+	err = db.storage.QueryValue(
+		ctx,
+		selectUserTransfersSumQuery,
+		[]any{userID, programID, since},
+		[]any{&sum},
+	)
+	if err != nil {
+		return decimal.Zero, handleSQLError(err)
+	}
+	return sum, nil
+}
+
+//go:embed sql/insert_transfer.sql
+var insertTransferQuery string
+
+func (db *DBRepository) InsertTransfer(ctx context.Context, transfer data.Transfer) (id int64, err error) {
+	var closedAt *time.Time
+	if !transfer.ClosedAt.IsZero() {
+		closedAt = &transfer.ClosedAt
+	}
+	err = db.storage.QueryValue(
+		ctx,
+		insertTransferQuery,
+		[]any{
+			transfer.SenderID,
+			transfer.RecipientID,
+			transfer.ProgramID,
+			transfer.Amount,
+			transfer.Status,
+			transfer.CreatedAt,
+			closedAt,
+		},
+		[]any{&id},
+	)
+	if err != nil {
+		return 0, handleSQLError(err)
+	}
+	return id, nil
+}
+
+//go:embed sql/select_transfer_for_update.sql
+var selectTransferForUpdateQuery string
+
+// GetTransferForUpdate returns the transfer locked until the end of the transaction, logins are not filled.
+func (db *DBRepository) GetTransferForUpdate(ctx context.Context, transferID int64) (data.Transfer, error) {
+	var transfer data.Transfer
+	var closedAt *time.Time
+	err := db.storage.QueryValue(
+		ctx,
+		selectTransferForUpdateQuery,
+		[]any{transferID},
+		[]any{
+			&transfer.ID,
+			&transfer.SenderID,
+			&transfer.RecipientID,
+			&transfer.ProgramID,
+			&transfer.Amount,
+			&transfer.Status,
+			&transfer.CreatedAt,
+			&closedAt,
+		},
 	)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return data.Transfer{}, data.ErrNotFound
+		default:
+			return data.Transfer{}, handleSQLError(err)
+		}
+	}
+	transfer.CreatedAt = transfer.CreatedAt.UTC()
+	if closedAt != nil {
+		transfer.ClosedAt = closedAt.UTC()
+	}
+	return transfer, nil
+}
+
+//go:embed sql/close_transfer.sql
+var closeTransferQuery string
+
+func (db *DBRepository) CloseTransfer(
+	ctx context.Context,
+	transferID int64,
+	status data.TransferStatus,
+	closedAt time.Time,
+) error {
+	_, err := db.storage.Exec(ctx, closeTransferQuery, transferID, status, closedAt)
 	if err != nil {
 		return handleSQLError(err)
 	}
 	return nil
 }
 
+//go:embed sql/select_transfers.sql
+var selectTransfersQuery string
+
+// GetAllUserTransfers returns transfers sent and received by the user, the newest first.
+func (db *DBRepository) GetAllUserTransfers(ctx context.Context, userID int) ([]data.Transfer, error) {
+	rows, err := db.storage.Query(pgxstorage.WithReplica(ctx), selectTransfersQuery, userID)
+	if err != nil {
+		return nil, handleSQLError(err)
+	}
+	defer rows.Close()
+
+	result := make([]data.Transfer, 0)
+	for rows.Next() {
+		var transfer data.Transfer
+		var closedAt *time.Time
+		err := rows.Scan(
+			&transfer.ID,
+			&transfer.SenderID,
+			&transfer.SenderLogin,
+			&transfer.RecipientID,
+			&transfer.RecipientLogin,
+			&transfer.ProgramID,
+			&transfer.Amount,
+			&transfer.Status,
+			&transfer.CreatedAt,
+			&closedAt,
+		)
+		if err != nil {
+			return nil, handleSQLError(err)
+		}
+		transfer.CreatedAt = transfer.CreatedAt.UTC()
+		if closedAt != nil {
+			transfer.ClosedAt = closedAt.UTC()
+		}
+		result = append(result, transfer)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, handleSQLError(err)
+	}
+	return result, nil
+}
+
 //go:embed sql/select_withdrawals.sql
 var selectWithdrawalsQuery string
 
 func (db *DBRepository) GetAllUserWithdrawals(ctx context.Context, userID int) ([]data.Withdrawal, error) {
-	rows, err := db.storage.Query(ctx, selectWithdrawalsQuery, userID)
+	rows, err := db.storage.Query(pgxstorage.WithReplica(ctx), selectWithdrawalsQuery, userID)
 	if err != nil {
 		return nil, handleSQLError(err)
 	}
@@ -313,20 +1064,85 @@ func (db *DBRepository) GetAllUserWithdrawals(ctx context.Context, userID int) (
 			&order.OrderNumber,
 			&order.Amount,
 			&order.ProcessTime,
+			&order.ProgramID,
 		)
 		if err != nil {
 			return nil, handleSQLError(err)
 		}
+		order.ProcessTime = order.ProcessTime.UTC()
 		result = append(result, order)
 	}
 	return result, nil
 }
 
+//go:embed sql/select_statement.sql
+var selectStatementQuery string
+
+// StreamUserStatement passes the statement entries to yield one by one, the oldest first,
+// without reading the whole statement at once. Iteration stops at the first error of yield.
+func (db *DBRepository) StreamUserStatement(
+	ctx context.Context,
+	userID int,
+	filter data.StatementFilter,
+	yield func(entry data.StatementEntry) error,
+) error {
+	var from, to *time.Time
+	if !filter.From.IsZero() {
+		from = &filter.From
+	}
+	if !filter.To.IsZero() {
+		to = &filter.To
+	}
+	var limit *int
+	if filter.Limit > 0 {
+		limit = &filter.Limit
+	}
+	rows, err := db.storage.Query(
+		pgxstorage.WithReplica(ctx),
+		selectStatementQuery,
+		userID,
+		filter.ProgramID,
+		from,
+		to,
+		limit,
+		filter.Offset,
+	)
+	if err != nil {
+		return handleSQLError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry data.StatementEntry
+		var reference *string
+		err := rows.Scan(&entry.OccurredAt, &entry.Kind, &reference, &entry.Amount, &entry.Balance)
+		if err != nil {
+			return handleSQLError(err)
+		}
+		entry.OccurredAt = entry.OccurredAt.UTC()
+		if reference != nil {
+			entry.Reference = *reference
+		}
+		if err := yield(entry); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return handleSQLError(err)
+	}
+	return nil
+}
+
 func handleSQLError(err error) error {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
-		if pgErr.Code == "23505" {
+		switch pgErr.Code {
+		case "23505":
 			return data.ErrUniqueConstraintViolation
+		case "23503":
+			return fmt.Errorf("%w: %s", data.ErrForeignKeyViolation, pgErr.ConstraintName)
+		case "23514":
+			return fmt.Errorf("%w: %s", data.ErrCheckConstraintViolation, pgErr.ConstraintName)
 		}
 	}
 	return err
@@ -0,0 +1,4 @@
+UPDATE transfers
+SET status    = $2,
+    closed_at = $3
+WHERE id = $1
\ No newline at end of file
@@ -0,0 +1,21 @@
+WITH locked AS (SELECT id, remaining, credited_at
+                FROM point_lots
+                WHERE user_id = $1
+                  AND program_id = $2
+                  AND remaining > 0
+                  AND (expires_at IS NULL OR expires_at > $4)
+                ORDER BY credited_at, id
+                FOR UPDATE),
+     ordered AS (SELECT id,
+                        remaining,
+                        SUM(remaining) OVER (ORDER BY credited_at, id) - remaining AS taken_before
+                 FROM locked),
+     consumed AS (
+         UPDATE point_lots
+             SET remaining = point_lots.remaining - LEAST(ordered.remaining, $3 - ordered.taken_before)
+             FROM ordered
+             WHERE point_lots.id = ordered.id
+                 AND ordered.taken_before < $3
+             RETURNING LEAST(ordered.remaining, $3 - ordered.taken_before) AS taken)
+SELECT COALESCE(SUM(taken), 0)
+FROM consumed
\ No newline at end of file
@@ -0,0 +1,6 @@
+UPDATE user_balances
+SET balance = balance - $3
+WHERE user_id = $1
+  AND program_id = $2
+  AND balance - on_hold >= $3
+RETURNING balance
\ No newline at end of file
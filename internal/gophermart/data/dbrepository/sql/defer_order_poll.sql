@@ -0,0 +1,6 @@
+UPDATE orders
+SET next_poll_at     = $3,
+    lease_owner      = NULL,
+    lease_expires_at = NULL
+WHERE number = $1
+  AND lease_owner = $2
\ No newline at end of file
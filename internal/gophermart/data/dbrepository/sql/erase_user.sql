@@ -0,0 +1,7 @@
+UPDATE users
+SET login           = $2,
+    password        = NULL,
+    session_version = session_version + 1,
+    deleted_at      = $3
+WHERE id = $1
+  AND deleted_at IS NULL
\ No newline at end of file
@@ -0,0 +1,23 @@
+WITH expired AS (SELECT id, user_id, program_id, amount
+                 FROM holds
+                 WHERE status = 'ACTIVE'
+                   AND expires_at <= $1
+                 ORDER BY expires_at
+                 LIMIT $2 FOR UPDATE SKIP LOCKED),
+     closed AS (
+         UPDATE holds
+             SET status = 'EXPIRED',
+                 closed_at = $1
+             FROM expired
+             WHERE holds.id = expired.id
+             RETURNING expired.user_id, expired.program_id, expired.amount),
+     released AS (
+         UPDATE user_balances
+             SET on_hold = user_balances.on_hold - totals.amount
+             FROM (SELECT user_id, program_id, SUM(amount) AS amount
+                   FROM closed
+                   GROUP BY user_id, program_id) AS totals
+             WHERE user_balances.user_id = totals.user_id
+                 AND user_balances.program_id = totals.program_id)
+SELECT COUNT(*)
+FROM closed
\ No newline at end of file
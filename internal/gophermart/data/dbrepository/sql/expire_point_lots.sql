@@ -0,0 +1,26 @@
+WITH expired AS (SELECT id, user_id, program_id, remaining
+                 FROM point_lots
+                 WHERE remaining > 0
+                   AND expires_at <= $1
+                 ORDER BY expires_at
+                 LIMIT $2 FOR UPDATE SKIP LOCKED),
+     cleared AS (
+         UPDATE point_lots
+             SET remaining = 0
+             FROM expired
+             WHERE point_lots.id = expired.id
+             RETURNING expired.id, expired.user_id, expired.program_id, expired.remaining),
+     logged AS (
+         INSERT INTO point_expirations (lot_id, user_id, program_id, amount, expired_at)
+             SELECT id, user_id, program_id, remaining, $1
+             FROM cleared),
+     debited AS (
+         UPDATE user_balances
+             SET balance = user_balances.balance - totals.amount
+             FROM (SELECT user_id, program_id, SUM(remaining) AS amount
+                   FROM cleared
+                   GROUP BY user_id, program_id) AS totals
+             WHERE user_balances.user_id = totals.user_id
+                 AND user_balances.program_id = totals.program_id)
+SELECT COUNT(*)
+FROM cleared
\ No newline at end of file
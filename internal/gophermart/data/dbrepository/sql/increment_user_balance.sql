@@ -0,0 +1,5 @@
+INSERT INTO user_balances (user_id, program_id, balance)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, program_id) DO UPDATE
+    SET balance = user_balances.balance + EXCLUDED.balance
+RETURNING balance
\ No newline at end of file
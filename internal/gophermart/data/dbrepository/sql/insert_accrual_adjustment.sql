@@ -0,0 +1,3 @@
+INSERT INTO accrual_adjustments (order_number, user_id, program_id, previous_accrual, accrual, reason, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id
\ No newline at end of file
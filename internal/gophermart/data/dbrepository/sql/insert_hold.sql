@@ -0,0 +1,3 @@
+INSERT INTO holds (user_id, program_id, order_number, amount, status, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id
\ No newline at end of file
@@ -1,2 +1,2 @@
-INSERT INTO orders (number, status, user_id, accrual, upload_time)
-VALUES ($1, $2, $3, $4, $5)
\ No newline at end of file
+INSERT INTO orders (number, status, user_id, accrual, upload_time, program_id)
+VALUES ($1, $2, $3, $4, $5, $6)
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- the balance is credited first, the part of the credit covering a negative balance is not kept in the lot
+INSERT INTO point_lots (user_id, program_id, order_number, amount, remaining, credited_at, expires_at)
+SELECT $1, $2, $3, $4, LEAST($4, GREATEST(balance, 0)), $5, $6
+FROM user_balances
+WHERE user_id = $1
+  AND program_id = $2
\ No newline at end of file
@@ -0,0 +1,3 @@
+INSERT INTO transfers (sender_id, recipient_id, program_id, amount, status, created_at, closed_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id
\ No newline at end of file
@@ -1,2 +1,2 @@
-INSERT INTO withdrawals (order_number, user_id, amount, process_time)
-VALUES ($1, $2, $3, $4)
\ No newline at end of file
+INSERT INTO withdrawals (order_number, user_id, amount, process_time, program_id)
+VALUES ($1, $2, $3, $4, $5)
\ No newline at end of file
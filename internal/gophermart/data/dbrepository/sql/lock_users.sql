@@ -0,0 +1,5 @@
+SELECT id
+FROM users
+WHERE id = ANY ($1)
+ORDER BY id
+    FOR NO KEY UPDATE
\ No newline at end of file
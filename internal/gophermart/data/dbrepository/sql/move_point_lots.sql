@@ -0,0 +1,44 @@
+WITH locked AS (SELECT id, remaining, credited_at, expires_at
+                FROM point_lots
+                WHERE user_id = $1
+                  AND program_id = $2
+                  AND remaining > 0
+                  AND (expires_at IS NULL OR expires_at > $4)
+                ORDER BY credited_at, id
+                FOR UPDATE),
+     ordered AS (SELECT id,
+                        remaining,
+                        expires_at,
+                        SUM(remaining) OVER (ORDER BY credited_at, id) - remaining AS taken_before
+                 FROM locked),
+     consumed AS (
+         UPDATE point_lots
+             SET remaining = point_lots.remaining - LEAST(ordered.remaining, $3 - ordered.taken_before)
+             FROM ordered
+             WHERE point_lots.id = ordered.id
+                 AND ordered.taken_before < $3
+             RETURNING LEAST(ordered.remaining, $3 - ordered.taken_before) AS taken, ordered.expires_at),
+     grouped AS (SELECT expires_at,
+                        SUM(taken) AS amount,
+                        SUM(SUM(taken)) OVER (ORDER BY expires_at NULLS LAST) - SUM(taken) AS credited_before
+                 FROM consumed
+                 GROUP BY expires_at),
+     -- the recipient is credited first, a negative balance of the recipient is covered by the soonest expiring points
+     debt AS (SELECT GREATEST($3 - COALESCE((SELECT balance
+                                              FROM user_balances
+                                              WHERE user_id = $5
+                                                AND program_id = $2), $3), 0) AS amount),
+     -- the recipient lots keep the expiration of the taken points
+     credited AS (
+         INSERT INTO point_lots (user_id, program_id, amount, remaining, credited_at, expires_at)
+             SELECT $5,
+                    $2,
+                    grouped.amount,
+                    grouped.amount - LEAST(grouped.amount, GREATEST(debt.amount - grouped.credited_before, 0)),
+                    $4,
+                    grouped.expires_at
+             FROM grouped,
+                  debt
+             RETURNING amount)
+SELECT COALESCE(SUM(amount), 0)
+FROM credited
\ No newline at end of file
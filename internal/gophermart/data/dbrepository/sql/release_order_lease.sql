@@ -0,0 +1,5 @@
+UPDATE orders
+SET lease_owner      = NULL,
+    lease_expires_at = NULL
+WHERE number = $1
+  AND lease_owner = $2
\ No newline at end of file
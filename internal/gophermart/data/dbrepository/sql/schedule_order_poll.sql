@@ -0,0 +1,9 @@
+UPDATE orders
+SET status           = $2,
+    accrual_provider = $3,
+    attempts         = attempts + 1,
+    next_poll_at     = now() + make_interval(secs => $4),
+    last_error       = NULLIF($5, ''),
+    lease_owner      = NULL,
+    lease_expires_at = NULL
+WHERE number = $1
\ No newline at end of file
@@ -0,0 +1,4 @@
+SELECT id, user_id, program_id, order_number, amount, status, created_at, expires_at, closed_at
+FROM holds
+WHERE id = $1
+FOR UPDATE
\ No newline at end of file
@@ -1,4 +1,6 @@
 SELECT user_id, status
 FROM orders
 WHERE number = $1
-
+  AND lease_owner = $2
+  AND lease_expires_at > now()
+FOR UPDATE
\ No newline at end of file
@@ -0,0 +1,4 @@
+SELECT user_id, accrual, adjusted_accrual, status, program_id
+FROM orders
+WHERE number = $1
+FOR UPDATE
\ No newline at end of file
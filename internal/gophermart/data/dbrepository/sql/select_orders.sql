@@ -1,4 +1,4 @@
-SELECT number, accrual, upload_time, status
+SELECT number, accrual, adjusted_accrual, upload_time, status, program_id
 FROM orders
 WHERE user_id = $1
-
+ORDER BY upload_time DESC
\ No newline at end of file
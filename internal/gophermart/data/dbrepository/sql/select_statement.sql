@@ -0,0 +1,59 @@
+-- every movement of the balance, the running balance is computed over the whole history before the range filter
+-- an accrual is dated by its first lot, orders credited before point lots existed have only the upload time
+WITH entries AS (SELECT COALESCE(credits.credited_at, orders.upload_time) AS occurred_at,
+                        'ACCRUAL'                                         AS kind,
+                        orders.number                                     AS reference,
+                        orders.accrual                                    AS amount
+                 FROM orders
+                          LEFT JOIN LATERAL (SELECT MIN(point_lots.credited_at) AS credited_at
+                                             FROM point_lots
+                                             WHERE point_lots.order_number = orders.number) credits ON TRUE
+                 WHERE orders.user_id = $1
+                   AND orders.program_id = $2
+                   AND orders.status = 'PROCESSED'
+                   AND orders.accrual > 0
+                 UNION ALL
+                 SELECT created_at, 'ADJUSTMENT', order_number, accrual - previous_accrual
+                 FROM accrual_adjustments
+                 WHERE user_id = $1
+                   AND program_id = $2
+                   AND accrual <> previous_accrual
+                 UNION ALL
+                 SELECT process_time, 'WITHDRAWAL', order_number, -amount
+                 FROM withdrawals
+                 WHERE user_id = $1
+                   AND program_id = $2
+                 UNION ALL
+                 SELECT point_expirations.expired_at, 'EXPIRATION', point_lots.order_number, -point_expirations.amount
+                 FROM point_expirations
+                          JOIN point_lots ON point_lots.id = point_expirations.lot_id
+                 WHERE point_expirations.user_id = $1
+                   AND point_expirations.program_id = $2
+                 UNION ALL
+                 SELECT transfers.closed_at, 'TRANSFER_OUT', users.login, -transfers.amount
+                 FROM transfers
+                          JOIN users ON users.id = transfers.recipient_id
+                 WHERE transfers.sender_id = $1
+                   AND transfers.program_id = $2
+                   AND transfers.status = 'COMPLETED'
+                 UNION ALL
+                 SELECT transfers.closed_at, 'TRANSFER_IN', users.login, transfers.amount
+                 FROM transfers
+                          JOIN users ON users.id = transfers.sender_id
+                 WHERE transfers.recipient_id = $1
+                   AND transfers.program_id = $2
+                   AND transfers.status = 'COMPLETED'),
+     running AS (SELECT occurred_at,
+                        kind,
+                        reference,
+                        amount,
+                        SUM(amount) OVER history AS balance,
+                        ROW_NUMBER() OVER history AS entry_number
+                 FROM entries
+                 WINDOW history AS (ORDER BY occurred_at, kind, reference, amount ROWS UNBOUNDED PRECEDING))
+SELECT occurred_at, kind, reference, amount, balance
+FROM running
+WHERE ($3::TIMESTAMPTZ IS NULL OR occurred_at >= $3)
+  AND ($4::TIMESTAMPTZ IS NULL OR occurred_at < $4)
+ORDER BY entry_number
+LIMIT $5 OFFSET $6
\ No newline at end of file
@@ -0,0 +1,4 @@
+SELECT id, sender_id, recipient_id, program_id, amount, status, created_at, closed_at
+FROM transfers
+WHERE id = $1
+FOR UPDATE
\ No newline at end of file
@@ -0,0 +1,16 @@
+SELECT transfers.id,
+       transfers.sender_id,
+       senders.login,
+       transfers.recipient_id,
+       recipients.login,
+       transfers.program_id,
+       transfers.amount,
+       transfers.status,
+       transfers.created_at,
+       transfers.closed_at
+FROM transfers
+         JOIN users AS senders ON senders.id = transfers.sender_id
+         JOIN users AS recipients ON recipients.id = transfers.recipient_id
+WHERE transfers.sender_id = $1
+   OR transfers.recipient_id = $1
+ORDER BY transfers.created_at DESC, transfers.id DESC
\ No newline at end of file
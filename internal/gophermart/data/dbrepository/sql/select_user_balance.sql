@@ -1,3 +1,4 @@
 SELECT balance
-FROM users
-WHERE id = $1
\ No newline at end of file
+FROM user_balances
+WHERE user_id = $1
+  AND program_id = $2
\ No newline at end of file
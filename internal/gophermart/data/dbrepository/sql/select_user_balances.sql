@@ -0,0 +1,27 @@
+SELECT programs.id,
+       programs.name,
+       COALESCE(user_balances.balance, 0),
+       COALESCE(user_balances.on_hold, 0),
+       COALESCE(withdrawn.amount, 0),
+       COALESCE(expiring.amount, 0),
+       expiring.expires_at
+FROM programs
+         LEFT JOIN user_balances
+                   ON user_balances.program_id = programs.id
+                       AND user_balances.user_id = $1
+         LEFT JOIN (SELECT program_id, SUM(amount) AS amount
+                    FROM withdrawals
+                    WHERE user_id = $1
+                    GROUP BY program_id) AS withdrawn
+                   ON withdrawn.program_id = programs.id
+         LEFT JOIN (SELECT program_id, SUM(remaining) AS amount, MIN(expires_at) AS expires_at
+                    FROM point_lots
+                    WHERE user_id = $1
+                      AND remaining > 0
+                      AND expires_at <= $3
+                    GROUP BY program_id) AS expiring
+                   ON expiring.program_id = programs.id
+WHERE programs.id = $2
+   OR user_balances.user_id IS NOT NULL
+   OR withdrawn.program_id IS NOT NULL
+ORDER BY programs.id
\ No newline at end of file
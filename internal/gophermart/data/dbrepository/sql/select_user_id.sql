@@ -0,0 +1,4 @@
+SELECT id
+FROM users
+WHERE login = $1
+  AND deleted_at IS NULL
\ No newline at end of file
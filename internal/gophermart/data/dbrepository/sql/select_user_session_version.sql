@@ -0,0 +1,4 @@
+SELECT session_version
+FROM users
+WHERE id = $1
+  AND deleted_at IS NULL
\ No newline at end of file
@@ -0,0 +1,6 @@
+SELECT COALESCE(SUM(amount), 0)
+FROM transfers
+WHERE sender_id = $1
+  AND program_id = $2
+  AND created_at >= $3
+  AND status IN ('PENDING', 'COMPLETED')
\ No newline at end of file
@@ -1,3 +0,0 @@
-SELECT SUM(amount)
-FROM withdrawals
-WHERE user_id = $1
\ No newline at end of file
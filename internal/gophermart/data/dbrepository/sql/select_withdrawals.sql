@@ -1,4 +1,4 @@
-SELECT order_number, amount, process_time
+SELECT order_number, amount, process_time, program_id
 FROM withdrawals
 WHERE user_id = $1
-
+ORDER BY process_time DESC
\ No newline at end of file
@@ -0,0 +1,4 @@
+UPDATE user_balances
+SET on_hold = on_hold - $3
+WHERE user_id = $1
+  AND program_id = $2
\ No newline at end of file
@@ -0,0 +1,3 @@
+UPDATE orders
+SET adjusted_accrual = $2
+WHERE number = $1
\ No newline at end of file
@@ -1,3 +1,8 @@
 UPDATE orders
-SET status = $2, accrual = $3
+SET status           = $2,
+    accrual          = $3,
+    accrual_provider = $4,
+    program_id       = $5,
+    lease_owner      = NULL,
+    lease_expires_at = NULL
 WHERE number = $1
\ No newline at end of file
@@ -0,0 +1,5 @@
+UPDATE orders
+SET status = 'STUCK'
+WHERE status IN ('NEW', 'PROCESSING')
+  AND upload_time < now() - make_interval(secs => $1)
+  AND (lease_expires_at IS NULL OR lease_expires_at <= now())
\ No newline at end of file
@@ -1,3 +0,0 @@
-UPDATE users
-SET balance = $2
-WHERE id = $1
\ No newline at end of file
@@ -0,0 +1,4 @@
+UPDATE users
+SET login = $2
+WHERE id = $1
+  AND deleted_at IS NULL
\ No newline at end of file
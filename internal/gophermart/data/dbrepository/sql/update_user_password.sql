@@ -0,0 +1,7 @@
+UPDATE users
+SET password        = crypt($3, gen_salt('md5')),
+    session_version = session_version + 1
+WHERE id = $1
+  AND deleted_at IS NULL
+  AND password = crypt($2, password)
+RETURNING session_version
\ No newline at end of file
@@ -1,5 +1,7 @@
 SELECT id,
+       session_version,
        (password = crypt($2, password))
            AS password_match
 FROM users
-WHERE login = $1
\ No newline at end of file
+WHERE login = $1
+  AND deleted_at IS NULL
\ No newline at end of file
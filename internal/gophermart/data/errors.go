@@ -4,6 +4,11 @@ import "errors"
 
 var (
 	ErrUniqueConstraintViolation = errors.New("unique constraint violation")
+	ErrForeignKeyViolation       = errors.New("foreign key violation")
+	ErrCheckConstraintViolation  = errors.New("check constraint violation")
 	ErrInvalidPassword           = errors.New("invalid password")
 	ErrInvalidLogin              = errors.New("invalid login")
+	ErrLeaseLost                 = errors.New("lease lost")
+	ErrInsufficientBalance       = errors.New("insufficient balance")
+	ErrNotFound                  = errors.New("not found")
 )
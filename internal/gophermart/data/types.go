@@ -14,19 +14,146 @@ const (
 	ProcessingStatus = Status("PROCESSING")
 	ProcessedStatus  = Status("PROCESSED")
 	InvalidStatus    = Status("INVALID")
+	// StuckStatus is a terminal status of orders not finished by the accrual system in time.
+	StuckStatus = Status("STUCK")
 )
 
+// DefaultProgramID is the loyalty program of orders and withdrawals not naming one.
+const DefaultProgramID = "default"
+
 type Order struct {
 	UploadTime  time.Time
 	OrderNumber string
+	ProgramID   string
 	Accrual     decimal.Decimal
-	Status      Status
-	UserID      int
+	// AdjustedAccrual is the accrual corrected after the order was processed, invalid if never corrected.
+	AdjustedAccrual decimal.NullDecimal
+	// LeaseExpiresAt is the end of the lease taken on the order by the orders monitor, zero if not leased.
+	LeaseExpiresAt time.Time
+	Status         Status
+	UserID         int
+	Attempts       int
 }
 
 type Withdrawal struct {
 	ProcessTime time.Time
 	OrderNumber string
+	ProgramID   string
+	Amount      decimal.Decimal
+	UserID      int
+}
+
+type ProgramBalance struct {
+	ProgramID   string
+	ProgramName string
+	Balance     decimal.Decimal
+	OnHold      decimal.Decimal
+	Withdrawals decimal.Decimal
+	// Expiring is the part of Balance expiring soon, ExpiringAt is the nearest expiration or zero if none.
+	Expiring   decimal.Decimal
+	ExpiringAt time.Time
+}
+
+// PointLot is a credit of points, withdrawals consume lots oldest first. Zero ExpiresAt means the lot never expires.
+type PointLot struct {
+	CreditedAt  time.Time
+	ExpiresAt   time.Time
+	OrderNumber string
+	ProgramID   string
+	Amount      decimal.Decimal
+	UserID      int
+}
+
+type HoldStatus string
+
+const (
+	ActiveHoldStatus   = HoldStatus("ACTIVE")
+	CapturedHoldStatus = HoldStatus("CAPTURED")
+	ReleasedHoldStatus = HoldStatus("RELEASED")
+	ExpiredHoldStatus  = HoldStatus("EXPIRED")
+)
+
+// Hold reserves Amount of the user balance until it is captured into a withdrawal, released or expires.
+type Hold struct {
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	ClosedAt    time.Time
+	OrderNumber string
+	ProgramID   string
+	Status      HoldStatus
 	Amount      decimal.Decimal
+	ID          int64
 	UserID      int
 }
+
+type TransferStatus string
+
+const (
+	PendingTransferStatus   = TransferStatus("PENDING")
+	CompletedTransferStatus = TransferStatus("COMPLETED")
+	DeclinedTransferStatus  = TransferStatus("DECLINED")
+	CancelledTransferStatus = TransferStatus("CANCELLED")
+)
+
+// Transfer moves Amount from the sender to the recipient. Pending transfers keep Amount on hold of the sender
+// until the recipient accepts or declines it.
+type Transfer struct {
+	CreatedAt      time.Time
+	ClosedAt       time.Time
+	SenderLogin    string
+	RecipientLogin string
+	ProgramID      string
+	Status         TransferStatus
+	Amount         decimal.Decimal
+	ID             int64
+	SenderID       int
+	RecipientID    int
+}
+
+// AccrualAdjustment records a correction of the accrual of a processed order.
+type AccrualAdjustment struct {
+	CreatedAt       time.Time
+	OrderNumber     string
+	ProgramID       string
+	Reason          string
+	PreviousAccrual decimal.Decimal
+	Accrual         decimal.Decimal
+	ID              int64
+	UserID          int
+}
+
+type StatementEntryKind string
+
+const (
+	AccrualStatementEntry     = StatementEntryKind("ACCRUAL")
+	AdjustmentStatementEntry  = StatementEntryKind("ADJUSTMENT")
+	WithdrawalStatementEntry  = StatementEntryKind("WITHDRAWAL")
+	ExpirationStatementEntry  = StatementEntryKind("EXPIRATION")
+	TransferOutStatementEntry = StatementEntryKind("TRANSFER_OUT")
+	TransferInStatementEntry  = StatementEntryKind("TRANSFER_IN")
+)
+
+// StatementEntry is a movement of a program balance, Amount is negative for debits and Balance is the balance
+// after the movement. Reference is the order number or the transfer counterparty login, empty if unknown.
+type StatementEntry struct {
+	OccurredAt time.Time
+	Kind       StatementEntryKind
+	Reference  string
+	Amount     decimal.Decimal
+	Balance    decimal.Decimal
+}
+
+// StatementFilter selects statement entries of one program from From inclusive to To exclusive,
+// zero From or To leave the range open and zero Limit means no limit.
+type StatementFilter struct {
+	From      time.Time
+	To        time.Time
+	ProgramID string
+	Limit     int
+	Offset    int
+}
+
+type Lease struct {
+	Owner    string
+	Duration time.Duration
+}
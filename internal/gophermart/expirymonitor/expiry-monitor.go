@@ -0,0 +1,100 @@
+package expirymonitor
+
+import (
+	"context"
+	"fmt"
+	"go-market/pkg/logging"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type Repository interface {
+	ExpirePointLots(ctx context.Context, now time.Time, limit int) (count int64, err error)
+	ExpireHolds(ctx context.Context, now time.Time, limit int) (count int64, err error)
+}
+
+type Config struct {
+	TickPeriod time.Duration
+	// BatchSize is the maximum number of lots or holds expired by one statement.
+	BatchSize int
+}
+
+// ExpiryMonitor periodically releases expired holds and writes off points of expired lots.
+type ExpiryMonitor struct {
+	repository Repository
+	logger     *logging.ZapLogger
+	done       chan struct{}
+	config     Config
+}
+
+func NewExpiryMonitor(config Config, repository Repository, logger *logging.ZapLogger) *ExpiryMonitor {
+	return &ExpiryMonitor{
+		repository: repository,
+		logger:     logger,
+		done:       make(chan struct{}),
+		config:     config,
+	}
+}
+
+func (em *ExpiryMonitor) Run() {
+	ticker := time.NewTicker(em.config.TickPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-em.done:
+			return
+		case <-ticker.C:
+			if err := em.tick(); err != nil {
+				em.logger.ErrorCtx(context.Background(), "error while expiring points", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (em *ExpiryMonitor) Stop() {
+	close(em.done)
+}
+
+// tick releases holds before writing off lots, so that released points expire in the same tick.
+func (em *ExpiryMonitor) tick() error {
+	now := time.Now().UTC()
+	holdsCount, err := em.expireInBatches(now, em.repository.ExpireHolds)
+	if err != nil {
+		return fmt.Errorf("expiring holds failed: %w", err)
+	}
+	if holdsCount > 0 {
+		em.logger.InfoCtx(context.Background(), "holds expired", zap.Int64("count", holdsCount))
+	}
+	lotsCount, err := em.expireInBatches(now, em.repository.ExpirePointLots)
+	if err != nil {
+		return fmt.Errorf("expiring points lots failed: %w", err)
+	}
+	if lotsCount > 0 {
+		em.logger.InfoCtx(context.Background(), "points lots expired", zap.Int64("count", lotsCount))
+	}
+	return nil
+}
+
+func (em *ExpiryMonitor) expireInBatches(
+	now time.Time,
+	expire func(ctx context.Context, now time.Time, limit int) (int64, error),
+) (int64, error) {
+	var total int64
+	for {
+		select {
+		case <-em.done:
+			return total, nil
+		default:
+		}
+		count, err := expire(context.Background(), now, em.config.BatchSize)
+		if err != nil {
+			return total, err
+		}
+		total += count
+		if count < int64(em.config.BatchSize) {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package expirymonitor
+
+import (
+	"context"
+	"go-market/pkg/logging"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+type fakeBatches struct {
+	expired []int64
+	pending int64
+}
+
+func (b *fakeBatches) expire(limit int) int64 {
+	count := min(b.pending, int64(limit))
+	b.pending -= count
+	b.expired = append(b.expired, count)
+	return count
+}
+
+type fakeRepository struct {
+	lots  fakeBatches
+	holds fakeBatches
+}
+
+func (r *fakeRepository) ExpirePointLots(_ context.Context, _ time.Time, limit int) (int64, error) {
+	return r.lots.expire(limit), nil
+}
+
+func (r *fakeRepository) ExpireHolds(_ context.Context, _ time.Time, limit int) (int64, error) {
+	return r.holds.expire(limit), nil
+}
+
+// TestExpiryMonitorTick checks a tick expires holds and lots in batches until a batch is not full.
+func TestExpiryMonitorTick(t *testing.T) {
+	logger, err := logging.NewZapLogger(zapcore.ErrorLevel)
+	require.NoError(t, err)
+	repository := &fakeRepository{
+		lots:  fakeBatches{pending: 25},
+		holds: fakeBatches{pending: 10},
+	}
+	monitor := NewExpiryMonitor(Config{TickPeriod: time.Minute, BatchSize: 10}, repository, logger)
+
+	require.NoError(t, monitor.tick())
+	assert.Equal(t, []int64{10, 10, 5}, repository.lots.expired)
+	assert.Equal(t, []int64{10, 0}, repository.holds.expired)
+	assert.Zero(t, repository.lots.pending)
+	assert.Zero(t, repository.holds.pending)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"go-market/internal/common/clientprotocol"
+	servicePackage "go-market/internal/gophermart/service"
+	"go-market/pkg/logging"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// OrderNumberParam is the route parameter holding the number of an order.
+const OrderNumberParam = "orderNumber"
+
+type AccrualAdjustingHandler struct {
+	service AccrualAdjustingService
+	logger  *logging.ZapLogger
+}
+
+type AccrualAdjustingService interface {
+	AdjustAccrual(
+		ctx context.Context,
+		orderNumber string,
+		accrual decimal.Decimal,
+		reason string,
+	) (servicePackage.Adjustment, error)
+}
+
+// AdjustmentRequest holds the corrected accrual of the whole order, not the difference.
+type AdjustmentRequest struct {
+	Accrual clientprotocol.Money `json:"accrual"`
+	Reason  string               `json:"reason"`
+}
+
+type Adjustment struct {
+	CreatedAt       time.Time            `json:"created_at"`
+	OrderNumber     string               `json:"order"`
+	Program         string               `json:"program"`
+	Reason          string               `json:"reason"`
+	PreviousAccrual clientprotocol.Money `json:"previous_accrual"`
+	Accrual         clientprotocol.Money `json:"accrual"`
+	ID              int64                `json:"id"`
+}
+
+func NewAccrualAdjustingHandler(service AccrualAdjustingService, logger *logging.ZapLogger) *AccrualAdjustingHandler {
+	return &AccrualAdjustingHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *AccrualAdjustingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer closeBody(r.Context(), r.Body, h.logger)
+	w.Header().Add("Vary", "Accept")
+	moneyFormat, err := moneyFormatFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Unsupported money format", zap.Error(err))
+		w.WriteHeader(http.StatusNotAcceptable)
+		return
+	}
+
+	request, err := decodeJSON[AdjustmentRequest](r.Body)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "input decoding error", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" || request.Accrual.Decimal().IsNegative() {
+		h.logger.DebugCtx(r.Context(), "Empty adjustment reason or negative accrual")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	orderNumber := chi.URLParam(r, OrderNumberParam)
+	adjustment, err := h.service.AdjustAccrual(r.Context(), orderNumber, request.Accrual.Decimal(), reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, servicePackage.ErrOrderNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, servicePackage.ErrOrderNotProcessed):
+			h.logger.DebugCtx(r.Context(), "Failed to adjust accrual", zap.Error(err))
+			w.WriteHeader(http.StatusConflict)
+		case errors.Is(err, servicePackage.ErrNotEnoughBalance):
+			h.logger.DebugCtx(r.Context(), "Failed to adjust accrual", zap.Error(err))
+			w.WriteHeader(http.StatusPaymentRequired)
+		default:
+			h.logger.ErrorCtx(r.Context(), "Failed to adjust accrual", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	res := Adjustment{
+		CreatedAt:       adjustment.CreatedAt,
+		OrderNumber:     adjustment.OrderNumber,
+		Program:         adjustment.Program,
+		Reason:          adjustment.Reason,
+		PreviousAccrual: moneyFormat.Money(adjustment.PreviousAccrual),
+		Accrual:         moneyFormat.Money(adjustment.Accrual),
+		ID:              adjustment.ID,
+	}
+	if err := tryWriteResponseJSON(w, res); err != nil {
+		h.logger.ErrorCtx(r.Context(), "Error writing response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
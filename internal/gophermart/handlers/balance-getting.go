@@ -3,16 +3,32 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"go-market/internal/common/clientprotocol"
 	servicePackage "go-market/internal/gophermart/service"
 	"go-market/pkg/logging"
 	"net/http"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type BalanceInfo struct {
-	Balance     float64 `json:"current"`
-	Withdrawals float64 `json:"withdrawn"`
+	ExpiringAt  *time.Time           `json:"expiring_at,omitempty"`
+	Balance     clientprotocol.Money `json:"current"`
+	OnHold      clientprotocol.Money `json:"on_hold"`
+	Withdrawals clientprotocol.Money `json:"withdrawn"`
+	Expiring    clientprotocol.Money `json:"expiring"`
+	Programs    []ProgramBalance     `json:"programs"`
+}
+
+type ProgramBalance struct {
+	ExpiringAt  *time.Time           `json:"expiring_at,omitempty"`
+	Program     string               `json:"program"`
+	Name        string               `json:"name"`
+	Balance     clientprotocol.Money `json:"current"`
+	OnHold      clientprotocol.Money `json:"on_hold"`
+	Withdrawals clientprotocol.Money `json:"withdrawn"`
+	Expiring    clientprotocol.Money `json:"expiring"`
 }
 
 type BalanceGettingHandler struct {
@@ -32,6 +48,20 @@ func NewBalanceGettingHandler(service BalanceGettingService, logger *logging.Zap
 }
 
 func (h *BalanceGettingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+	moneyFormat, err := moneyFormatFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Unsupported money format", zap.Error(err))
+		w.WriteHeader(http.StatusNotAcceptable)
+		return
+	}
+	w.Header().Add("Vary", acceptTimezoneHeader)
+	location, err := locationFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Invalid time zone", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
 	userID, err := userIDFromCtx(r.Context())
 	if err != nil {
 		h.logger.ErrorCtx(r.Context(), failedToRecoverUserIDErrorMessage, zap.Error(err))
@@ -44,11 +74,24 @@ func (h *BalanceGettingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	balance, _ := balanceInfo.Balance.Float64()
-	withdrawals, _ := balanceInfo.Withdrawals.Float64()
 	convertedBalanceInfo := BalanceInfo{
-		Balance:     balance,
-		Withdrawals: withdrawals,
+		Balance:     moneyFormat.Money(balanceInfo.Balance),
+		OnHold:      moneyFormat.Money(balanceInfo.OnHold),
+		Withdrawals: moneyFormat.Money(balanceInfo.Withdrawals),
+		Expiring:    moneyFormat.Money(balanceInfo.Expiring),
+		ExpiringAt:  timeIn(balanceInfo.ExpiringAt, location),
+		Programs:    make([]ProgramBalance, len(balanceInfo.Programs)),
+	}
+	for i, program := range balanceInfo.Programs {
+		convertedBalanceInfo.Programs[i] = ProgramBalance{
+			Program:     program.Program,
+			Name:        program.Name,
+			Balance:     moneyFormat.Money(program.Balance),
+			OnHold:      moneyFormat.Money(program.OnHold),
+			Withdrawals: moneyFormat.Money(program.Withdrawals),
+			Expiring:    moneyFormat.Money(program.Expiring),
+			ExpiringAt:  timeIn(program.ExpiringAt, location),
+		}
 	}
 	res, err := json.Marshal(convertedBalanceInfo)
 	if err != nil {
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	servicePackage "go-market/internal/gophermart/service"
+	"go-market/pkg/logging"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// HoldIDParam is the route parameter holding the id of a hold.
+const HoldIDParam = "holdID"
+
+// HoldClosingHandler captures or releases the hold named by the HoldIDParam route parameter.
+type HoldClosingHandler struct {
+	closeHold func(ctx context.Context, userID int, holdID int64) (servicePackage.Hold, error)
+	logger    *logging.ZapLogger
+}
+
+type HoldCapturingService interface {
+	CaptureHold(ctx context.Context, userID int, holdID int64) (servicePackage.Hold, error)
+}
+
+type HoldReleasingService interface {
+	ReleaseHold(ctx context.Context, userID int, holdID int64) (servicePackage.Hold, error)
+}
+
+func NewHoldCapturingHandler(service HoldCapturingService, logger *logging.ZapLogger) *HoldClosingHandler {
+	return &HoldClosingHandler{
+		closeHold: service.CaptureHold,
+		logger:    logger,
+	}
+}
+
+func NewHoldReleasingHandler(service HoldReleasingService, logger *logging.ZapLogger) *HoldClosingHandler {
+	return &HoldClosingHandler{
+		closeHold: service.ReleaseHold,
+		logger:    logger,
+	}
+}
+
+func (h *HoldClosingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+	moneyFormat, err := moneyFormatFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Unsupported money format", zap.Error(err))
+		w.WriteHeader(http.StatusNotAcceptable)
+		return
+	}
+	w.Header().Add("Vary", acceptTimezoneHeader)
+	location, err := locationFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Invalid time zone", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	userID, err := userIDFromCtx(r.Context())
+	if err != nil {
+		h.logger.ErrorCtx(r.Context(), failedToRecoverUserIDErrorMessage, zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	holdID, err := strconv.ParseInt(chi.URLParam(r, HoldIDParam), 10, 64)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Invalid hold id", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	hold, err := h.closeHold(r.Context(), userID, holdID)
+	if err != nil {
+		switch {
+		case errors.Is(err, servicePackage.ErrHoldNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, servicePackage.ErrHoldNotActive), errors.Is(err, servicePackage.ErrOrderAlreadyHeld):
+			h.logger.DebugCtx(r.Context(), "Failed to close hold", zap.Error(err))
+			w.WriteHeader(http.StatusConflict)
+		case errors.Is(err, servicePackage.ErrNotEnoughBalance):
+			h.logger.DebugCtx(r.Context(), "Failed to close hold", zap.Error(err))
+			w.WriteHeader(http.StatusPaymentRequired)
+		default:
+			h.logger.ErrorCtx(r.Context(), "Failed to close hold", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	if err := tryWriteResponseJSON(w, newHold(hold, moneyFormat, location)); err != nil {
+		h.logger.ErrorCtx(r.Context(), "Error writing response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"go-market/internal/common/clientprotocol"
+	servicePackage "go-market/internal/gophermart/service"
+	"go-market/pkg/logging"
+	"go-market/pkg/lunh"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+type HoldCreatingHandler struct {
+	service HoldCreatingService
+	logger  *logging.ZapLogger
+}
+
+type HoldCreatingService interface {
+	CreateHold(
+		ctx context.Context,
+		userID int,
+		orderNumber string,
+		programID string,
+		amount decimal.Decimal,
+		ttl time.Duration,
+	) (servicePackage.Hold, error)
+}
+
+type HoldRequest struct {
+	OrderNumber string               `json:"order"`
+	Program     string               `json:"program,omitempty"`
+	Amount      clientprotocol.Money `json:"sum"`
+	// TTLSeconds is the hold lifetime, the default one when omitted.
+	TTLSeconds int64 `json:"ttl,omitempty"`
+}
+
+type Hold struct {
+	CreatedAt   time.Time            `json:"created_at"`
+	ExpiresAt   time.Time            `json:"expires_at"`
+	ClosedAt    *time.Time           `json:"closed_at,omitempty"`
+	OrderNumber string               `json:"order"`
+	Program     string               `json:"program"`
+	Status      string               `json:"status"`
+	Amount      clientprotocol.Money `json:"sum"`
+	ID          int64                `json:"id"`
+}
+
+func NewHoldCreatingHandler(service HoldCreatingService, logger *logging.ZapLogger) *HoldCreatingHandler {
+	return &HoldCreatingHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *HoldCreatingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer closeBody(r.Context(), r.Body, h.logger)
+	w.Header().Add("Vary", "Accept")
+	moneyFormat, err := moneyFormatFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Unsupported money format", zap.Error(err))
+		w.WriteHeader(http.StatusNotAcceptable)
+		return
+	}
+	w.Header().Add("Vary", acceptTimezoneHeader)
+	location, err := locationFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Invalid time zone", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	userID, err := userIDFromCtx(r.Context())
+	if err != nil {
+		h.logger.ErrorCtx(r.Context(), failedToRecoverUserIDErrorMessage, zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	request, err := decodeJSON[HoldRequest](r.Body)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "input decoding error", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if request.Amount.Decimal().IsZero() {
+		h.logger.DebugCtx(r.Context(), "Zero hold sum")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !lunh.Validate(request.OrderNumber) {
+		h.logger.DebugCtx(r.Context(), "Invalid order number", zap.String("body", request.OrderNumber))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	hold, err := h.service.CreateHold(
+		r.Context(),
+		userID,
+		request.OrderNumber,
+		request.Program,
+		request.Amount.Decimal(),
+		time.Duration(request.TTLSeconds)*time.Second,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, servicePackage.ErrNotEnoughBalance):
+			h.logger.DebugCtx(r.Context(), "Failed to create hold", zap.Error(err))
+			w.WriteHeader(http.StatusPaymentRequired)
+		case errors.Is(err, servicePackage.ErrOrderAlreadyHeld):
+			h.logger.DebugCtx(r.Context(), "Failed to create hold", zap.Error(err))
+			w.WriteHeader(http.StatusConflict)
+		case errors.Is(err, servicePackage.ErrUnknownProgram), errors.Is(err, servicePackage.ErrInvalidHoldTTL):
+			h.logger.DebugCtx(r.Context(), "Failed to create hold", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			h.logger.ErrorCtx(r.Context(), "Failed to create hold", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := writeJSON(w, newHold(hold, moneyFormat, location)); err != nil {
+		h.logger.ErrorCtx(r.Context(), "Error writing response", zap.Error(err))
+	}
+}
+
+func newHold(hold servicePackage.Hold, moneyFormat clientprotocol.MoneyFormat, location *time.Location) Hold {
+	return Hold{
+		CreatedAt:   hold.CreatedAt.In(location),
+		ExpiresAt:   hold.ExpiresAt.In(location),
+		ClosedAt:    timeIn(hold.ClosedAt, location),
+		OrderNumber: hold.OrderNumber,
+		Program:     hold.Program,
+		Status:      string(hold.Status),
+		Amount:      moneyFormat.Money(hold.Amount),
+		ID:          hold.ID,
+	}
+}
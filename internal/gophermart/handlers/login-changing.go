@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	servicePackage "go-market/internal/gophermart/service"
+	"go-market/pkg/logging"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type LoginChangingHandler struct {
+	service LoginChangingService
+	logger  *logging.ZapLogger
+}
+
+type LoginChangingInput struct {
+	Login string `json:"login"`
+}
+
+type LoginChangingService interface {
+	ChangeLogin(ctx context.Context, userID int, login string) error
+}
+
+func NewLoginChangingHandler(service LoginChangingService, logger *logging.ZapLogger) *LoginChangingHandler {
+	return &LoginChangingHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *LoginChangingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer closeBody(r.Context(), r.Body, h.logger)
+
+	userID, err := userIDFromCtx(r.Context())
+	if err != nil {
+		h.logger.ErrorCtx(r.Context(), failedToRecoverUserIDErrorMessage, zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	input, err := decodeJSON[LoginChangingInput](r.Body)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "input decoding error", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.ChangeLogin(r.Context(), userID, input.Login)
+	if err != nil {
+		var validationErr *servicePackage.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			h.logger.DebugCtx(r.Context(), err.Error(), zap.String("login", input.Login))
+			if err := writeValidationError(w, validationErr, nil); err != nil {
+				h.logger.ErrorCtx(r.Context(), "Error writing response", zap.Error(err))
+			}
+			return
+		case errors.Is(err, servicePackage.ErrLoginTaken):
+			h.logger.DebugCtx(r.Context(), err.Error(), zap.String("login", input.Login))
+			w.WriteHeader(http.StatusConflict)
+			return
+		case errors.Is(err, servicePackage.ErrSessionRevoked):
+			h.logger.DebugCtx(r.Context(), err.Error(), zap.Int("userID", userID))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		default:
+			h.logger.ErrorCtx(r.Context(), "login changing service error", zap.Error(err), zap.Any("input", input))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+}
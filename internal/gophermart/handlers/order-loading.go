@@ -18,9 +18,12 @@ type OrderLoadingHandler struct {
 }
 
 type OrderLoadingService interface {
-	RegisterOrder(ctx context.Context, userID int, orderNumber string) error
+	RegisterOrder(ctx context.Context, userID int, orderNumber string, programID string) error
 }
 
+// programQueryParam names the loyalty program to accrue points of an uploaded order into.
+const programQueryParam = "program"
+
 func NewOrderLoadingHandler(service OrderLoadingService, logger *logging.ZapLogger) *OrderLoadingHandler {
 	return &OrderLoadingHandler{
 		service: service,
@@ -57,7 +60,7 @@ func (h *OrderLoadingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	err = h.service.RegisterOrder(r.Context(), userID, orderNumber)
+	err = h.service.RegisterOrder(r.Context(), userID, orderNumber, r.URL.Query().Get(programQueryParam))
 	if err != nil {
 		switch {
 		case errors.Is(err, servicePackage.ErrOrderRegistered):
@@ -67,6 +70,10 @@ func (h *OrderLoadingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 			h.logger.DebugCtx(r.Context(), "Failed to register order", zap.Error(err))
 			w.WriteHeader(http.StatusConflict)
 			return
+		case errors.Is(err, servicePackage.ErrUnknownProgram):
+			h.logger.DebugCtx(r.Context(), "Failed to register order", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			return
 		default:
 			h.logger.ErrorCtx(r.Context(), "Failed to register order", zap.Error(err))
 			w.WriteHeader(http.StatusInternalServerError)
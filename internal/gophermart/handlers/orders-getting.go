@@ -19,8 +19,11 @@ type OrderGettingHandler struct {
 type Order struct {
 	UploadedAt time.Time                  `json:"uploaded_at"`
 	Number     string                     `json:"number"`
+	Program    string                     `json:"program"`
 	Status     clientprotocol.OrderStatus `json:"status"`
-	Accrual    float64                    `json:"accrual"`
+	Accrual    clientprotocol.Money       `json:"accrual"`
+	// AdjustedAccrual is set when the accrual was corrected after processing, Accrual stays the original one.
+	AdjustedAccrual *clientprotocol.Money `json:"adjusted_accrual,omitempty"`
 }
 
 type OrderGettingService interface {
@@ -35,6 +38,20 @@ func NewOrderGettingHandler(service OrderGettingService, logger *logging.ZapLogg
 }
 
 func (h *OrderGettingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+	moneyFormat, err := moneyFormatFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Unsupported money format", zap.Error(err))
+		w.WriteHeader(http.StatusNotAcceptable)
+		return
+	}
+	w.Header().Add("Vary", acceptTimezoneHeader)
+	location, err := locationFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Invalid time zone", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
 	userID, err := userIDFromCtx(r.Context())
 	if err != nil {
 		h.logger.ErrorCtx(r.Context(), failedToRecoverUserIDErrorMessage, zap.Error(err))
@@ -53,12 +70,16 @@ func (h *OrderGettingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	}
 	res := make([]Order, len(orders))
 	for i, order := range orders {
-		accrual, _ := order.Accrual.Float64()
 		res[i] = Order{
 			Number:     order.Number,
+			Program:    order.Program,
 			Status:     order.Status,
-			Accrual:    accrual,
-			UploadedAt: order.UploadedAt,
+			Accrual:    moneyFormat.Money(order.Accrual),
+			UploadedAt: order.UploadedAt.In(location),
+		}
+		if order.AdjustedAccrual.Valid {
+			adjustedAccrual := moneyFormat.Money(order.AdjustedAccrual.Decimal)
+			res[i].AdjustedAccrual = &adjustedAccrual
 		}
 	}
 	if err := tryWriteResponseJSON(w, res); err != nil {
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	servicePackage "go-market/internal/gophermart/service"
+	"go-market/pkg/logging"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type PasswordChangingHandler struct {
+	service PasswordChangingService
+	logger  *logging.ZapLogger
+}
+
+type PasswordChangingInput struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+type PasswordChangingService interface {
+	ChangePassword(ctx context.Context, userID int, oldPassword string, newPassword string) (string, error)
+}
+
+func NewPasswordChangingHandler(service PasswordChangingService, logger *logging.ZapLogger) *PasswordChangingHandler {
+	return &PasswordChangingHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP changes the password and responds with a token of a new session, tokens issued before are revoked.
+func (h *PasswordChangingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer closeBody(r.Context(), r.Body, h.logger)
+
+	userID, err := userIDFromCtx(r.Context())
+	if err != nil {
+		h.logger.ErrorCtx(r.Context(), failedToRecoverUserIDErrorMessage, zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	input, err := decodeJSON[PasswordChangingInput](r.Body)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "input decoding error", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	tkn, err := h.service.ChangePassword(r.Context(), userID, input.OldPassword, input.NewPassword)
+	if err != nil {
+		var validationErr *servicePackage.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			h.logger.DebugCtx(r.Context(), err.Error(), zap.Int("userID", userID))
+			fieldNames := map[string]string{servicePackage.PasswordField: "new_password"}
+			if err := writeValidationError(w, validationErr, fieldNames); err != nil {
+				h.logger.ErrorCtx(r.Context(), "Error writing response", zap.Error(err))
+			}
+			return
+		case errors.Is(err, servicePackage.ErrInvalidCredentials):
+			h.logger.DebugCtx(r.Context(), err.Error(), zap.Int("userID", userID))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		default:
+			h.logger.ErrorCtx(r.Context(), "password changing service error", zap.Error(err), zap.Int("userID", userID))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Authorization", "Bearer "+tkn)
+}
@@ -43,7 +43,14 @@ func (h *RegisterHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	tkn, err := h.service.Register(r.Context(), input.Login, input.Password)
 	if err != nil {
+		var validationErr *servicePackage.ValidationError
 		switch {
+		case errors.As(err, &validationErr):
+			h.logger.DebugCtx(r.Context(), err.Error(), zap.String("login", input.Login))
+			if err := writeValidationError(w, validationErr, nil); err != nil {
+				h.logger.ErrorCtx(r.Context(), "Error writing response", zap.Error(err))
+			}
+			return
 		case errors.Is(err, servicePackage.ErrLoginTaken):
 			h.logger.DebugCtx(r.Context(), err.Error(), zap.String("login", input.Login))
 			w.WriteHeader(http.StatusConflict)
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"go-market/internal/common/clientprotocol"
+	servicePackage "go-market/internal/gophermart/service"
+	"go-market/pkg/logging"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultStatementPageSize = 100
+	maxStatementPageSize     = 1000
+
+	statementFormatCSV  = "csv"
+	statementDateLayout = "2006-01-02"
+)
+
+var statementCSVHeader = []string{"occurred_at", "kind", "reference", "sum", "balance"}
+
+type StatementGettingHandler struct {
+	service StatementGettingService
+	logger  *logging.ZapLogger
+}
+
+type StatementGettingService interface {
+	StreamStatement(
+		ctx context.Context,
+		userID int,
+		filter servicePackage.StatementFilter,
+		yield func(entry servicePackage.StatementEntry) error,
+	) error
+}
+
+type StatementEntry struct {
+	OccurredAt time.Time            `json:"occurred_at"`
+	Kind       string               `json:"kind"`
+	Reference  string               `json:"reference,omitempty"`
+	Amount     clientprotocol.Money `json:"sum"`
+	Balance    clientprotocol.Money `json:"balance"`
+}
+
+func NewStatementGettingHandler(service StatementGettingService, logger *logging.ZapLogger) *StatementGettingHandler {
+	return &StatementGettingHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP responds with a page of the statement in JSON, or with the whole range in CSV for format=csv.
+// Amounts of debits are negative.
+func (h *StatementGettingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+	moneyFormat, err := moneyFormatFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Unsupported money format", zap.Error(err))
+		w.WriteHeader(http.StatusNotAcceptable)
+		return
+	}
+	w.Header().Add("Vary", acceptTimezoneHeader)
+	location, err := locationFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Invalid time zone", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	userID, err := userIDFromCtx(r.Context())
+	if err != nil {
+		h.logger.ErrorCtx(r.Context(), failedToRecoverUserIDErrorMessage, zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	csvFormat := r.URL.Query().Get("format") == statementFormatCSV
+	filter, err := statementFilterFromRequest(r, location, csvFormat)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Invalid statement query", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if csvFormat {
+		h.writeCSV(w, r, userID, filter, location)
+		return
+	}
+
+	res := make([]StatementEntry, 0)
+	err = h.service.StreamStatement(r.Context(), userID, filter, func(entry servicePackage.StatementEntry) error {
+		res = append(res, StatementEntry{
+			OccurredAt: entry.OccurredAt.In(location),
+			Kind:       string(entry.Kind),
+			Reference:  entry.Reference,
+			Amount:     moneyFormat.Money(entry.Amount),
+			Balance:    moneyFormat.Money(entry.Balance),
+		})
+		return nil
+	})
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	if len(res) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err := tryWriteResponseJSON(w, res); err != nil {
+		h.logger.ErrorCtx(r.Context(), "Error writing response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// writeCSV streams the entries as they are read, the status is written with the first entry,
+// so errors after it can only be logged.
+func (h *StatementGettingHandler) writeCSV(
+	w http.ResponseWriter,
+	r *http.Request,
+	userID int,
+	filter servicePackage.StatementFilter,
+	location *time.Location,
+) {
+	writer := csv.NewWriter(w)
+	started := false
+	err := h.service.StreamStatement(r.Context(), userID, filter, func(entry servicePackage.StatementEntry) error {
+		if !started {
+			started = true
+			w.Header().Add("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Add("Content-Disposition", `attachment; filename="statement.csv"`)
+			w.WriteHeader(http.StatusOK)
+			if err := writer.Write(statementCSVHeader); err != nil {
+				return fmt.Errorf("writing csv header failed: %w", err)
+			}
+		}
+		err := writer.Write([]string{
+			entry.OccurredAt.In(location).Format(time.RFC3339),
+			string(entry.Kind),
+			entry.Reference,
+			entry.Amount.StringFixed(clientprotocol.MoneyScale),
+			entry.Balance.StringFixed(clientprotocol.MoneyScale),
+		})
+		if err != nil {
+			return fmt.Errorf("writing csv entry failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil && !started {
+		h.writeError(w, r, err)
+		return
+	}
+	if err != nil {
+		h.logger.ErrorCtx(r.Context(), "Error streaming statement", zap.Error(err))
+		return
+	}
+	if !started {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		h.logger.ErrorCtx(r.Context(), "Error writing response", zap.Error(err))
+	}
+}
+
+func (h *StatementGettingHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, servicePackage.ErrUnknownProgram), errors.Is(err, servicePackage.ErrInvalidStatementRange):
+		h.logger.DebugCtx(r.Context(), "Failed to get statement", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		h.logger.ErrorCtx(r.Context(), "Failed to get statement", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// statementFilterFromRequest reads program, from, to, limit and offset query parameters.
+// CSV exports are not limited unless limit is given.
+func statementFilterFromRequest(
+	r *http.Request,
+	location *time.Location,
+	csvFormat bool,
+) (servicePackage.StatementFilter, error) {
+	query := r.URL.Query()
+	filter := servicePackage.StatementFilter{
+		Program: query.Get("program"),
+	}
+	if !csvFormat {
+		filter.Limit = defaultStatementPageSize
+	}
+	var err error
+	if value := query.Get("from"); value != "" {
+		filter.From, err = parseStatementTime(value, location, false)
+		if err != nil {
+			return servicePackage.StatementFilter{}, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if value := query.Get("to"); value != "" {
+		filter.To, err = parseStatementTime(value, location, true)
+		if err != nil {
+			return servicePackage.StatementFilter{}, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		filter.Limit, err = strconv.Atoi(value)
+		if err != nil || filter.Limit < 1 || filter.Limit > maxStatementPageSize {
+			return servicePackage.StatementFilter{}, fmt.Errorf("limit must be from 1 to %d", maxStatementPageSize)
+		}
+	}
+	if value := query.Get("offset"); value != "" {
+		filter.Offset, err = strconv.Atoi(value)
+		if err != nil || filter.Offset < 0 {
+			return servicePackage.StatementFilter{}, errors.New("offset must not be negative")
+		}
+	}
+	return filter, nil
+}
+
+// parseStatementTime accepts RFC 3339 timestamps and dates in location, a date ending the range includes its day.
+func parseStatementTime(value string, location *time.Location, rangeEnd bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(statementDateLayout, value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("neither a timestamp nor a date: %w", err)
+	}
+	if rangeEnd {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	servicePackage "go-market/internal/gophermart/service"
+	"go-market/pkg/logging"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// TransferIDParam is the route parameter holding the id of a transfer.
+const TransferIDParam = "transferID"
+
+// TransferClosingHandler closes the pending transfer named by the TransferIDParam route parameter.
+type TransferClosingHandler struct {
+	closeTransfer func(ctx context.Context, userID int, transferID int64) (servicePackage.Transfer, error)
+	logger        *logging.ZapLogger
+}
+
+type TransferAcceptingService interface {
+	AcceptTransfer(ctx context.Context, userID int, transferID int64) (servicePackage.Transfer, error)
+}
+
+type TransferDecliningService interface {
+	DeclineTransfer(ctx context.Context, userID int, transferID int64) (servicePackage.Transfer, error)
+}
+
+type TransferCancellingService interface {
+	CancelTransfer(ctx context.Context, userID int, transferID int64) (servicePackage.Transfer, error)
+}
+
+func NewTransferAcceptingHandler(service TransferAcceptingService, logger *logging.ZapLogger) *TransferClosingHandler {
+	return &TransferClosingHandler{
+		closeTransfer: service.AcceptTransfer,
+		logger:        logger,
+	}
+}
+
+func NewTransferDecliningHandler(service TransferDecliningService, logger *logging.ZapLogger) *TransferClosingHandler {
+	return &TransferClosingHandler{
+		closeTransfer: service.DeclineTransfer,
+		logger:        logger,
+	}
+}
+
+func NewTransferCancellingHandler(
+	service TransferCancellingService,
+	logger *logging.ZapLogger,
+) *TransferClosingHandler {
+	return &TransferClosingHandler{
+		closeTransfer: service.CancelTransfer,
+		logger:        logger,
+	}
+}
+
+func (h *TransferClosingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+	moneyFormat, err := moneyFormatFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Unsupported money format", zap.Error(err))
+		w.WriteHeader(http.StatusNotAcceptable)
+		return
+	}
+	w.Header().Add("Vary", acceptTimezoneHeader)
+	location, err := locationFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Invalid time zone", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	userID, err := userIDFromCtx(r.Context())
+	if err != nil {
+		h.logger.ErrorCtx(r.Context(), failedToRecoverUserIDErrorMessage, zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	transferID, err := strconv.ParseInt(chi.URLParam(r, TransferIDParam), 10, 64)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Invalid transfer id", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	transfer, err := h.closeTransfer(r.Context(), userID, transferID)
+	if err != nil {
+		switch {
+		case errors.Is(err, servicePackage.ErrTransferNotFound):
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, servicePackage.ErrTransferNotPending):
+			h.logger.DebugCtx(r.Context(), "Failed to close transfer", zap.Error(err))
+			w.WriteHeader(http.StatusConflict)
+		case errors.Is(err, servicePackage.ErrNotEnoughBalance):
+			h.logger.DebugCtx(r.Context(), "Failed to close transfer", zap.Error(err))
+			w.WriteHeader(http.StatusPaymentRequired)
+		default:
+			h.logger.ErrorCtx(r.Context(), "Failed to close transfer", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	if err := tryWriteResponseJSON(w, newTransfer(transfer, moneyFormat, location)); err != nil {
+		h.logger.ErrorCtx(r.Context(), "Error writing response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
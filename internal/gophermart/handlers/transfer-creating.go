@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"go-market/internal/common/clientprotocol"
+	servicePackage "go-market/internal/gophermart/service"
+	"go-market/pkg/logging"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+type TransferCreatingHandler struct {
+	service TransferCreatingService
+	logger  *logging.ZapLogger
+}
+
+type TransferCreatingService interface {
+	Transfer(
+		ctx context.Context,
+		senderID int,
+		recipientLogin string,
+		programID string,
+		amount decimal.Decimal,
+		requireAccept bool,
+	) (servicePackage.Transfer, error)
+}
+
+type TransferRequest struct {
+	Recipient string               `json:"recipient"`
+	Program   string               `json:"program,omitempty"`
+	Amount    clientprotocol.Money `json:"sum"`
+	// RequireAccept keeps the transfer pending until the recipient accepts it.
+	RequireAccept bool `json:"require_accept,omitempty"`
+}
+
+type Transfer struct {
+	CreatedAt    time.Time            `json:"created_at"`
+	ClosedAt     *time.Time           `json:"closed_at,omitempty"`
+	Direction    string               `json:"direction"`
+	Counterparty string               `json:"counterparty"`
+	Program      string               `json:"program"`
+	Status       string               `json:"status"`
+	Amount       clientprotocol.Money `json:"sum"`
+	ID           int64                `json:"id"`
+}
+
+func NewTransferCreatingHandler(service TransferCreatingService, logger *logging.ZapLogger) *TransferCreatingHandler {
+	return &TransferCreatingHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP responds 200 with a completed transfer and 202 with a transfer waiting for the recipient.
+func (h *TransferCreatingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer closeBody(r.Context(), r.Body, h.logger)
+	w.Header().Add("Vary", "Accept")
+	moneyFormat, err := moneyFormatFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Unsupported money format", zap.Error(err))
+		w.WriteHeader(http.StatusNotAcceptable)
+		return
+	}
+	w.Header().Add("Vary", acceptTimezoneHeader)
+	location, err := locationFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Invalid time zone", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	userID, err := userIDFromCtx(r.Context())
+	if err != nil {
+		h.logger.ErrorCtx(r.Context(), failedToRecoverUserIDErrorMessage, zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	request, err := decodeJSON[TransferRequest](r.Body)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "input decoding error", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if request.Recipient == "" || request.Amount.Decimal().IsZero() {
+		h.logger.DebugCtx(r.Context(), "Empty transfer recipient or sum")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := h.service.Transfer(
+		r.Context(),
+		userID,
+		request.Recipient,
+		request.Program,
+		request.Amount.Decimal(),
+		request.RequireAccept,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, servicePackage.ErrNotEnoughBalance):
+			h.logger.DebugCtx(r.Context(), "Failed to transfer", zap.Error(err))
+			w.WriteHeader(http.StatusPaymentRequired)
+		case errors.Is(err, servicePackage.ErrRecipientNotFound):
+			h.logger.DebugCtx(r.Context(), "Failed to transfer", zap.Error(err))
+			w.WriteHeader(http.StatusNotFound)
+		case errors.Is(err, servicePackage.ErrTransferLimitExceeded):
+			h.logger.DebugCtx(r.Context(), "Failed to transfer", zap.Error(err))
+			w.WriteHeader(http.StatusForbidden)
+		case errors.Is(err, servicePackage.ErrUnknownProgram), errors.Is(err, servicePackage.ErrSelfTransfer):
+			h.logger.DebugCtx(r.Context(), "Failed to transfer", zap.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			h.logger.ErrorCtx(r.Context(), "Failed to transfer", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+	status := http.StatusOK
+	if request.RequireAccept {
+		status = http.StatusAccepted
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := writeJSON(w, newTransfer(transfer, moneyFormat, location)); err != nil {
+		h.logger.ErrorCtx(r.Context(), "Error writing response", zap.Error(err))
+	}
+}
+
+func newTransfer(
+	transfer servicePackage.Transfer,
+	moneyFormat clientprotocol.MoneyFormat,
+	location *time.Location,
+) Transfer {
+	return Transfer{
+		CreatedAt:    transfer.CreatedAt.In(location),
+		ClosedAt:     timeIn(transfer.ClosedAt, location),
+		Direction:    string(transfer.Direction),
+		Counterparty: transfer.Counterparty,
+		Program:      transfer.Program,
+		Status:       string(transfer.Status),
+		Amount:       moneyFormat.Money(transfer.Amount),
+		ID:           transfer.ID,
+	}
+}
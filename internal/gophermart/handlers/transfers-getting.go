@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	servicePackage "go-market/internal/gophermart/service"
+	"go-market/pkg/logging"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type TransfersGettingHandler struct {
+	service TransfersGettingService
+	logger  *logging.ZapLogger
+}
+
+type TransfersGettingService interface {
+	GetAllUserTransfers(ctx context.Context, userID int) ([]servicePackage.Transfer, error)
+}
+
+func NewTransfersGettingHandler(service TransfersGettingService, logger *logging.ZapLogger) *TransfersGettingHandler {
+	return &TransfersGettingHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *TransfersGettingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+	moneyFormat, err := moneyFormatFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Unsupported money format", zap.Error(err))
+		w.WriteHeader(http.StatusNotAcceptable)
+		return
+	}
+	w.Header().Add("Vary", acceptTimezoneHeader)
+	location, err := locationFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Invalid time zone", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	userID, err := userIDFromCtx(r.Context())
+	if err != nil {
+		h.logger.ErrorCtx(r.Context(), failedToRecoverUserIDErrorMessage, zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	transfers, err := h.service.GetAllUserTransfers(r.Context(), userID)
+	if err != nil {
+		h.logger.ErrorCtx(r.Context(), "Error getting transfers", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if len(transfers) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	res := make([]Transfer, len(transfers))
+	for i, transfer := range transfers {
+		res[i] = newTransfer(transfer, moneyFormat, location)
+	}
+	if err := tryWriteResponseJSON(w, res); err != nil {
+		h.logger.ErrorCtx(r.Context(), "Error writing response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	servicePackage "go-market/internal/gophermart/service"
+	"go-market/pkg/logging"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type UserDeletingHandler struct {
+	service UserDeletingService
+	logger  *logging.ZapLogger
+}
+
+type UserDeletingService interface {
+	DeleteUser(ctx context.Context, userID int) error
+}
+
+func NewUserDeletingHandler(service UserDeletingService, logger *logging.ZapLogger) *UserDeletingHandler {
+	return &UserDeletingHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP erases the login and credentials of the user, the token used stops working.
+func (h *UserDeletingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromCtx(r.Context())
+	if err != nil {
+		h.logger.ErrorCtx(r.Context(), failedToRecoverUserIDErrorMessage, zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = h.service.DeleteUser(r.Context(), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, servicePackage.ErrSessionRevoked):
+			h.logger.DebugCtx(r.Context(), err.Error(), zap.Int("userID", userID))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		default:
+			h.logger.ErrorCtx(r.Context(), "user deleting service error", zap.Error(err), zap.Int("userID", userID))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
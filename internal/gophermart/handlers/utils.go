@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"go-market/internal/common/clientprotocol"
 	"go-market/internal/gophermart/service"
 	"go-market/pkg/logging"
 	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/jwtauth/v5"
 	"go.uber.org/zap"
@@ -17,6 +21,9 @@ import (
 
 const (
 	invalidUserID = -1
+
+	// acceptTimezoneHeader holds an IANA time zone name used to render timestamps, UTC by default.
+	acceptTimezoneHeader = "Accept-Timezone"
 )
 
 var (
@@ -51,6 +58,76 @@ func userIDFromCtx(ctx context.Context) (userID int, err error) {
 	return userID, nil
 }
 
+func locationFromRequest(r *http.Request) (*time.Location, error) {
+	name := r.Header.Get(acceptTimezoneHeader)
+	if name == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: %w", acceptTimezoneHeader, err)
+	}
+	return location, nil
+}
+
+// timeIn converts t to location, zero time is converted to nil.
+func timeIn(t time.Time, location *time.Location) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	res := t.In(location)
+	return &res
+}
+
+// moneyFormatFromRequest reads the money parameter of the Accept header, e.g. "application/json; money=string".
+func moneyFormatFromRequest(r *http.Request) (clientprotocol.MoneyFormat, error) {
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		_, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		if value, ok := params[clientprotocol.MoneyFormatParam]; ok {
+			return clientprotocol.ParseMoneyFormat(value) //nolint:wrapcheck // unnecessary
+		}
+	}
+	return clientprotocol.MoneyNumber, nil
+}
+
+// FieldErrorOutput is a violation of one request field, Field is named as in the request body.
+type FieldErrorOutput struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorOutput is the body of a 400 response to a request with invalid fields.
+type ValidationErrorOutput struct {
+	Error  string             `json:"error"`
+	Fields []FieldErrorOutput `json:"fields"`
+}
+
+// writeValidationError responds with 400 listing the violations, fields missing in fieldNames keep their names.
+func writeValidationError(w http.ResponseWriter, err *service.ValidationError, fieldNames map[string]string) error {
+	output := ValidationErrorOutput{
+		Error:  "credentials violate the policy",
+		Fields: make([]FieldErrorOutput, len(err.Errors)),
+	}
+	for i, fieldError := range err.Errors {
+		field := fieldError.Field
+		if name, ok := fieldNames[field]; ok {
+			field = name
+		}
+		output.Fields[i] = FieldErrorOutput{Field: field, Message: fieldError.Message}
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	return writeJSON(w, output)
+}
+
+// writeJSON writes the body of a response which headers are already written.
+func writeJSON(w http.ResponseWriter, responseItem any) error {
+	return json.NewEncoder(w).Encode(responseItem) //nolint:wrapcheck // unnecessary
+}
+
 func tryWriteResponseJSON(w http.ResponseWriter, responseItem any) error {
 	res, err := json.Marshal(responseItem)
 	if err != nil {
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"errors"
+	"go-market/internal/common/clientprotocol"
 	servicePackage "go-market/internal/gophermart/service"
 	"go-market/pkg/logging"
 	"go-market/pkg/lunh"
@@ -18,12 +19,13 @@ type WithdrawRequesterHandler struct {
 }
 
 type WithdrawRequesterService interface {
-	Withdraw(ctx context.Context, userID int, orderNumber string, amount decimal.Decimal) error
+	Withdraw(ctx context.Context, userID int, orderNumber string, programID string, amount decimal.Decimal) error
 }
 
 type WithdrawalRequest struct {
-	OrderNumber string          `json:"order"`
-	Amount      decimal.Decimal `json:"sum"`
+	OrderNumber string               `json:"order"`
+	Program     string               `json:"program,omitempty"`
+	Amount      clientprotocol.Money `json:"sum"`
 }
 
 func NewWithdrawRequesterHandler(
@@ -51,19 +53,29 @@ func (h *WithdrawRequesterHandler) ServeHTTP(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if request.Amount.Decimal().IsZero() {
+		h.logger.DebugCtx(r.Context(), "Zero withdrawal sum")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
 	if !lunh.Validate(request.OrderNumber) {
 		h.logger.DebugCtx(r.Context(), "Invalid order number", zap.String("body", request.OrderNumber))
 		w.WriteHeader(http.StatusUnprocessableEntity)
 		return
 	}
 
-	err = h.service.Withdraw(r.Context(), userID, request.OrderNumber, request.Amount)
+	err = h.service.Withdraw(r.Context(), userID, request.OrderNumber, request.Program, request.Amount.Decimal())
 	if err != nil {
 		switch {
 		case errors.Is(err, servicePackage.ErrNotEnoughBalance):
 			h.logger.DebugCtx(r.Context(), "", zap.Error(err))
 			w.WriteHeader(http.StatusPaymentRequired)
 			return
+		case errors.Is(err, servicePackage.ErrUnknownProgram):
+			h.logger.DebugCtx(r.Context(), "Unknown program", zap.String("program", request.Program))
+			w.WriteHeader(http.StatusBadRequest)
+			return
 		default:
 			h.logger.ErrorCtx(r.Context(), "Error getting orders", zap.Error(err))
 			w.WriteHeader(http.StatusInternalServerError)
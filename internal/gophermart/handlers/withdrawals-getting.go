@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"go-market/internal/common/clientprotocol"
 	servicePackage "go-market/internal/gophermart/service"
 	"go-market/pkg/logging"
 	"net/http"
@@ -16,9 +17,10 @@ type WithdrawalsGettingHandler struct {
 }
 
 type Withdrawal struct {
-	ProcessTime time.Time `json:"processed_at"`
-	OrderNumber string    `json:"order"`
-	Amount      float64   `json:"sum"`
+	ProcessTime time.Time            `json:"processed_at"`
+	OrderNumber string               `json:"order"`
+	Program     string               `json:"program"`
+	Amount      clientprotocol.Money `json:"sum"`
 }
 
 type WithdrawalsGettingService interface {
@@ -36,6 +38,20 @@ func NewWithdrawalsGettingHandler(
 }
 
 func (h *WithdrawalsGettingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+	moneyFormat, err := moneyFormatFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Unsupported money format", zap.Error(err))
+		w.WriteHeader(http.StatusNotAcceptable)
+		return
+	}
+	w.Header().Add("Vary", acceptTimezoneHeader)
+	location, err := locationFromRequest(r)
+	if err != nil {
+		h.logger.DebugCtx(r.Context(), "Invalid time zone", zap.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
 	userID, err := userIDFromCtx(r.Context())
 	if err != nil {
 		h.logger.ErrorCtx(r.Context(), failedToRecoverUserIDErrorMessage, zap.Error(err))
@@ -54,11 +70,11 @@ func (h *WithdrawalsGettingHandler) ServeHTTP(w http.ResponseWriter, r *http.Req
 	}
 	res := make([]Withdrawal, len(withdrawals))
 	for i, withdrawal := range withdrawals {
-		amount, _ := withdrawal.Amount.Float64()
 		res[i] = Withdrawal{
 			OrderNumber: withdrawal.OrderNumber,
-			Amount:      amount,
-			ProcessTime: withdrawal.ProcessTime,
+			Program:     withdrawal.Program,
+			Amount:      moneyFormat.Money(withdrawal.Amount),
+			ProcessTime: withdrawal.ProcessTime.In(location),
 		}
 	}
 	if err := tryWriteResponseJSON(w, res); err != nil {
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// AdminTokenHeader carries the token of administrative and integration requests.
+const AdminTokenHeader = "X-Admin-Token"
+
+// AdminToken lets through only requests bearing the configured token in AdminTokenHeader.
+type AdminToken struct {
+	token []byte
+}
+
+func NewAdminToken(token string) *AdminToken {
+	return &AdminToken{
+		token: []byte(token),
+	}
+}
+
+func (at *AdminToken) CreateHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := []byte(r.Header.Get(AdminTokenHeader))
+		if len(at.token) == 0 || subtle.ConstantTimeCompare(token, at.token) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-market/internal/gophermart/service"
+	"go-market/pkg/logging"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/jwtauth/v5"
+	"go.uber.org/zap"
+)
+
+type SessionChecker interface {
+	CheckSession(ctx context.Context, userID int, sessionVersion int) error
+}
+
+// Session drops tokens of revoked sessions from the request context, so that jwtauth.Authenticator
+// and other authenticators reject them. It expects jwtauth.Verifier to be applied.
+type Session struct {
+	checker SessionChecker
+	logger  *logging.ZapLogger
+}
+
+func NewSession(checker SessionChecker, logger *logging.ZapLogger) *Session {
+	return &Session{
+		checker: checker,
+		logger:  logger,
+	}
+}
+
+func (s *Session) CreateHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, claims, err := jwtauth.FromContext(r.Context())
+		if err != nil || token == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		err = s.check(r.Context(), claims)
+		switch {
+		case err == nil:
+		case errors.Is(err, service.ErrSessionRevoked):
+			s.logger.DebugCtx(r.Context(), "Session is revoked", zap.Error(err))
+			r = r.WithContext(jwtauth.NewContext(r.Context(), nil, err))
+		default:
+			s.logger.ErrorCtx(r.Context(), "Failed to check session", zap.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Session) check(ctx context.Context, claims map[string]any) error {
+	userIDStr, ok := claims[service.UserIDClaimName].(string)
+	if !ok {
+		return fmt.Errorf("%w: invalid user id type", service.ErrSessionRevoked)
+	}
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		return fmt.Errorf("%w: invalid user id: %w", service.ErrSessionRevoked, err)
+	}
+	sessionVersion := 0
+	if sessionStr, ok := claims[service.SessionClaimName].(string); ok {
+		sessionVersion, err = strconv.Atoi(sessionStr)
+		if err != nil {
+			return fmt.Errorf("%w: invalid session: %w", service.ErrSessionRevoked, err)
+		}
+	}
+	return s.checker.CheckSession(ctx, userID, sessionVersion) //nolint:wrapcheck // unnecessary
+}
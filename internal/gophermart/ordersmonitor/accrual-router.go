@@ -0,0 +1,49 @@
+package ordersmonitor
+
+import (
+	"fmt"
+	"strings"
+)
+
+type AccrualRoute struct {
+	Prefix   string
+	Provider string
+}
+
+type RouterConfig struct {
+	// Routes are checked in order, the first route with a matching prefix wins.
+	Routes          []AccrualRoute
+	DefaultProvider string
+}
+
+// AccrualRouter picks an accrual provider for an order number.
+type AccrualRouter struct {
+	providers map[string]AccrualSystem
+	cfg       RouterConfig
+}
+
+func NewAccrualRouter(cfg RouterConfig, providers map[string]AccrualSystem) (*AccrualRouter, error) {
+	if _, ok := providers[cfg.DefaultProvider]; !ok {
+		return nil, fmt.Errorf("unknown default accrual provider %q", cfg.DefaultProvider)
+	}
+	for _, route := range cfg.Routes {
+		if _, ok := providers[route.Provider]; !ok {
+			return nil, fmt.Errorf("unknown accrual provider %q for prefix %q", route.Provider, route.Prefix)
+		}
+	}
+	return &AccrualRouter{
+		cfg:       cfg,
+		providers: providers,
+	}, nil
+}
+
+func (r *AccrualRouter) Route(orderNumber string) (providerName string, provider AccrualSystem) {
+	providerName = r.cfg.DefaultProvider
+	for _, route := range r.cfg.Routes {
+		if strings.HasPrefix(orderNumber, route.Prefix) {
+			providerName = route.Provider
+			break
+		}
+	}
+	return providerName, r.providers[providerName]
+}
@@ -0,0 +1,107 @@
+package ordersmonitor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAccrualRouter(t *testing.T) {
+	providers := map[string]AccrualSystem{
+		"http":   &fakeAccrualSystem{},
+		"static": &fakeAccrualSystem{},
+	}
+	tests := []struct {
+		name        string
+		cfg         RouterConfig
+		expectedErr string
+	}{
+		{
+			name: "known providers",
+			cfg: RouterConfig{
+				Routes:          []AccrualRoute{{Prefix: "1", Provider: "static"}},
+				DefaultProvider: "http",
+			},
+		},
+		{
+			name:        "unknown default provider",
+			cfg:         RouterConfig{DefaultProvider: "grpc"},
+			expectedErr: `unknown default accrual provider "grpc"`,
+		},
+		{
+			name: "unknown route provider",
+			cfg: RouterConfig{
+				Routes: []AccrualRoute{
+					{Prefix: "1", Provider: "static"},
+					{Prefix: "2", Provider: "grpc"},
+				},
+				DefaultProvider: "http",
+			},
+			expectedErr: `unknown accrual provider "grpc" for prefix "2"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router, err := NewAccrualRouter(test.cfg, providers)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				assert.Nil(t, router)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, router)
+		})
+	}
+}
+
+func TestAccrualRouterRoute(t *testing.T) {
+	providers := map[string]AccrualSystem{
+		"http":   &fakeAccrualSystem{},
+		"static": &fakeAccrualSystem{},
+		"grpc":   &fakeAccrualSystem{},
+	}
+	router, err := NewAccrualRouter(RouterConfig{
+		Routes: []AccrualRoute{
+			{Prefix: "1", Provider: "static"},
+			{Prefix: "12", Provider: "grpc"},
+			{Prefix: "9", Provider: "grpc"},
+		},
+		DefaultProvider: "http",
+	}, providers)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		orderNumber      string
+		expectedProvider string
+	}{
+		{
+			name:             "matching prefix",
+			orderNumber:      "9278923470",
+			expectedProvider: "grpc",
+		},
+		{
+			name:             "first matching route wins",
+			orderNumber:      "12345678903",
+			expectedProvider: "static",
+		},
+		{
+			name:             "no matching route",
+			orderNumber:      "2377225624",
+			expectedProvider: "http",
+		},
+		{
+			name:             "empty number",
+			orderNumber:      "",
+			expectedProvider: "http",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			providerName, provider := router.Route(test.orderNumber)
+			assert.Equal(t, test.expectedProvider, providerName)
+			assert.Same(t, providers[test.expectedProvider], provider)
+		})
+	}
+}
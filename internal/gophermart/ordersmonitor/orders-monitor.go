@@ -8,6 +8,7 @@ import (
 	"go-market/internal/gophermart/accrualsystem"
 	"go-market/internal/gophermart/data"
 	"go-market/pkg/logging"
+	"go-market/pkg/pgxstorage"
 	"go-market/pkg/threadsafe"
 	"go-market/pkg/timeutils"
 	"sync"
@@ -18,18 +19,42 @@ import (
 )
 
 type TransactionManager interface {
-	DoWithTransaction(ctx context.Context, f func(ctx context.Context) error) error
+	DoWithTransaction(ctx context.Context, f func(ctx context.Context) error, opts ...pgxstorage.TxOption) error
 }
 
 type OrdersRepository interface {
-	GetOrders(ctx context.Context, limit int, allowedStatuses ...data.Status) ([]data.Order, error)
-	GetOrder(ctx context.Context, orderNumber string) (userID int, status data.Status, err error)
-	SetOrderStatus(ctx context.Context, orderNumber string, accrual decimal.Decimal, status data.Status) error
+	GetOrders(ctx context.Context, lease data.Lease, limit int, allowedStatuses ...data.Status) ([]data.Order, error)
+	GetOrder(ctx context.Context, orderNumber string, leaseOwner string) (userID int, status data.Status, err error)
+	ReleaseOrderLease(ctx context.Context, orderNumber string, leaseOwner string) error
+	DeferOrderPoll(ctx context.Context, orderNumber string, leaseOwner string, pollAt time.Time) error
+	ScheduleOrderPoll(
+		ctx context.Context,
+		orderNumber string,
+		status data.Status,
+		accrualProvider string,
+		delay time.Duration,
+		lastError string,
+	) error
+	MarkStuckOrders(ctx context.Context, maxAge time.Duration) (count int64, err error)
+	SetOrderStatus(
+		ctx context.Context,
+		orderNumber string,
+		accrual decimal.Decimal,
+		status data.Status,
+		accrualProvider string,
+		programID string,
+	) error
 }
 
 type BonusPointsRepository interface {
-	GetUserBalance(ctx context.Context, userID int) (decimal.Decimal, error)
-	SetUserBalance(ctx context.Context, userID int, value decimal.Decimal) error
+	IncrementUserBalance(
+		ctx context.Context,
+		userID int,
+		programID string,
+		delta decimal.Decimal,
+	) (decimal.Decimal, error)
+	ProgramExists(ctx context.Context, programID string) (bool, error)
+	InsertPointLot(ctx context.Context, lot data.PointLot) error
 }
 
 type AccrualSystem interface {
@@ -38,16 +63,29 @@ type AccrualSystem interface {
 }
 
 type Config struct {
-	TickPeriod        time.Duration
-	WorkersCount      int
-	TasksBufferLength int
+	Router RouterConfig
+	// InstanceID identifies the monitor as an owner of order leases, it must be unique across replicas.
+	InstanceID string
+	// LeaseDuration is the time an order stays claimed by the monitor, expired leases are reclaimed by others.
+	LeaseDuration time.Duration
+	// PollBackoff defines delays between polls of an order not finished by the accrual system.
+	PollBackoff timeutils.RetryPolicy
+	// MaxOrderAge is the age after which an unfinished order is marked as stuck.
+	MaxOrderAge time.Duration
+	// StuckCheckPeriod is the period of the stuck orders sweep, it is expected to be much longer than TickPeriod.
+	StuckCheckPeriod time.Duration
+	// PointsLifetimeMonths is the number of months accrued points stay usable, zero means they never expire.
+	PointsLifetimeMonths int
+	TickPeriod           time.Duration
+	WorkersCount         int
+	TasksBufferLength    int
 }
 
 type OrdersMonitor struct {
 	orderStatusRepository OrdersRepository
 	bonusPointsRepository BonusPointsRepository
 	transactionManager    TransactionManager
-	accrualSystem         AccrualSystem
+	accrualRouter         *AccrualRouter
 	processingOrders      *threadsafe.HashSet[string]
 	logger                *logging.ZapLogger
 	done                  chan struct{}
@@ -59,14 +97,14 @@ func NewOrdersMonitor(
 	orderStatusRepository OrdersRepository,
 	bonusPointsRepository BonusPointsRepository,
 	transactionManager TransactionManager,
-	accrualSystem AccrualSystem,
+	accrualRouter *AccrualRouter,
 	logger *logging.ZapLogger,
 ) *OrdersMonitor {
 	return &OrdersMonitor{
 		orderStatusRepository: orderStatusRepository,
 		bonusPointsRepository: bonusPointsRepository,
 		transactionManager:    transactionManager,
-		accrualSystem:         accrualSystem,
+		accrualRouter:         accrualRouter,
 		config:                config,
 		processingOrders:      threadsafe.NewHashSet[string](),
 		logger:                logger,
@@ -75,23 +113,23 @@ func NewOrdersMonitor(
 }
 
 func (om *OrdersMonitor) Run() {
-	orderNumbersChan := make(chan string, om.config.TasksBufferLength)
+	ordersChan := make(chan data.Order, om.config.TasksBufferLength)
 
 	wg := &sync.WaitGroup{}
 
 	for range om.config.WorkersCount {
 		wg.Add(1)
-		go func(orderNumbersChan <-chan string) {
+		go func(ordersChan <-chan data.Order) {
 			defer wg.Done()
-			om.worker(orderNumbersChan)
-		}(orderNumbersChan)
+			om.worker(ordersChan)
+		}(ordersChan)
 	}
 
 	wg.Add(1)
-	go func(orderNumbersChan chan<- string) {
+	go func(ordersChan chan<- data.Order) {
 		defer wg.Done()
-		om.scheduler(orderNumbersChan)
-	}(orderNumbersChan)
+		om.scheduler(ordersChan)
+	}(ordersChan)
 
 	wg.Wait()
 }
@@ -100,31 +138,58 @@ func (om *OrdersMonitor) Stop() {
 	close(om.done)
 }
 
-func (om *OrdersMonitor) scheduler(orderNumbersChan chan<- string) {
-	defer close(orderNumbersChan)
+func (om *OrdersMonitor) scheduler(ordersChan chan<- data.Order) {
+	defer close(ordersChan)
 
 	ticker := time.NewTicker(om.config.TickPeriod)
 	defer ticker.Stop()
 
+	// a nil channel never fires, so the sweep is disabled without a period or a max age
+	var stuckTickerChan <-chan time.Time
+	if om.config.MaxOrderAge > 0 && om.config.StuckCheckPeriod > 0 {
+		stuckTicker := time.NewTicker(om.config.StuckCheckPeriod)
+		defer stuckTicker.Stop()
+		stuckTickerChan = stuckTicker.C
+	}
+
 	for {
 		select {
 		case <-om.done:
 			return
 		case <-ticker.C:
-			if err := om.tick(orderNumbersChan); err != nil {
+			if err := om.tick(ordersChan); err != nil {
 				om.logger.ErrorCtx(context.Background(), "error while scheduling orders", zap.Error(err))
 			}
+		case <-stuckTickerChan:
+			if err := om.markStuckOrders(); err != nil {
+				om.logger.ErrorCtx(context.Background(), "error while marking stuck orders", zap.Error(err))
+			}
 		}
 	}
 }
 
-func (om *OrdersMonitor) tick(orderNumbersChan chan<- string) error {
-	maxTasksToSchedule := om.config.TasksBufferLength - len(orderNumbersChan)
+func (om *OrdersMonitor) markStuckOrders() error {
+	stuckCount, err := om.orderStatusRepository.MarkStuckOrders(context.Background(), om.config.MaxOrderAge)
+	if err != nil {
+		return fmt.Errorf("marking stuck orders failed: %w", err)
+	}
+	if stuckCount > 0 {
+		om.logger.WarnCtx(context.Background(), "orders marked as stuck", zap.Int64("count", stuckCount))
+	}
+	return nil
+}
+
+func (om *OrdersMonitor) tick(ordersChan chan<- data.Order) error {
+	maxTasksToSchedule := om.config.TasksBufferLength - len(ordersChan)
 	if maxTasksToSchedule <= 0 {
 		return nil
 	}
-	orderNumbers, err := om.orderStatusRepository.GetOrders(
+	orders, err := om.orderStatusRepository.GetOrders(
 		context.Background(),
+		data.Lease{
+			Owner:    om.config.InstanceID,
+			Duration: om.config.LeaseDuration,
+		},
 		maxTasksToSchedule,
 		data.NewStatus,
 		data.ProcessingStatus,
@@ -132,35 +197,81 @@ func (om *OrdersMonitor) tick(orderNumbersChan chan<- string) error {
 	if err != nil {
 		return fmt.Errorf("getting orders failed: %w", err)
 	}
-	if len(orderNumbers) == 0 {
+	if len(orders) == 0 {
 		return nil
 	}
-	for _, order := range orderNumbers {
+	// every returned order is leased, skipped orders are given back so that they do not idle until the lease expires
+	for _, order := range orders {
 		orderNumber := order.OrderNumber
 		if om.processingOrders.Contains(orderNumber) {
+			om.releaseLease(orderNumber)
+			continue
+		}
+		providerName, provider := om.accrualRouter.Route(orderNumber)
+		if awakeTime := provider.GetServiceAwakeTime(); time.Now().Before(awakeTime) {
+			om.logger.DebugCtx(
+				context.Background(),
+				"accrual provider unavailable, deferring order",
+				zap.String("orderNumber", orderNumber),
+				zap.String("provider", providerName),
+				zap.Time("awakeTime", awakeTime),
+			)
+			err := om.orderStatusRepository.DeferOrderPoll(
+				context.Background(),
+				orderNumber,
+				om.config.InstanceID,
+				awakeTime,
+			)
+			if err != nil {
+				om.logger.ErrorCtx(context.Background(), "failed to defer order poll", zap.Error(err))
+			}
 			continue
 		}
 		om.logger.DebugCtx(context.Background(), "scheduling order", zap.String("orderNumber", orderNumber))
 		om.processingOrders.Add(orderNumber)
-		orderNumbersChan <- orderNumber
+		ordersChan <- order
 	}
 	return nil
 }
 
-func (om *OrdersMonitor) worker(orderNumberChan <-chan string) {
-	for orderNumber := range orderNumberChan {
-		err := om.handleOrder(orderNumber)
-		om.processingOrders.Remove(orderNumber)
-		if err != nil {
-			om.logger.ErrorCtx(context.TODO(), "failed to handle order", zap.Error(err))
+func (om *OrdersMonitor) worker(ordersChan <-chan data.Order) {
+	for order := range ordersChan {
+		err := om.handleOrder(order)
+		om.processingOrders.Remove(order.OrderNumber)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, data.ErrLeaseLost) {
+			om.logger.DebugCtx(context.TODO(), "order lease lost", zap.String("orderNumber", order.OrderNumber))
+			continue
 		}
+		om.logger.ErrorCtx(context.TODO(), "failed to handle order", zap.Error(err))
+		om.releaseLease(order.OrderNumber)
 	}
 }
 
-func (om *OrdersMonitor) handleOrder(orderNumber string) error {
+func (om *OrdersMonitor) releaseLease(orderNumber string) {
+	err := om.orderStatusRepository.ReleaseOrderLease(context.TODO(), orderNumber, om.config.InstanceID)
+	if err != nil {
+		om.logger.ErrorCtx(context.TODO(), "failed to release order lease", zap.Error(err))
+	}
+}
+
+// handleOrder polls the accrual system outside of a transaction, so that a slow or unavailable provider holds
+// neither a connection nor the order row, and then writes the result in a short transaction re-checking the lease.
+// All waits end with the lease of the order.
+func (om *OrdersMonitor) handleOrder(order data.Order) error {
+	ctx, cancel := context.WithDeadline(context.Background(), om.leaseDeadline(order))
+	defer cancel()
+	orderNumber := order.OrderNumber
+	providerName, provider := om.accrualRouter.Route(orderNumber)
+	remoteOrder, remoteErr := om.getRemoteOrder(ctx, provider, orderNumber)
+	if remoteErr != nil && ctx.Err() != nil {
+		return fmt.Errorf("failed to get remote order status from %s: %w", providerName, remoteErr)
+	}
 	//nolint:wrapcheck // wrapping unnecessary
-	return om.transactionManager.DoWithTransaction(context.Background(), func(ctx context.Context) error {
-		userID, status, err := om.orderStatusRepository.GetOrder(ctx, orderNumber)
+	return om.transactionManager.DoWithTransaction(ctx, func(ctx context.Context) error {
+		userID, status, err := om.orderStatusRepository.GetOrder(ctx, orderNumber, om.config.InstanceID)
 		if err != nil {
 			return fmt.Errorf("failed to get order: %w", err)
 		}
@@ -169,44 +280,93 @@ func (om *OrdersMonitor) handleOrder(orderNumber string) error {
 			return nil
 		case data.InvalidStatus:
 			return nil
+		case data.StuckStatus:
+			return nil
 		case data.NullStatus:
 			return errors.New("invalid order status")
 		}
-		remoteOrder, err := om.getRemoteOrder(ctx, orderNumber)
-		if err != nil {
+		if remoteErr != nil {
 			switch {
-			case errors.Is(err, accrualsystem.ErrNoOrderFound):
-				return om.orderStatusRepository.SetOrderStatus(ctx, orderNumber, decimal.Zero, data.InvalidStatus)
+			case errors.Is(remoteErr, accrualsystem.ErrNoOrderFound):
+				return om.orderStatusRepository.SetOrderStatus(
+					ctx,
+					orderNumber,
+					decimal.Zero,
+					data.InvalidStatus,
+					providerName,
+					order.ProgramID,
+				)
 			default:
-				return fmt.Errorf("failed to get remote order status: %w", err)
+				om.logger.WarnCtx(
+					ctx,
+					"failed to get remote order status, postponing",
+					zap.String("orderNumber", orderNumber),
+					zap.String("provider", providerName),
+					zap.Error(remoteErr),
+				)
+				return om.orderStatusRepository.ScheduleOrderPoll(
+					ctx,
+					orderNumber,
+					status,
+					providerName,
+					om.config.PollBackoff.Delay(order.Attempts),
+					remoteErr.Error(),
+				)
 			}
 		}
 		switch remoteOrder.Status {
 		case accrualsystemprotocol.Invalid:
-			return om.orderStatusRepository.SetOrderStatus(ctx, orderNumber, decimal.Zero, data.InvalidStatus)
+			return om.orderStatusRepository.SetOrderStatus(
+				ctx,
+				orderNumber,
+				decimal.Zero,
+				data.InvalidStatus,
+				providerName,
+				order.ProgramID,
+			)
 		case accrualsystemprotocol.Processing, accrualsystemprotocol.Registered:
-			return om.orderStatusRepository.SetOrderStatus(ctx, orderNumber, decimal.Zero, data.ProcessingStatus)
+			return om.orderStatusRepository.ScheduleOrderPoll(
+				ctx,
+				orderNumber,
+				data.ProcessingStatus,
+				providerName,
+				om.config.PollBackoff.Delay(order.Attempts),
+				"",
+			)
 		case accrualsystemprotocol.Processed:
-			currentPoints, err := om.bonusPointsRepository.GetUserBalance(ctx, userID)
+			programID, err := om.targetProgram(ctx, order, remoteOrder)
 			if err != nil {
-				return fmt.Errorf("failed to get current bonus points: %w", err)
+				return fmt.Errorf("failed to choose loyalty program: %w", err)
 			}
-			newBalance := currentPoints.Add(remoteOrder.Accrual)
-			om.logger.DebugCtx(
+			newBalance, err := om.bonusPointsRepository.IncrementUserBalance(
 				ctx,
-				"setting balance",
-				zap.Any("currentPoints", newBalance),
-				zap.Any("newBalance", newBalance),
+				userID,
+				programID,
+				remoteOrder.Accrual,
 			)
-			err = om.bonusPointsRepository.SetUserBalance(ctx, userID, newBalance)
 			if err != nil {
-				return fmt.Errorf("failed to set current bonus points: %w", err)
+				return fmt.Errorf("failed to increment bonus points: %w", err)
 			}
+			if remoteOrder.Accrual.IsPositive() {
+				err = om.bonusPointsRepository.InsertPointLot(ctx, om.newPointLot(order, userID, programID, remoteOrder))
+				if err != nil {
+					return fmt.Errorf("failed to insert points lot: %w", err)
+				}
+			}
+			om.logger.DebugCtx(
+				ctx,
+				"balance incremented",
+				zap.String("accrual", remoteOrder.Accrual.String()),
+				zap.String("program", programID),
+				zap.String("newBalance", newBalance.String()),
+			)
 			err = om.orderStatusRepository.SetOrderStatus(
 				ctx,
 				orderNumber,
 				remoteOrder.Accrual,
 				data.ProcessedStatus,
+				providerName,
+				programID,
 			)
 			if err != nil {
 				return fmt.Errorf("failed to set order status: %w", err)
@@ -217,17 +377,79 @@ func (om *OrdersMonitor) handleOrder(orderNumber string) error {
 	})
 }
 
+// leaseDeadline is the expiry of the lease taken on the order, or a whole lease from now if it is unknown.
+func (om *OrdersMonitor) leaseDeadline(order data.Order) time.Time {
+	if order.LeaseExpiresAt.IsZero() {
+		return time.Now().Add(om.config.LeaseDuration)
+	}
+	return order.LeaseExpiresAt
+}
+
+func (om *OrdersMonitor) newPointLot(
+	order data.Order,
+	userID int,
+	programID string,
+	remoteOrder accrualsystemprotocol.Order,
+) data.PointLot {
+	lot := data.PointLot{
+		CreditedAt:  time.Now().UTC(),
+		OrderNumber: order.OrderNumber,
+		ProgramID:   programID,
+		Amount:      remoteOrder.Accrual,
+		UserID:      userID,
+	}
+	if om.config.PointsLifetimeMonths > 0 {
+		lot.ExpiresAt = lot.CreditedAt.AddDate(0, om.config.PointsLifetimeMonths, 0)
+	}
+	return lot
+}
+
+// targetProgram picks the program to credit: the one named by the accrual system if it is known,
+// otherwise the one the order was uploaded for.
+func (om *OrdersMonitor) targetProgram(
+	ctx context.Context,
+	order data.Order,
+	remoteOrder accrualsystemprotocol.Order,
+) (string, error) {
+	fallback := order.ProgramID
+	if fallback == "" {
+		fallback = data.DefaultProgramID
+	}
+	if remoteOrder.Program == "" || remoteOrder.Program == fallback {
+		return fallback, nil
+	}
+	exists, err := om.bonusPointsRepository.ProgramExists(ctx, remoteOrder.Program)
+	if err != nil {
+		return "", fmt.Errorf("failed to check program %s: %w", remoteOrder.Program, err)
+	}
+	if !exists {
+		om.logger.WarnCtx(
+			ctx,
+			"accrual system named unknown program, crediting order program",
+			zap.String("orderNumber", order.OrderNumber),
+			zap.String("program", remoteOrder.Program),
+			zap.String("fallback", fallback),
+		)
+		return fallback, nil
+	}
+	return remoteOrder.Program, nil
+}
+
 //nolint:wrapcheck // wrapping unnecessary
-func (om *OrdersMonitor) getRemoteOrder(ctx context.Context, orderNumber string) (accrualsystemprotocol.Order, error) {
+func (om *OrdersMonitor) getRemoteOrder(
+	ctx context.Context,
+	accrualSystem AccrualSystem,
+	orderNumber string,
+) (accrualsystemprotocol.Order, error) {
 	for {
 		if ctx.Err() != nil {
 			return accrualsystemprotocol.Order{}, ctx.Err()
 		}
-		remoteOrder, err := om.accrualSystem.GetOrderStatus(ctx, orderNumber)
+		remoteOrder, err := accrualSystem.GetOrderStatus(ctx, orderNumber)
 		if err != nil {
 			switch {
-			case errors.Is(err, accrualsystem.ErrTooManyRequests):
-				timeToWait := time.Until(om.accrualSystem.GetServiceAwakeTime())
+			case errors.Is(err, accrualsystem.ErrTooManyRequests), errors.Is(err, accrualsystem.ErrCircuitOpen):
+				timeToWait := time.Until(accrualSystem.GetServiceAwakeTime())
 				err := timeutils.SleepCtx(ctx, timeToWait)
 				if err != nil {
 					return accrualsystemprotocol.Order{}, err
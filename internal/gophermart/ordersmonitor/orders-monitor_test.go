@@ -0,0 +1,385 @@
+package ordersmonitor
+
+import (
+	"context"
+	"fmt"
+	"go-market/internal/common/accrualsystemprotocol"
+	"go-market/internal/gophermart/accrualsystem"
+	"go-market/internal/gophermart/data"
+	"go-market/internal/gophermart/data/database"
+	"go-market/internal/gophermart/data/dbrepository"
+	"go-market/pkg/logging"
+	"go-market/pkg/lunh"
+	"go-market/pkg/pgxstorage"
+	"go-market/pkg/timeutils"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+const testDatabaseURIEnv = "TEST_DATABASE_URI"
+
+// TestOrdersMonitorExactlyOnce runs two monitors against one database and checks every order is credited once.
+func TestOrdersMonitorExactlyOnce(t *testing.T) {
+	dsn, ok := os.LookupEnv(testDatabaseURIEnv)
+	if !ok {
+		t.Skipf("%s is not set", testDatabaseURIEnv)
+	}
+
+	logger, err := logging.NewZapLogger(zapcore.ErrorLevel)
+	require.NoError(t, err)
+	retryPolicy := timeutils.RetryPolicy{MaxAttempts: 1}
+	storage, err := pgxstorage.New(
+		database.NewPgxDatabaseFactory(database.Config{ConnectionString: dsn, RetryPolicy: retryPolicy}),
+		retryPolicy,
+		pgxstorage.ReplicaConfig{},
+	)
+	require.NoError(t, err)
+	defer storage.Close()
+	repository := dbrepository.New(storage, logger)
+	transactionManager := pgxstorage.NewTransactionsManager(storage, timeutils.RetryPolicy{MaxAttempts: 10})
+
+	ctx := context.Background()
+	userID, err := repository.InsertUser(ctx, fmt.Sprintf("monitor-%d", rand.Int32()), "password")
+	require.NoError(t, err)
+
+	const ordersCount = 20
+	accrual := decimal.NewFromInt(100)
+	for range ordersCount {
+		err := repository.InsertOrder(ctx, &data.Order{
+			OrderNumber: newOrderNumber(),
+			ProgramID:   data.DefaultProgramID,
+			Status:      data.NewStatus,
+			UserID:      userID,
+			Accrual:     decimal.Zero,
+			UploadTime:  time.Now(),
+		})
+		require.NoError(t, err)
+	}
+
+	static := accrualsystem.NewStaticAccrualSystem(accrualsystem.StaticConfig{
+		Rules: []accrualsystem.StaticRule{{Status: accrualsystemprotocol.Processed, Accrual: accrual}},
+	})
+	router, err := NewAccrualRouter(
+		RouterConfig{DefaultProvider: accrualsystem.StaticProviderName},
+		map[string]AccrualSystem{accrualsystem.StaticProviderName: static},
+	)
+	require.NoError(t, err)
+
+	monitors := make([]*OrdersMonitor, 0)
+	wg := &sync.WaitGroup{}
+	for i := range 2 {
+		monitor := NewOrdersMonitor(
+			Config{
+				InstanceID:        "test-monitor-" + strconv.Itoa(i),
+				LeaseDuration:     time.Minute,
+				PollBackoff:       timeutils.RetryPolicy{InitialInterval: 10 * time.Millisecond},
+				TickPeriod:        10 * time.Millisecond,
+				WorkersCount:      4,
+				TasksBufferLength: 4,
+			},
+			repository,
+			repository,
+			transactionManager,
+			router,
+			logger,
+		)
+		monitors = append(monitors, monitor)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			monitor.Run()
+		}()
+	}
+
+	assert.Eventually(t, func() bool {
+		orders, err := repository.GetAllUserOrders(ctx, userID)
+		if err != nil {
+			return false
+		}
+		for _, order := range orders {
+			if order.Status != data.ProcessedStatus {
+				return false
+			}
+		}
+		return true
+	}, 30*time.Second, 50*time.Millisecond)
+
+	for _, monitor := range monitors {
+		monitor.Stop()
+	}
+	wg.Wait()
+
+	balance, err := repository.GetUserBalance(ctx, userID, data.DefaultProgramID)
+	require.NoError(t, err)
+	assert.True(t, accrual.Mul(decimal.NewFromInt(ordersCount)).Equal(balance), "balance %s", balance)
+}
+
+func newOrderNumber() string {
+	number := strconv.FormatInt(rand.Int64N(1_000_000_000_000), 10)
+	for checkDigit := range 10 {
+		candidate := number + strconv.Itoa(checkDigit)
+		if lunh.Validate(candidate) {
+			return candidate
+		}
+	}
+	panic("no check digit found")
+}
+
+type fakeTransactionManager struct {
+	inTransaction bool
+	transactions  int
+}
+
+func (tm *fakeTransactionManager) DoWithTransaction(
+	ctx context.Context,
+	f func(ctx context.Context) error,
+	_ ...pgxstorage.TxOption,
+) error {
+	tm.inTransaction = true
+	tm.transactions++
+	defer func() {
+		tm.inTransaction = false
+	}()
+	return f(ctx)
+}
+
+type fakeOrdersRepository struct {
+	OrdersRepository
+	pollsAt  map[string]time.Time
+	leased   []data.Order
+	released []string
+	status   data.Status
+	// stuckChecks is updated by the scheduler goroutine
+	stuckChecks atomic.Int32
+}
+
+func (r *fakeOrdersRepository) MarkStuckOrders(context.Context, time.Duration) (int64, error) {
+	r.stuckChecks.Add(1)
+	return 0, nil
+}
+
+func (r *fakeOrdersRepository) GetOrders(context.Context, data.Lease, int, ...data.Status) ([]data.Order, error) {
+	return r.leased, nil
+}
+
+func (r *fakeOrdersRepository) ReleaseOrderLease(_ context.Context, orderNumber string, _ string) error {
+	r.released = append(r.released, orderNumber)
+	return nil
+}
+
+func (r *fakeOrdersRepository) DeferOrderPoll(_ context.Context, orderNumber string, _ string, pollAt time.Time) error {
+	if r.pollsAt == nil {
+		r.pollsAt = make(map[string]time.Time)
+	}
+	r.pollsAt[orderNumber] = pollAt
+	return nil
+}
+
+func (r *fakeOrdersRepository) GetOrder(context.Context, string, string) (int, data.Status, error) {
+	return 1, data.NewStatus, nil
+}
+
+func (r *fakeOrdersRepository) SetOrderStatus(
+	_ context.Context,
+	_ string,
+	_ decimal.Decimal,
+	status data.Status,
+	_ string,
+	_ string,
+) error {
+	r.status = status
+	return nil
+}
+
+// fakeAccrualSystem answers with err, or with order when err is nil, and remembers whether it was called
+// within a transaction.
+type fakeAccrualSystem struct {
+	awakeTime               time.Time
+	transactionManager      *fakeTransactionManager
+	order                   accrualsystemprotocol.Order
+	err                     error
+	calledWithinTransaction bool
+}
+
+func (s *fakeAccrualSystem) GetServiceAwakeTime() time.Time {
+	return s.awakeTime
+}
+
+func (s *fakeAccrualSystem) GetOrderStatus(context.Context, string) (accrualsystemprotocol.Order, error) {
+	s.calledWithinTransaction = s.calledWithinTransaction || s.transactionManager.inTransaction
+	return s.order, s.err
+}
+
+func TestHandleOrder(t *testing.T) {
+	logger, err := logging.NewZapLogger(zapcore.ErrorLevel)
+	require.NoError(t, err)
+	tests := []struct {
+		name                 string
+		awakeTime            time.Time
+		remoteOrder          accrualsystemprotocol.Order
+		remoteErr            error
+		expectedStatus       data.Status
+		expectedTransactions int
+		expectError          bool
+	}{
+		{
+			name:                 "invalid order",
+			remoteOrder:          accrualsystemprotocol.Order{Status: accrualsystemprotocol.Invalid},
+			expectedStatus:       data.InvalidStatus,
+			expectedTransactions: 1,
+		},
+		{
+			name:                 "unknown order",
+			remoteErr:            accrualsystem.ErrNoOrderFound,
+			expectedStatus:       data.InvalidStatus,
+			expectedTransactions: 1,
+		},
+		{
+			// the provider stays unavailable longer than the lease, the wait ends with the lease
+			name:        "unavailable provider",
+			awakeTime:   time.Now().Add(time.Hour),
+			remoteErr:   accrualsystem.ErrCircuitOpen,
+			expectError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transactionManager := &fakeTransactionManager{}
+			provider := &fakeAccrualSystem{
+				awakeTime:          test.awakeTime,
+				transactionManager: transactionManager,
+				order:              test.remoteOrder,
+				err:                test.remoteErr,
+			}
+			router, err := NewAccrualRouter(
+				RouterConfig{DefaultProvider: "fake"},
+				map[string]AccrualSystem{"fake": provider},
+			)
+			require.NoError(t, err)
+			repository := &fakeOrdersRepository{}
+			monitor := NewOrdersMonitor(Config{}, repository, nil, transactionManager, router, logger)
+
+			started := time.Now()
+			err = monitor.handleOrder(data.Order{
+				OrderNumber:    "12345678903",
+				ProgramID:      data.DefaultProgramID,
+				LeaseExpiresAt: started.Add(50 * time.Millisecond),
+			})
+			if test.expectError {
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+				assert.Less(t, time.Since(started), time.Second)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.False(t, provider.calledWithinTransaction)
+			assert.Equal(t, test.expectedTransactions, transactionManager.transactions)
+			assert.Equal(t, test.expectedStatus, repository.status)
+		})
+	}
+}
+
+// TestTickGivesBackSkippedOrders checks orders not scheduled by a tick do not stay leased.
+func TestTickGivesBackSkippedOrders(t *testing.T) {
+	logger, err := logging.NewZapLogger(zapcore.ErrorLevel)
+	require.NoError(t, err)
+	awakeTime := time.Now().Add(time.Minute)
+	router, err := NewAccrualRouter(
+		RouterConfig{
+			Routes:          []AccrualRoute{{Prefix: "9", Provider: "sleeping"}},
+			DefaultProvider: "awake",
+		},
+		map[string]AccrualSystem{
+			"awake":    &fakeAccrualSystem{},
+			"sleeping": &fakeAccrualSystem{awakeTime: awakeTime},
+		},
+	)
+	require.NoError(t, err)
+	repository := &fakeOrdersRepository{
+		leased: []data.Order{
+			{OrderNumber: "12345678903"},
+			{OrderNumber: "2377225624"},
+			{OrderNumber: "9278923470"},
+		},
+	}
+	monitor := NewOrdersMonitor(
+		Config{InstanceID: "test-monitor", TasksBufferLength: 10},
+		repository,
+		nil,
+		&fakeTransactionManager{},
+		router,
+		logger,
+	)
+	monitor.processingOrders.Add("2377225624")
+
+	ordersChan := make(chan data.Order, 10)
+	require.NoError(t, monitor.tick(ordersChan))
+	close(ordersChan)
+
+	scheduled := make([]string, 0)
+	for order := range ordersChan {
+		scheduled = append(scheduled, order.OrderNumber)
+	}
+	assert.Equal(t, []string{"12345678903"}, scheduled)
+	assert.Equal(t, []string{"2377225624"}, repository.released)
+	assert.Equal(t, map[string]time.Time{"9278923470": awakeTime}, repository.pollsAt)
+}
+
+// TestStuckOrdersSweepPeriod checks the stuck orders sweep runs on its own period instead of every tick.
+func TestStuckOrdersSweepPeriod(t *testing.T) {
+	logger, err := logging.NewZapLogger(zapcore.ErrorLevel)
+	require.NoError(t, err)
+	tests := []struct {
+		name             string
+		stuckCheckPeriod time.Duration
+		expectSweep      bool
+	}{
+		{
+			name:             "sweep period longer than ticks",
+			stuckCheckPeriod: time.Hour,
+		},
+		{
+			name:             "sweep period elapsed",
+			stuckCheckPeriod: 10 * time.Millisecond,
+			expectSweep:      true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := &fakeOrdersRepository{}
+			monitor := NewOrdersMonitor(
+				Config{
+					MaxOrderAge:       time.Hour,
+					StuckCheckPeriod:  test.stuckCheckPeriod,
+					TickPeriod:        time.Millisecond,
+					TasksBufferLength: 1,
+				},
+				repository,
+				nil,
+				&fakeTransactionManager{},
+				nil,
+				logger,
+			)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				monitor.scheduler(make(chan data.Order, 1))
+			}()
+
+			time.Sleep(100 * time.Millisecond)
+			monitor.Stop()
+			<-done
+
+			assert.Equal(t, test.expectSweep, repository.stuckChecks.Load() > 0)
+		})
+	}
+}
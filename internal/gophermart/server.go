@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"go-market/internal/gophermart/apiv2"
 	"go-market/internal/gophermart/handlers"
 	"go-market/internal/gophermart/middleware"
 	"go-market/pkg/logging"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/jwtauth/v5"
+	"go.uber.org/zap"
 )
 
 type Server struct {
@@ -22,6 +24,10 @@ type Server struct {
 type AuthorizationService interface {
 	handlers.RegistrationService
 	handlers.AuthorizationService
+	handlers.PasswordChangingService
+	handlers.LoginChangingService
+	handlers.UserDeletingService
+	middleware.SessionChecker
 }
 
 type OrdersService interface {
@@ -35,23 +41,58 @@ type WalletService interface {
 	handlers.WithdrawRequesterService
 }
 
+type HoldsService interface {
+	handlers.HoldCreatingService
+	handlers.HoldCapturingService
+	handlers.HoldReleasingService
+}
+
+type AdjustmentsService interface {
+	handlers.AccrualAdjustingService
+}
+
+type StatementService interface {
+	handlers.StatementGettingService
+}
+
+type TransfersService interface {
+	handlers.TransferCreatingService
+	handlers.TransferAcceptingService
+	handlers.TransferDecliningService
+	handlers.TransferCancellingService
+	handlers.TransfersGettingService
+}
+
 func NewServer(
 	cfg Config,
 	tokenAuth *jwtauth.JWTAuth,
 	authorizationService AuthorizationService,
 	ordersService OrdersService,
 	walletService WalletService,
+	holdsService HoldsService,
+	transfersService TransfersService,
+	adjustmentsService AdjustmentsService,
+	statementService StatementService,
 	logger *logging.ZapLogger,
-) *Server {
+) (*Server, error) {
+	mux, err := createMux(
+		tokenAuth,
+		cfg.AdminToken,
+		authorizationService,
+		ordersService,
+		walletService,
+		holdsService,
+		transfersService,
+		adjustmentsService,
+		statementService,
+		logger,
+	)
+	if err != nil {
+		return nil, err
+	}
 	srv := &http.Server{
-		Addr: cfg.ServerAddress,
-		Handler: createMux(
-			tokenAuth,
-			authorizationService,
-			ordersService,
-			walletService,
-			logger,
-		),
+		Addr:    cfg.ServerAddress,
+		Handler: mux,
 	}
 
 	res := &Server{
@@ -60,7 +101,7 @@ func NewServer(
 		httpServer: srv,
 	}
 
-	return res
+	return res, nil
 }
 
 func (s *Server) Run() error {
@@ -81,21 +122,51 @@ func (s *Server) Shutdown() error {
 
 func createMux(
 	tokenAuth *jwtauth.JWTAuth,
+	adminToken string,
 	authorizationService AuthorizationService,
 	ordersService OrdersService,
 	walletService WalletService,
+	holdsService HoldsService,
+	transfersService TransfersService,
+	adjustmentsService AdjustmentsService,
+	statementService StatementService,
 	logger *logging.ZapLogger,
-) *chi.Mux {
+) (*chi.Mux, error) {
 	registrationHandler := handlers.NewRegisterHandler(authorizationService, logger)
 	authorizationHandler := handlers.NewAuthorizationHandler(authorizationService, logger)
+	passwordChangingHandler := handlers.NewPasswordChangingHandler(authorizationService, logger)
+	loginChangingHandler := handlers.NewLoginChangingHandler(authorizationService, logger)
+	userDeletingHandler := handlers.NewUserDeletingHandler(authorizationService, logger)
 	orderLoadingHandler := handlers.NewOrderLoadingHandler(ordersService, logger)
 	orderGettingHandler := handlers.NewOrderGettingHandler(ordersService, logger)
 	balanceGettingHandler := handlers.NewBalanceGettingHandler(walletService, logger)
 	withdrawalsGettingHandler := handlers.NewWithdrawalsGettingHandler(walletService, logger)
 	withdrawHandler := handlers.NewWithdrawRequesterHandler(walletService, logger)
+	holdCreatingHandler := handlers.NewHoldCreatingHandler(holdsService, logger)
+	holdCapturingHandler := handlers.NewHoldCapturingHandler(holdsService, logger)
+	holdReleasingHandler := handlers.NewHoldReleasingHandler(holdsService, logger)
+	transferCreatingHandler := handlers.NewTransferCreatingHandler(transfersService, logger)
+	transferAcceptingHandler := handlers.NewTransferAcceptingHandler(transfersService, logger)
+	transferDecliningHandler := handlers.NewTransferDecliningHandler(transfersService, logger)
+	transferCancellingHandler := handlers.NewTransferCancellingHandler(transfersService, logger)
+	transfersGettingHandler := handlers.NewTransfersGettingHandler(transfersService, logger)
+	accrualAdjustingHandler := handlers.NewAccrualAdjustingHandler(adjustmentsService, logger)
+	statementGettingHandler := handlers.NewStatementGettingHandler(statementService, logger)
+	apiV2Server := apiv2.NewServer(authorizationService, ordersService, walletService, logger)
+	spec, err := apiv2.SpecJSON()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // unnecessary
+	}
 
 	loggerContextMiddleware := middleware.NewLoggerContext()
 	panicRecover := middleware.NewPanicRecover(logger)
+	adminTokenMiddleware := middleware.NewAdminToken(adminToken)
+	sessionMiddleware := middleware.NewSession(authorizationService, logger)
+	authentication := chi.Chain(
+		jwtauth.Verifier(tokenAuth),
+		sessionMiddleware.CreateHandler,
+		jwtauth.Authenticator(tokenAuth),
+	)
 
 	router := chi.NewRouter()
 
@@ -104,19 +175,52 @@ func createMux(
 	router.Route("/api/user/", func(router chi.Router) {
 		router.Post("/register", registrationHandler.ServeHTTP)
 		router.Post("/login", authorizationHandler.ServeHTTP)
-		router.With(
-			jwtauth.Verifier(tokenAuth),
-			jwtauth.Authenticator(tokenAuth),
-		).Route("/", func(router chi.Router) {
+		router.With(authentication...).Route("/", func(router chi.Router) {
+			router.Post("/password", passwordChangingHandler.ServeHTTP)
 			router.Post("/orders", orderLoadingHandler.ServeHTTP)
 			router.Get("/orders", orderGettingHandler.ServeHTTP)
 			router.Get("/withdrawals", withdrawalsGettingHandler.ServeHTTP)
+			router.Get("/statement", statementGettingHandler.ServeHTTP)
+			router.Route("/transfers", func(router chi.Router) {
+				router.Get("/", transfersGettingHandler.ServeHTTP)
+				router.Post("/{"+handlers.TransferIDParam+"}/accept", transferAcceptingHandler.ServeHTTP)
+				router.Post("/{"+handlers.TransferIDParam+"}/decline", transferDecliningHandler.ServeHTTP)
+				router.Post("/{"+handlers.TransferIDParam+"}/cancel", transferCancellingHandler.ServeHTTP)
+			})
 			router.Route("/balance", func(router chi.Router) {
 				router.Get("/", balanceGettingHandler.ServeHTTP)
 				router.Post("/withdraw", withdrawHandler.ServeHTTP)
+				router.Post("/transfer", transferCreatingHandler.ServeHTTP)
+				router.Route("/holds", func(router chi.Router) {
+					router.Post("/", holdCreatingHandler.ServeHTTP)
+					router.Post("/{"+handlers.HoldIDParam+"}/capture", holdCapturingHandler.ServeHTTP)
+					router.Post("/{"+handlers.HoldIDParam+"}/release", holdReleasingHandler.ServeHTTP)
+				})
 			})
 		})
 	})
 
-	return router
+	router.With(authentication...).Group(func(router chi.Router) {
+		router.Patch("/api/user", loginChangingHandler.ServeHTTP)
+		router.Delete("/api/user", userDeletingHandler.ServeHTTP)
+	})
+
+	if adminToken != "" {
+		router.With(adminTokenMiddleware.CreateHandler).Route("/api/admin/", func(router chi.Router) {
+			router.Post("/orders/{"+handlers.OrderNumberParam+"}/adjustments", accrualAdjustingHandler.ServeHTTP)
+		})
+	}
+
+	router.Group(func(router chi.Router) {
+		router.Use(jwtauth.Verifier(tokenAuth), sessionMiddleware.CreateHandler)
+		apiv2.NewHandler(apiV2Server, router)
+	})
+	router.Get("/api/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(spec); err != nil {
+			logger.ErrorCtx(r.Context(), "Error writing response", zap.Error(err))
+		}
+	})
+
+	return router, nil
 }
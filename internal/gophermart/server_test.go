@@ -0,0 +1,986 @@
+package gophermart
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-market/internal/common/clientprotocol"
+	"go-market/internal/gophermart/apiv2"
+	"go-market/internal/gophermart/data"
+	"go-market/internal/gophermart/middleware"
+	"go-market/internal/gophermart/service"
+	"go-market/pkg/jwtfactory"
+	"go-market/pkg/logging"
+
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	testLogin       = "user"
+	testPassword    = "password"
+	registeredOrder = "12345678903"
+	foreignOrder    = "2377225624"
+	unknownProgram  = "unknown"
+	activeHoldID    = 1
+	closedHoldID    = 2
+	recipientLogin  = "recipient"
+	pendingTransfer = 1
+	closedTransfer  = 2
+	testAdminToken  = "admin-token"
+	pendingOrder    = "79927398713"
+	erasedUserID    = 3
+)
+
+var errPasswordRequired = &service.ValidationError{
+	Errors: []service.FieldError{{Field: service.PasswordField, Message: "is required"}},
+}
+
+type fakeServices struct {
+	tokenFactory *jwtfactory.TokenFactory
+}
+
+func (s *fakeServices) Register(_ context.Context, login string, password string) (string, error) {
+	if password == "" {
+		return "", errPasswordRequired
+	}
+	if login == testLogin {
+		return "", service.ErrLoginTaken
+	}
+	return s.tokenFactory.Generate(map[string]string{service.UserIDClaimName: "2"})
+}
+
+func (s *fakeServices) Login(_ context.Context, login string, password string) (string, error) {
+	if login != testLogin || password != testPassword {
+		return "", service.ErrInvalidCredentials
+	}
+	return s.tokenFactory.Generate(map[string]string{service.UserIDClaimName: "1"})
+}
+
+func (s *fakeServices) ChangePassword(
+	_ context.Context,
+	_ int,
+	oldPassword string,
+	newPassword string,
+) (string, error) {
+	if newPassword == "" {
+		return "", errPasswordRequired
+	}
+	if oldPassword != testPassword {
+		return "", service.ErrInvalidCredentials
+	}
+	return s.tokenFactory.Generate(map[string]string{service.UserIDClaimName: "1", service.SessionClaimName: "1"})
+}
+
+func (s *fakeServices) ChangeLogin(_ context.Context, _ int, login string) error {
+	if login == recipientLogin {
+		return service.ErrLoginTaken
+	}
+	return nil
+}
+
+func (s *fakeServices) DeleteUser(context.Context, int) error {
+	return nil
+}
+
+func (s *fakeServices) CheckSession(_ context.Context, userID int, _ int) error {
+	if userID == erasedUserID {
+		return service.ErrSessionRevoked
+	}
+	return nil
+}
+
+func (s *fakeServices) RegisterOrder(_ context.Context, _ int, orderNumber string, programID string) error {
+	if programID == unknownProgram {
+		return service.ErrUnknownProgram
+	}
+	switch orderNumber {
+	case registeredOrder:
+		return service.ErrOrderRegistered
+	case foreignOrder:
+		return service.ErrOrderRegisteredByAnotherUser
+	}
+	return nil
+}
+
+func (s *fakeServices) GetAllOrders(context.Context, int) ([]service.Order, error) {
+	return []service.Order{
+		{
+			Number:          registeredOrder,
+			Program:         "default",
+			Status:          clientprotocol.Processed,
+			Accrual:         decimal.RequireFromString("729.98"),
+			AdjustedAccrual: decimal.NullDecimal{Decimal: decimal.NewFromInt(500), Valid: true},
+			UploadedAt:      time.Date(2020, 12, 10, 15, 15, 45, 0, time.UTC),
+		},
+	}, nil
+}
+
+func (s *fakeServices) GetUserBalanceInfo(context.Context, int) (service.BalanceInfo, error) {
+	return service.BalanceInfo{
+		Balance:     decimal.RequireFromString("500.5"),
+		Withdrawals: decimal.NewFromInt(42),
+		Expiring:    decimal.NewFromInt(100),
+		ExpiringAt:  time.Date(2021, 1, 10, 15, 15, 45, 0, time.UTC),
+		Programs: []service.ProgramBalance{
+			{
+				Program:     "default",
+				Name:        "Gophermart points",
+				Balance:     decimal.RequireFromString("500.5"),
+				Withdrawals: decimal.NewFromInt(42),
+				Expiring:    decimal.NewFromInt(100),
+				ExpiringAt:  time.Date(2021, 1, 10, 15, 15, 45, 0, time.UTC),
+			},
+			{
+				Program:     "partner",
+				Name:        "Partner miles",
+				Balance:     decimal.NewFromInt(7),
+				Withdrawals: decimal.Zero,
+			},
+		},
+	}, nil
+}
+
+func (s *fakeServices) GetAllUserWithdrawals(context.Context, int) ([]service.Withdrawal, error) {
+	return []service.Withdrawal{
+		{
+			OrderNumber: "2377225624",
+			Program:     "default",
+			Amount:      decimal.NewFromInt(500),
+			ProcessTime: time.Date(2020, 12, 9, 16, 9, 57, 0, time.UTC),
+		},
+	}, nil
+}
+
+func (s *fakeServices) Withdraw(_ context.Context, _ int, _ string, programID string, amount decimal.Decimal) error {
+	if programID == unknownProgram {
+		return service.ErrUnknownProgram
+	}
+	if amount.GreaterThan(decimal.NewFromInt(1000)) {
+		return service.ErrNotEnoughBalance
+	}
+	return nil
+}
+
+func (s *fakeServices) CreateHold(
+	_ context.Context,
+	_ int,
+	orderNumber string,
+	programID string,
+	amount decimal.Decimal,
+	ttl time.Duration,
+) (service.Hold, error) {
+	if amount.GreaterThan(decimal.NewFromInt(1000)) {
+		return service.Hold{}, service.ErrNotEnoughBalance
+	}
+	createdAt := time.Date(2020, 12, 9, 16, 9, 57, 0, time.UTC)
+	return service.Hold{
+		CreatedAt:   createdAt,
+		ExpiresAt:   createdAt.Add(ttl),
+		OrderNumber: orderNumber,
+		Program:     programID,
+		Status:      data.ActiveHoldStatus,
+		Amount:      amount,
+		ID:          activeHoldID,
+	}, nil
+}
+
+func (s *fakeServices) CaptureHold(ctx context.Context, userID int, holdID int64) (service.Hold, error) {
+	return s.closeHold(ctx, userID, holdID, data.CapturedHoldStatus)
+}
+
+func (s *fakeServices) ReleaseHold(ctx context.Context, userID int, holdID int64) (service.Hold, error) {
+	return s.closeHold(ctx, userID, holdID, data.ReleasedHoldStatus)
+}
+
+func (s *fakeServices) closeHold(_ context.Context, _ int, holdID int64, status data.HoldStatus) (service.Hold, error) {
+	switch holdID {
+	case activeHoldID:
+		createdAt := time.Date(2020, 12, 9, 16, 9, 57, 0, time.UTC)
+		return service.Hold{
+			CreatedAt:   createdAt,
+			ExpiresAt:   createdAt.Add(time.Hour),
+			ClosedAt:    createdAt.Add(time.Minute),
+			OrderNumber: foreignOrder,
+			Program:     "default",
+			Status:      status,
+			Amount:      decimal.NewFromInt(10),
+			ID:          activeHoldID,
+		}, nil
+	case closedHoldID:
+		return service.Hold{}, service.ErrHoldNotActive
+	}
+	return service.Hold{}, service.ErrHoldNotFound
+}
+
+func (s *fakeServices) Transfer(
+	_ context.Context,
+	_ int,
+	recipient string,
+	programID string,
+	amount decimal.Decimal,
+	requireAccept bool,
+) (service.Transfer, error) {
+	switch {
+	case recipient == testLogin:
+		return service.Transfer{}, service.ErrSelfTransfer
+	case recipient != recipientLogin:
+		return service.Transfer{}, service.ErrRecipientNotFound
+	case amount.GreaterThan(decimal.NewFromInt(1000)):
+		return service.Transfer{}, service.ErrTransferLimitExceeded
+	}
+	createdAt := time.Date(2020, 12, 9, 16, 9, 57, 0, time.UTC)
+	transfer := service.Transfer{
+		CreatedAt:    createdAt,
+		Direction:    service.OutgoingTransfer,
+		Counterparty: recipient,
+		Program:      programID,
+		Status:       data.PendingTransferStatus,
+		Amount:       amount,
+		ID:           pendingTransfer,
+	}
+	if !requireAccept {
+		transfer.Status = data.CompletedTransferStatus
+		transfer.ClosedAt = createdAt
+	}
+	return transfer, nil
+}
+
+func (s *fakeServices) AcceptTransfer(ctx context.Context, userID int, transferID int64) (service.Transfer, error) {
+	return s.closeTransfer(ctx, userID, transferID, data.CompletedTransferStatus)
+}
+
+func (s *fakeServices) DeclineTransfer(ctx context.Context, userID int, transferID int64) (service.Transfer, error) {
+	return s.closeTransfer(ctx, userID, transferID, data.DeclinedTransferStatus)
+}
+
+func (s *fakeServices) CancelTransfer(ctx context.Context, userID int, transferID int64) (service.Transfer, error) {
+	return s.closeTransfer(ctx, userID, transferID, data.CancelledTransferStatus)
+}
+
+func (s *fakeServices) closeTransfer(
+	_ context.Context,
+	_ int,
+	transferID int64,
+	status data.TransferStatus,
+) (service.Transfer, error) {
+	switch transferID {
+	case pendingTransfer:
+		createdAt := time.Date(2020, 12, 9, 16, 9, 57, 0, time.UTC)
+		return service.Transfer{
+			CreatedAt:    createdAt,
+			ClosedAt:     createdAt.Add(time.Minute),
+			Direction:    service.IncomingTransfer,
+			Counterparty: recipientLogin,
+			Program:      "default",
+			Status:       status,
+			Amount:       decimal.NewFromInt(10),
+			ID:           pendingTransfer,
+		}, nil
+	case closedTransfer:
+		return service.Transfer{}, service.ErrTransferNotPending
+	}
+	return service.Transfer{}, service.ErrTransferNotFound
+}
+
+func (s *fakeServices) GetAllUserTransfers(context.Context, int) ([]service.Transfer, error) {
+	return []service.Transfer{
+		{
+			CreatedAt:    time.Date(2020, 12, 9, 16, 9, 57, 0, time.UTC),
+			Direction:    service.IncomingTransfer,
+			Counterparty: recipientLogin,
+			Program:      "default",
+			Status:       data.PendingTransferStatus,
+			Amount:       decimal.NewFromInt(10),
+			ID:           pendingTransfer,
+		},
+	}, nil
+}
+
+func (s *fakeServices) AdjustAccrual(
+	_ context.Context,
+	orderNumber string,
+	accrual decimal.Decimal,
+	reason string,
+) (service.Adjustment, error) {
+	switch orderNumber {
+	case registeredOrder:
+		return service.Adjustment{
+			CreatedAt:       time.Date(2020, 12, 11, 10, 0, 0, 0, time.UTC),
+			OrderNumber:     orderNumber,
+			Program:         "default",
+			Reason:          reason,
+			PreviousAccrual: decimal.RequireFromString("729.98"),
+			Accrual:         accrual,
+			ID:              1,
+		}, nil
+	case pendingOrder:
+		return service.Adjustment{}, service.ErrOrderNotProcessed
+	}
+	return service.Adjustment{}, service.ErrOrderNotFound
+}
+
+func (s *fakeServices) StreamStatement(
+	_ context.Context,
+	_ int,
+	filter service.StatementFilter,
+	yield func(entry service.StatementEntry) error,
+) error {
+	if filter.Program == unknownProgram {
+		return service.ErrUnknownProgram
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.To.After(filter.From) {
+		return service.ErrInvalidStatementRange
+	}
+	entries := []service.StatementEntry{
+		{
+			OccurredAt: time.Date(2020, 12, 10, 15, 15, 45, 0, time.UTC),
+			Kind:       data.AccrualStatementEntry,
+			Reference:  registeredOrder,
+			Amount:     decimal.NewFromInt(500),
+			Balance:    decimal.NewFromInt(500),
+		},
+		{
+			OccurredAt: time.Date(2020, 12, 11, 10, 0, 0, 0, time.UTC),
+			Kind:       data.TransferOutStatementEntry,
+			Reference:  recipientLogin,
+			Amount:     decimal.NewFromInt(-100),
+			Balance:    decimal.NewFromInt(400),
+		},
+	}
+	for _, entry := range entries {
+		if err := yield(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TestContract checks requests and responses of both API versions against openapi.yaml.
+func TestContract(t *testing.T) {
+	logger, err := logging.NewZapLogger(zapcore.ErrorLevel)
+	require.NoError(t, err)
+	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	services := &fakeServices{tokenFactory: jwtfactory.New(tokenAuth, time.Hour)}
+	mux, err := createMux(
+		tokenAuth,
+		testAdminToken,
+		services,
+		services,
+		services,
+		services,
+		services,
+		services,
+		services,
+		logger,
+	)
+	require.NoError(t, err)
+	token, err := services.tokenFactory.Generate(map[string]string{service.UserIDClaimName: "1"})
+	require.NoError(t, err)
+	revokedToken, err := services.tokenFactory.Generate(map[string]string{service.UserIDClaimName: "3"})
+	require.NoError(t, err)
+
+	spec, err := apiv2.LoadSpec()
+	require.NoError(t, err)
+	router, err := legacy.NewRouter(spec)
+	require.NoError(t, err)
+
+	credentials := `{"login":"` + testLogin + `","password":"` + testPassword + `"}`
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		contentType    string
+		body           string
+		anonymous      bool
+		revoked        bool
+		admin          bool
+		expectedStatus int
+	}{
+		{
+			name:           "v1 register taken",
+			method:         http.MethodPost,
+			path:           "/api/user/register",
+			contentType:    "application/json",
+			body:           credentials,
+			anonymous:      true,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "v1 register",
+			method:         http.MethodPost,
+			path:           "/api/user/register",
+			contentType:    "application/json",
+			body:           `{"login":"new","password":"p"}`,
+			anonymous:      true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 register with empty password",
+			method:         http.MethodPost,
+			path:           "/api/user/register",
+			contentType:    "application/json",
+			body:           `{"login":"new","password":""}`,
+			anonymous:      true,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "v1 login",
+			method:         http.MethodPost,
+			path:           "/api/user/login",
+			contentType:    "application/json",
+			body:           credentials,
+			anonymous:      true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 login failed",
+			method:         http.MethodPost,
+			path:           "/api/user/login",
+			contentType:    "application/json",
+			body:           `{"login":"x","password":"y"}`,
+			anonymous:      true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "v1 upload order",
+			method:         http.MethodPost,
+			path:           "/api/user/orders",
+			contentType:    "text/plain",
+			body:           "79927398713",
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "v1 upload own order",
+			method:         http.MethodPost,
+			path:           "/api/user/orders",
+			contentType:    "text/plain",
+			body:           registeredOrder,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 upload foreign order",
+			method:         http.MethodPost,
+			path:           "/api/user/orders",
+			contentType:    "text/plain",
+			body:           foreignOrder,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "v1 upload invalid order",
+			method:         http.MethodPost,
+			path:           "/api/user/orders",
+			contentType:    "text/plain",
+			body:           "12345",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "v1 upload order to unknown program",
+			method:         http.MethodPost,
+			path:           "/api/user/orders?program=" + unknownProgram,
+			contentType:    "text/plain",
+			body:           "79927398713",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "v1 get orders",
+			method:         http.MethodGet,
+			path:           "/api/user/orders",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 get orders anonymous",
+			method:         http.MethodGet,
+			path:           "/api/user/orders",
+			anonymous:      true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "v1 get balance",
+			method:         http.MethodGet,
+			path:           "/api/user/balance",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 withdraw",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/withdraw",
+			contentType:    "application/json",
+			body:           `{"order":"2377225624","sum":751}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 withdraw too much",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/withdraw",
+			contentType:    "application/json",
+			body:           `{"order":"2377225624","sum":1751}`,
+			expectedStatus: http.StatusPaymentRequired,
+		},
+		{
+			name:           "v1 withdraw from unknown program",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/withdraw",
+			contentType:    "application/json",
+			body:           `{"order":"2377225624","program":"` + unknownProgram + `","sum":1}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "v1 create hold",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/holds",
+			contentType:    "application/json",
+			body:           `{"order":"2377225624","sum":751,"ttl":600}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "v1 create hold too big",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/holds",
+			contentType:    "application/json",
+			body:           `{"order":"2377225624","sum":1751}`,
+			expectedStatus: http.StatusPaymentRequired,
+		},
+		{
+			name:           "v1 capture hold",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/holds/1/capture",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 capture closed hold",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/holds/2/capture",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "v1 release hold",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/holds/1/release",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 release unknown hold",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/holds/3/release",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "v1 transfer",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/transfer",
+			contentType:    "application/json",
+			body:           `{"recipient":"recipient","sum":751}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 transfer requiring accept",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/transfer",
+			contentType:    "application/json",
+			body:           `{"recipient":"recipient","sum":1,"require_accept":true}`,
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "v1 transfer to unknown recipient",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/transfer",
+			contentType:    "application/json",
+			body:           `{"recipient":"nobody","sum":1}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "v1 transfer to oneself",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/transfer",
+			contentType:    "application/json",
+			body:           `{"recipient":"user","sum":1}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "v1 transfer over limit",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/transfer",
+			contentType:    "application/json",
+			body:           `{"recipient":"recipient","sum":1751}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "v1 get transfers",
+			method:         http.MethodGet,
+			path:           "/api/user/transfers",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 accept transfer",
+			method:         http.MethodPost,
+			path:           "/api/user/transfers/1/accept",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 decline closed transfer",
+			method:         http.MethodPost,
+			path:           "/api/user/transfers/2/decline",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "v1 cancel transfer",
+			method:         http.MethodPost,
+			path:           "/api/user/transfers/1/cancel",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 cancel unknown transfer",
+			method:         http.MethodPost,
+			path:           "/api/user/transfers/3/cancel",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "v1 get withdrawals",
+			method:         http.MethodGet,
+			path:           "/api/user/withdrawals",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 get statement",
+			method:         http.MethodGet,
+			path:           "/api/user/statement?from=2020-12-01&to=2020-12-31&limit=10",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 get statement csv",
+			method:         http.MethodGet,
+			path:           "/api/user/statement?format=csv",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 get statement of unknown program",
+			method:         http.MethodGet,
+			path:           "/api/user/statement?program=" + unknownProgram,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "v1 get statement with inverted range",
+			method:         http.MethodGet,
+			path:           "/api/user/statement?from=2020-12-31&to=2020-12-01",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "v1 change password",
+			method:         http.MethodPost,
+			path:           "/api/user/password",
+			contentType:    "application/json",
+			body:           `{"old_password":"` + testPassword + `","new_password":"new"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 change password to empty",
+			method:         http.MethodPost,
+			path:           "/api/user/password",
+			contentType:    "application/json",
+			body:           `{"old_password":"` + testPassword + `","new_password":""}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "v1 change password with wrong old password",
+			method:         http.MethodPost,
+			path:           "/api/user/password",
+			contentType:    "application/json",
+			body:           `{"old_password":"wrong","new_password":"new"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "v1 change login",
+			method:         http.MethodPatch,
+			path:           "/api/user",
+			contentType:    "application/json",
+			body:           `{"login":"renamed"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v1 change login to taken",
+			method:         http.MethodPatch,
+			path:           "/api/user",
+			contentType:    "application/json",
+			body:           `{"login":"` + recipientLogin + `"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "v1 delete user",
+			method:         http.MethodDelete,
+			path:           "/api/user",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "v1 revoked session",
+			method:         http.MethodGet,
+			path:           "/api/user/orders",
+			revoked:        true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "v2 revoked session",
+			method:         http.MethodGet,
+			path:           "/api/v2/user/orders",
+			revoked:        true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "admin adjust accrual",
+			method:         http.MethodPost,
+			path:           "/api/admin/orders/" + registeredOrder + "/adjustments",
+			contentType:    "application/json",
+			body:           `{"accrual":500,"reason":"return"}`,
+			anonymous:      true,
+			admin:          true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "admin adjust accrual without token",
+			method:         http.MethodPost,
+			path:           "/api/admin/orders/" + registeredOrder + "/adjustments",
+			contentType:    "application/json",
+			body:           `{"accrual":500,"reason":"return"}`,
+			anonymous:      true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "admin adjust accrual of pending order",
+			method:         http.MethodPost,
+			path:           "/api/admin/orders/" + pendingOrder + "/adjustments",
+			contentType:    "application/json",
+			body:           `{"accrual":500,"reason":"return"}`,
+			anonymous:      true,
+			admin:          true,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "admin adjust accrual of unknown order",
+			method:         http.MethodPost,
+			path:           "/api/admin/orders/" + foreignOrder + "/adjustments",
+			contentType:    "application/json",
+			body:           `{"accrual":500,"reason":"return"}`,
+			anonymous:      true,
+			admin:          true,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "v2 register",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/register",
+			contentType:    "application/json",
+			body:           `{"login":"new","password":"p"}`,
+			anonymous:      true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 register with empty password",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/register",
+			contentType:    "application/json",
+			body:           `{"login":"new","password":""}`,
+			anonymous:      true,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "v2 register taken",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/register",
+			contentType:    "application/json",
+			body:           credentials,
+			anonymous:      true,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "v2 login",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/login",
+			contentType:    "application/json",
+			body:           credentials,
+			anonymous:      true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 login failed",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/login",
+			contentType:    "application/json",
+			body:           `{"login":"x","password":"y"}`,
+			anonymous:      true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "v2 upload order",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/orders",
+			contentType:    "application/json",
+			body:           `{"number":"79927398713"}`,
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "v2 upload own order",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/orders",
+			contentType:    "application/json",
+			body:           `{"number":"` + registeredOrder + `"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 upload foreign order",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/orders",
+			contentType:    "application/json",
+			body:           `{"number":"` + foreignOrder + `"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "v2 upload invalid order",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/orders",
+			contentType:    "application/json",
+			body:           `{"number":"12345"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "v2 upload order to program",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/orders",
+			contentType:    "application/json",
+			body:           `{"number":"79927398713","program":"partner"}`,
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "v2 upload order to unknown program",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/orders",
+			contentType:    "application/json",
+			body:           `{"number":"79927398713","program":"` + unknownProgram + `"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "v2 upload anonymous",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/orders",
+			contentType:    "application/json",
+			body:           `{"number":"79927398713"}`,
+			anonymous:      true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "v2 get orders",
+			method:         http.MethodGet,
+			path:           "/api/v2/user/orders",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 get balance",
+			method:         http.MethodGet,
+			path:           "/api/v2/user/balance",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 withdraw",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/balance/withdraw",
+			contentType:    "application/json",
+			body:           `{"order":"2377225624","sum":"751.5"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 withdraw too much",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/balance/withdraw",
+			contentType:    "application/json",
+			body:           `{"order":"2377225624","sum":"1751"}`,
+			expectedStatus: http.StatusPaymentRequired,
+		},
+		{
+			name:           "v2 withdraw from program",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/balance/withdraw",
+			contentType:    "application/json",
+			body:           `{"order":"2377225624","program":"partner","sum":"5"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 withdraw from unknown program",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/balance/withdraw",
+			contentType:    "application/json",
+			body:           `{"order":"2377225624","program":"` + unknownProgram + `","sum":"1"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "v2 get withdrawals",
+			method:         http.MethodGet,
+			path:           "/api/v2/user/withdrawals",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "spec",
+			method:         http.MethodGet,
+			path:           "/api/openapi.json",
+			anonymous:      true,
+			expectedStatus: http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			if test.contentType != "" {
+				request.Header.Set("Content-Type", test.contentType)
+			}
+			switch {
+			case test.revoked:
+				request.Header.Set("Authorization", "Bearer "+revokedToken)
+			case !test.anonymous:
+				request.Header.Set("Authorization", "Bearer "+token)
+			}
+			if test.admin {
+				request.Header.Set(middleware.AdminTokenHeader, testAdminToken)
+			}
+			route, pathParams, err := router.FindRoute(request)
+			require.NoError(t, err)
+			requestInput := &openapi3filter.RequestValidationInput{
+				Request:    request,
+				PathParams: pathParams,
+				Route:      route,
+				Options: &openapi3filter.Options{
+					AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				},
+			}
+			require.NoError(t, openapi3filter.ValidateRequest(context.Background(), requestInput))
+
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, request)
+			response := recorder.Result()
+			defer func() {
+				_ = response.Body.Close()
+			}()
+			assert.Equal(t, test.expectedStatus, response.StatusCode)
+			body, err := io.ReadAll(response.Body)
+			require.NoError(t, err)
+			responseInput := &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: requestInput,
+				Status:                 response.StatusCode,
+				Header:                 response.Header,
+				Body:                   io.NopCloser(bytes.NewReader(body)),
+				Options: &openapi3filter.Options{
+					IncludeResponseStatus: true,
+				},
+			}
+			assert.NoError(t, openapi3filter.ValidateResponse(context.Background(), responseInput), string(body))
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-market/internal/gophermart/data"
+	"go-market/pkg/logging"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrOrderNotProcessed = errors.New("order is not processed")
+)
+
+// NegativeBalancePolicy decides whether a clawback may take more points than the user has available.
+type NegativeBalancePolicy string
+
+const (
+	// DenyNegativeBalance rejects clawbacks exceeding the available balance.
+	DenyNegativeBalance = NegativeBalancePolicy("deny")
+	// AllowNegativeBalance claws back the whole delta, the balance stays negative until later credits cover it.
+	AllowNegativeBalance = NegativeBalancePolicy("allow")
+)
+
+type Adjustment struct {
+	CreatedAt       time.Time
+	OrderNumber     string
+	Program         string
+	Reason          string
+	PreviousAccrual decimal.Decimal
+	Accrual         decimal.Decimal
+	ID              int64
+}
+
+type AdjustmentsConfig struct {
+	NegativeBalancePolicy NegativeBalancePolicy
+	// PointsLifetimeMonths is the lifetime of points credited by upward corrections, zero means forever.
+	PointsLifetimeMonths int
+}
+
+type AdjustmentRepository interface {
+	GetOrderForUpdate(ctx context.Context, orderNumber string) (data.Order, error)
+	IncrementUserBalance(ctx context.Context, userID int, programID string, delta decimal.Decimal) (decimal.Decimal, error)
+	DebitUserBalanceIfSufficient(
+		ctx context.Context,
+		userID int,
+		programID string,
+		amount decimal.Decimal,
+	) (decimal.Decimal, error)
+	InsertPointLot(ctx context.Context, lot data.PointLot) error
+	ConsumePointLots(
+		ctx context.Context,
+		userID int,
+		programID string,
+		amount decimal.Decimal,
+		now time.Time,
+	) (decimal.Decimal, error)
+	SetOrderAdjustedAccrual(ctx context.Context, orderNumber string, accrual decimal.Decimal) error
+	InsertAccrualAdjustment(ctx context.Context, adjustment data.AccrualAdjustment) (int64, error)
+}
+
+// Adjustments applies corrections of accruals of processed orders issued after returns or by partners.
+type Adjustments struct {
+	transactionManager TransactionManager
+	repository         AdjustmentRepository
+	logger             *logging.ZapLogger
+	config             AdjustmentsConfig
+}
+
+func NewAdjustments(
+	config AdjustmentsConfig,
+	transactionManager TransactionManager,
+	repository AdjustmentRepository,
+	logger *logging.ZapLogger,
+) *Adjustments {
+	return &Adjustments{
+		config:             config,
+		transactionManager: transactionManager,
+		repository:         repository,
+		logger:             logger,
+	}
+}
+
+// AdjustAccrual replaces the accrual of a processed order with accrual and applies the difference
+// to the balance of the order program. Clawbacks exceeding the available balance fail with ErrNotEnoughBalance
+// unless the negative balance policy allows them.
+func (a *Adjustments) AdjustAccrual(
+	ctx context.Context,
+	orderNumber string,
+	accrual decimal.Decimal,
+	reason string,
+) (Adjustment, error) {
+	var adjustment data.AccrualAdjustment
+	err := a.transactionManager.DoWithTransaction(ctx, func(ctx context.Context) error {
+		order, err := a.repository.GetOrderForUpdate(ctx, orderNumber)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				return ErrOrderNotFound
+			default:
+				return fmt.Errorf("getting order failed: %w", err)
+			}
+		}
+		if order.Status != data.ProcessedStatus {
+			return ErrOrderNotProcessed
+		}
+		previous := order.Accrual
+		if order.AdjustedAccrual.Valid {
+			previous = order.AdjustedAccrual.Decimal
+		}
+		now := time.Now().UTC()
+		delta := accrual.Sub(previous)
+		switch {
+		case delta.IsPositive():
+			err = a.credit(ctx, order, delta, now)
+		case delta.IsNegative():
+			err = a.clawBack(ctx, order, delta.Neg())
+		}
+		if err != nil {
+			return err
+		}
+		err = a.repository.SetOrderAdjustedAccrual(ctx, orderNumber, accrual)
+		if err != nil {
+			return fmt.Errorf("setting adjusted accrual failed: %w", err)
+		}
+		adjustment = data.AccrualAdjustment{
+			CreatedAt:       now,
+			OrderNumber:     orderNumber,
+			ProgramID:       order.ProgramID,
+			Reason:          reason,
+			PreviousAccrual: previous,
+			Accrual:         accrual,
+			UserID:          order.UserID,
+		}
+		adjustment.ID, err = a.repository.InsertAccrualAdjustment(ctx, adjustment)
+		if err != nil {
+			return fmt.Errorf("inserting accrual adjustment failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return Adjustment{}, err //nolint:wrapcheck // unnecessary
+	}
+	a.logger.InfoCtx(
+		ctx,
+		"accrual adjusted",
+		zap.String("orderNumber", orderNumber),
+		zap.String("previousAccrual", adjustment.PreviousAccrual.String()),
+		zap.String("accrual", accrual.String()),
+		zap.String("reason", reason),
+	)
+	return Adjustment{
+		CreatedAt:       adjustment.CreatedAt,
+		OrderNumber:     adjustment.OrderNumber,
+		Program:         adjustment.ProgramID,
+		Reason:          adjustment.Reason,
+		PreviousAccrual: adjustment.PreviousAccrual,
+		Accrual:         adjustment.Accrual,
+		ID:              adjustment.ID,
+	}, nil
+}
+
+func (a *Adjustments) credit(ctx context.Context, order data.Order, amount decimal.Decimal, now time.Time) error {
+	_, err := a.repository.IncrementUserBalance(ctx, order.UserID, order.ProgramID, amount)
+	if err != nil {
+		return fmt.Errorf("incrementing user balance failed: %w", err)
+	}
+	lot := data.PointLot{
+		CreditedAt:  now,
+		OrderNumber: order.OrderNumber,
+		ProgramID:   order.ProgramID,
+		Amount:      amount,
+		UserID:      order.UserID,
+	}
+	if a.config.PointsLifetimeMonths > 0 {
+		lot.ExpiresAt = now.AddDate(0, a.config.PointsLifetimeMonths, 0)
+	}
+	err = a.repository.InsertPointLot(ctx, lot)
+	if err != nil {
+		return fmt.Errorf("inserting points lot failed: %w", err)
+	}
+	return nil
+}
+
+func (a *Adjustments) clawBack(ctx context.Context, order data.Order, amount decimal.Decimal) error {
+	var err error
+	switch a.config.NegativeBalancePolicy {
+	case AllowNegativeBalance:
+		_, err = a.repository.IncrementUserBalance(ctx, order.UserID, order.ProgramID, amount.Neg())
+	default:
+		_, err = a.repository.DebitUserBalanceIfSufficient(ctx, order.UserID, order.ProgramID, amount)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInsufficientBalance):
+			return ErrNotEnoughBalance
+		default:
+			return fmt.Errorf("debiting user balance failed: %w", err)
+		}
+	}
+	// expired lots not written off yet are taken as well, otherwise the expiry monitor would debit them again
+	_, err = a.repository.ConsumePointLots(ctx, order.UserID, order.ProgramID, amount, time.Time{})
+	if err != nil {
+		return fmt.Errorf("consuming points lots failed: %w", err)
+	}
+	return nil
+}
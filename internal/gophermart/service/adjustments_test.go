@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-market/internal/gophermart/data"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdjustments claws back more than the available balance and checks the next credit covers the debt first.
+func TestAdjustments(t *testing.T) {
+	repository, transactionManager, logger := newTestRepository(t)
+	wallet := NewWallet(WalletConfig{}, transactionManager, repository, logger)
+	orders := NewOrders(transactionManager, repository)
+	denying := NewAdjustments(
+		AdjustmentsConfig{NegativeBalancePolicy: DenyNegativeBalance},
+		transactionManager,
+		repository,
+		logger,
+	)
+	allowing := NewAdjustments(
+		AdjustmentsConfig{NegativeBalancePolicy: AllowNegativeBalance},
+		transactionManager,
+		repository,
+		logger,
+	)
+
+	ctx := context.Background()
+	userID, _ := newTestUser(t, repository)
+	orderNumber := newTestOrder(t, repository, userID, decimal.NewFromInt(100), time.Now().UTC())
+	creditTestPoints(t, repository, userID, orderNumber, decimal.NewFromInt(100))
+	err := wallet.Withdraw(ctx, userID, fmt.Sprintf("%d-withdrawal", userID), "", decimal.NewFromInt(60))
+	require.NoError(t, err)
+
+	_, err = denying.AdjustAccrual(ctx, orderNumber, decimal.Zero, "return")
+	require.ErrorIs(t, err, ErrNotEnoughBalance)
+	_, err = allowing.AdjustAccrual(ctx, orderNumber, decimal.Zero, "return")
+	require.NoError(t, err)
+	info, err := wallet.GetUserBalanceInfo(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(-60).Equal(info.Balance), "balance %s", info.Balance)
+
+	adjustment, err := allowing.AdjustAccrual(ctx, orderNumber, decimal.NewFromInt(80), "partner correction")
+	require.NoError(t, err)
+	assert.True(t, adjustment.PreviousAccrual.IsZero(), "previous accrual %s", adjustment.PreviousAccrual)
+	info, err = wallet.GetUserBalanceInfo(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(20).Equal(info.Balance), "balance %s", info.Balance)
+	// only the points left after covering the debt may be withdrawn
+	err = wallet.Withdraw(ctx, userID, fmt.Sprintf("%d-withdrawal-2", userID), "", decimal.NewFromInt(20))
+	require.NoError(t, err)
+
+	userOrders, err := orders.GetAllOrders(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, userOrders, 1)
+	assert.True(t, decimal.NewFromInt(100).Equal(userOrders[0].Accrual), "accrual %s", userOrders[0].Accrual)
+	assert.True(t, userOrders[0].AdjustedAccrual.Valid)
+	assert.True(t, decimal.NewFromInt(80).Equal(userOrders[0].AdjustedAccrual.Decimal))
+}
+
+type fakeAdjustmentRepository struct {
+	AdjustmentRepository
+	order           data.Order
+	balance         decimal.Decimal
+	adjustedAccrual decimal.Decimal
+	lots            []data.PointLot
+}
+
+func (r *fakeAdjustmentRepository) GetOrderForUpdate(context.Context, string) (data.Order, error) {
+	return r.order, nil
+}
+
+func (r *fakeAdjustmentRepository) IncrementUserBalance(
+	_ context.Context,
+	_ int,
+	_ string,
+	delta decimal.Decimal,
+) (decimal.Decimal, error) {
+	r.balance = r.balance.Add(delta)
+	return r.balance, nil
+}
+
+func (r *fakeAdjustmentRepository) DebitUserBalanceIfSufficient(
+	_ context.Context,
+	_ int,
+	_ string,
+	amount decimal.Decimal,
+) (decimal.Decimal, error) {
+	if r.balance.LessThan(amount) {
+		return decimal.Decimal{}, data.ErrInsufficientBalance
+	}
+	r.balance = r.balance.Sub(amount)
+	return r.balance, nil
+}
+
+func (r *fakeAdjustmentRepository) InsertPointLot(_ context.Context, lot data.PointLot) error {
+	r.lots = append(r.lots, lot)
+	return nil
+}
+
+func (r *fakeAdjustmentRepository) ConsumePointLots(
+	_ context.Context,
+	_ int,
+	_ string,
+	amount decimal.Decimal,
+	_ time.Time,
+) (decimal.Decimal, error) {
+	return amount, nil
+}
+
+func (r *fakeAdjustmentRepository) SetOrderAdjustedAccrual(_ context.Context, _ string, accrual decimal.Decimal) error {
+	r.adjustedAccrual = accrual
+	return nil
+}
+
+func (r *fakeAdjustmentRepository) InsertAccrualAdjustment(context.Context, data.AccrualAdjustment) (int64, error) {
+	return 1, nil
+}
+
+func TestAdjustAccrual(t *testing.T) {
+	processedOrder := data.Order{
+		OrderNumber: "12345678903",
+		ProgramID:   data.DefaultProgramID,
+		Status:      data.ProcessedStatus,
+		UserID:      1,
+		Accrual:     decimal.NewFromInt(100),
+	}
+	adjustedOrder := processedOrder
+	adjustedOrder.AdjustedAccrual = decimal.NullDecimal{Decimal: decimal.NewFromInt(60), Valid: true}
+	processingOrder := processedOrder
+	processingOrder.Status = data.ProcessingStatus
+
+	tests := []struct {
+		name             string
+		policy           NegativeBalancePolicy
+		order            data.Order
+		accrual          decimal.Decimal
+		expectedErr      error
+		expectedBalance  decimal.Decimal
+		expectedPrevious decimal.Decimal
+		expectedLots     int
+	}{
+		{
+			name:             "credit",
+			policy:           DenyNegativeBalance,
+			order:            processedOrder,
+			accrual:          decimal.NewFromInt(120),
+			expectedBalance:  decimal.NewFromInt(50),
+			expectedPrevious: decimal.NewFromInt(100),
+			expectedLots:     1,
+		},
+		{
+			name:             "clawback within the balance",
+			policy:           DenyNegativeBalance,
+			order:            processedOrder,
+			accrual:          decimal.NewFromInt(80),
+			expectedBalance:  decimal.NewFromInt(10),
+			expectedPrevious: decimal.NewFromInt(100),
+		},
+		{
+			name:            "clawback over the balance denied",
+			policy:          DenyNegativeBalance,
+			order:           processedOrder,
+			accrual:         decimal.NewFromInt(20),
+			expectedErr:     ErrNotEnoughBalance,
+			expectedBalance: decimal.NewFromInt(30),
+		},
+		{
+			name:             "clawback over the balance allowed",
+			policy:           AllowNegativeBalance,
+			order:            processedOrder,
+			accrual:          decimal.NewFromInt(20),
+			expectedBalance:  decimal.NewFromInt(-50),
+			expectedPrevious: decimal.NewFromInt(100),
+		},
+		{
+			name:             "clawback relative to the previous adjustment",
+			policy:           AllowNegativeBalance,
+			order:            adjustedOrder,
+			accrual:          decimal.NewFromInt(20),
+			expectedBalance:  decimal.NewFromInt(-10),
+			expectedPrevious: decimal.NewFromInt(60),
+		},
+		{
+			name:            "order not processed",
+			policy:          AllowNegativeBalance,
+			order:           processingOrder,
+			accrual:         decimal.NewFromInt(20),
+			expectedErr:     ErrOrderNotProcessed,
+			expectedBalance: decimal.NewFromInt(30),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := &fakeAdjustmentRepository{order: test.order, balance: decimal.NewFromInt(30)}
+			adjustments := NewAdjustments(
+				AdjustmentsConfig{NegativeBalancePolicy: test.policy},
+				fakeTransactionManager{},
+				repository,
+				newTestLogger(t),
+			)
+
+			adjustment, err := adjustments.AdjustAccrual(context.Background(), test.order.OrderNumber, test.accrual, "return")
+			assert.True(t, test.expectedBalance.Equal(repository.balance), "balance %s", repository.balance)
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, test.expectedPrevious.Equal(adjustment.PreviousAccrual))
+			assert.True(t, test.accrual.Equal(repository.adjustedAccrual))
+			assert.Len(t, repository.lots, test.expectedLots)
+		})
+	}
+}
@@ -6,45 +6,81 @@ import (
 	"fmt"
 	"go-market/internal/gophermart/data"
 	"strconv"
+	"strings"
+	"time"
 )
 
 var (
 	ErrLoginTaken         = errors.New("login is already taken")
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrSessionRevoked     = errors.New("session is revoked")
 )
 
 var (
 	UserIDClaimName = "user_id"
+	// SessionClaimName holds the session version the token was issued for, tokens without it belong to version 0.
+	SessionClaimName = "session"
 )
 
+// erasedLoginPrefix starts the logins of erased users, it is reserved so that erasure never clashes with a user.
+const erasedLoginPrefix = "deleted-"
+
 type UserRepository interface {
 	InsertUser(ctx context.Context, login, password string) (userID int, err error)
-	ValidateUser(ctx context.Context, login, password string) (userID int, err error)
+	ValidateUser(ctx context.Context, login, password string) (userID int, sessionVersion int, err error)
+	GetUserSessionVersion(ctx context.Context, userID int) (sessionVersion int, err error)
+	UpdateUserPassword(ctx context.Context, userID int, oldPassword, newPassword string) (sessionVersion int, err error)
+	UpdateUserLogin(ctx context.Context, userID int, login string) error
+	EraseUser(ctx context.Context, userID int, anonymizedLogin string, erasedAt time.Time) error
 }
 
 type TokenFactory interface {
 	Generate(extraClaims map[string]string) (string, error)
 }
 
+type AuthorizationConfig struct {
+	Credentials CredentialsPolicy
+}
+
 type Authorization struct {
 	userRepository     UserRepository
 	transactionManager TransactionManager
 	tokenFactory       TokenFactory
+	breachedPasswords  BreachedPasswords
+	config             AuthorizationConfig
 }
 
+// NewAuthorization creates the service, breachedPasswords may be nil to skip the breached password check.
 func NewAuthorization(
+	config AuthorizationConfig,
 	userRepository UserRepository,
 	transactionManager TransactionManager,
 	tokenFactory TokenFactory,
+	breachedPasswords BreachedPasswords,
 ) *Authorization {
 	return &Authorization{
+		config:             config,
 		userRepository:     userRepository,
 		transactionManager: transactionManager,
 		tokenFactory:       tokenFactory,
+		breachedPasswords:  breachedPasswords,
 	}
 }
 
+// Register creates a user, credentials violating the policy fail with ValidationError listing all violations.
 func (r *Authorization) Register(ctx context.Context, login string, password string) (string, error) {
+	fieldErrors := r.validateLogin(login)
+	passwordErrors, err := r.validatePassword(ctx, password)
+	if err != nil {
+		return "", err
+	}
+	fieldErrors = append(fieldErrors, passwordErrors...)
+	if len(fieldErrors) > 0 {
+		return "", &ValidationError{Errors: fieldErrors}
+	}
+	if strings.HasPrefix(login, erasedLoginPrefix) {
+		return "", ErrLoginTaken
+	}
 	userID, err := r.userRepository.InsertUser(ctx, login, password)
 	if err != nil {
 		switch {
@@ -55,19 +91,11 @@ func (r *Authorization) Register(ctx context.Context, login string, password str
 		}
 	}
 
-	payload := map[string]string{
-		UserIDClaimName: strconv.Itoa(userID),
-	}
-	token, err := r.tokenFactory.Generate(payload)
-	if err != nil {
-		return "", fmt.Errorf("error generating token: %w", err)
-	}
-
-	return token, nil
+	return r.generateToken(userID, 0)
 }
 
 func (r *Authorization) Login(ctx context.Context, login string, password string) (string, error) {
-	userID, err := r.userRepository.ValidateUser(ctx, login, password)
+	userID, sessionVersion, err := r.userRepository.ValidateUser(ctx, login, password)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrInvalidPassword):
@@ -79,8 +107,97 @@ func (r *Authorization) Login(ctx context.Context, login string, password string
 		}
 	}
 
+	return r.generateToken(userID, sessionVersion)
+}
+
+// ChangePassword replaces the password of the user if oldPassword matches and revokes all sessions of the user,
+// it returns a token of the new session. A new password violating the policy fails with ValidationError.
+func (r *Authorization) ChangePassword(
+	ctx context.Context,
+	userID int,
+	oldPassword string,
+	newPassword string,
+) (string, error) {
+	fieldErrors, err := r.validatePassword(ctx, newPassword)
+	if err != nil {
+		return "", err
+	}
+	if len(fieldErrors) > 0 {
+		return "", &ValidationError{Errors: fieldErrors}
+	}
+	sessionVersion, err := r.userRepository.UpdateUserPassword(ctx, userID, oldPassword, newPassword)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInvalidPassword):
+			return "", ErrInvalidCredentials
+		default:
+			return "", fmt.Errorf("error updating password: %w", err)
+		}
+	}
+
+	return r.generateToken(userID, sessionVersion)
+}
+
+// ChangeLogin renames the user, sessions stay valid as tokens refer to the user by id.
+func (r *Authorization) ChangeLogin(ctx context.Context, userID int, login string) error {
+	if fieldErrors := r.validateLogin(login); len(fieldErrors) > 0 {
+		return &ValidationError{Errors: fieldErrors}
+	}
+	if strings.HasPrefix(login, erasedLoginPrefix) {
+		return ErrLoginTaken
+	}
+	err := r.userRepository.UpdateUserLogin(ctx, userID, login)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrUniqueConstraintViolation):
+			return ErrLoginTaken
+		case errors.Is(err, data.ErrNotFound):
+			return ErrSessionRevoked
+		default:
+			return fmt.Errorf("error updating login: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteUser erases the personal data of the user and revokes all sessions, the login is anonymized
+// and the password dropped while orders, withdrawals and other financial rows are kept for accounting.
+func (r *Authorization) DeleteUser(ctx context.Context, userID int) error {
+	anonymizedLogin := erasedLoginPrefix + strconv.Itoa(userID)
+	err := r.userRepository.EraseUser(ctx, userID, anonymizedLogin, time.Now().UTC())
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrNotFound):
+			return ErrSessionRevoked
+		default:
+			return fmt.Errorf("error erasing user: %w", err)
+		}
+	}
+	return nil
+}
+
+// CheckSession returns ErrSessionRevoked if the password was changed or the user erased
+// after a token of sessionVersion was issued.
+func (r *Authorization) CheckSession(ctx context.Context, userID int, sessionVersion int) error {
+	current, err := r.userRepository.GetUserSessionVersion(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrNotFound):
+			return ErrSessionRevoked
+		default:
+			return fmt.Errorf("error getting session version: %w", err)
+		}
+	}
+	if current != sessionVersion {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+func (r *Authorization) generateToken(userID int, sessionVersion int) (string, error) {
 	payload := map[string]string{
-		UserIDClaimName: strconv.Itoa(userID),
+		UserIDClaimName:  strconv.Itoa(userID),
+		SessionClaimName: strconv.Itoa(sessionVersion),
 	}
 	token, err := r.tokenFactory.Generate(payload)
 	if err != nil {
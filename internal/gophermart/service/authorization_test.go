@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-market/internal/gophermart/data"
+	"go-market/pkg/jwtfactory"
+	"math/rand/v2"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAccountManagement changes the password, renames and erases a user and checks which sessions survive.
+func TestAccountManagement(t *testing.T) {
+	repository, transactionManager, _ := newTestRepository(t)
+	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	authorization := NewAuthorization(
+		AuthorizationConfig{},
+		repository,
+		transactionManager,
+		jwtfactory.New(tokenAuth, time.Hour),
+		nil,
+	)
+
+	ctx := context.Background()
+	login := fmt.Sprintf("account-%d", rand.Int32())
+	otherLogin := login + "-other"
+	_, err := authorization.Register(ctx, login, "password")
+	require.NoError(t, err)
+	_, err = authorization.Register(ctx, otherLogin, "password")
+	require.NoError(t, err)
+	userID, err := repository.GetUserIDByLogin(ctx, login)
+	require.NoError(t, err)
+
+	_, err = authorization.ChangePassword(ctx, userID, "wrong", "new-password")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	token, err := authorization.ChangePassword(ctx, userID, "password", "new-password")
+	require.NoError(t, err)
+	require.ErrorIs(t, authorization.CheckSession(ctx, userID, 0), ErrSessionRevoked)
+	decoded, err := tokenAuth.Decode(token)
+	require.NoError(t, err)
+	sessionClaim, ok := decoded.Get(SessionClaimName)
+	require.True(t, ok)
+	sessionVersion, err := strconv.Atoi(sessionClaim.(string))
+	require.NoError(t, err)
+	assert.NoError(t, authorization.CheckSession(ctx, userID, sessionVersion))
+	_, err = authorization.Login(ctx, login, "password")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	require.ErrorIs(t, authorization.ChangeLogin(ctx, userID, otherLogin), ErrLoginTaken)
+	renamed := login + "-renamed"
+	require.NoError(t, authorization.ChangeLogin(ctx, userID, renamed))
+	_, err = authorization.Login(ctx, renamed, "new-password")
+	require.NoError(t, err)
+	assert.NoError(t, authorization.CheckSession(ctx, userID, sessionVersion))
+
+	require.NoError(t, authorization.DeleteUser(ctx, userID))
+	require.ErrorIs(t, authorization.CheckSession(ctx, userID, sessionVersion+1), ErrSessionRevoked)
+	_, err = authorization.Login(ctx, renamed, "new-password")
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = authorization.Register(ctx, "deleted-"+strconv.Itoa(userID), "password")
+	require.ErrorIs(t, err, ErrLoginTaken)
+	// the erased login is free again
+	_, err = authorization.Register(ctx, renamed, "password")
+	require.NoError(t, err)
+}
+
+type fakeUserRepository struct {
+	UserRepository
+	sessionVersions map[int]int
+}
+
+func (r *fakeUserRepository) GetUserSessionVersion(_ context.Context, userID int) (int, error) {
+	sessionVersion, ok := r.sessionVersions[userID]
+	if !ok {
+		return 0, data.ErrNotFound
+	}
+	return sessionVersion, nil
+}
+
+func TestCheckSession(t *testing.T) {
+	authorization := NewAuthorization(
+		AuthorizationConfig{},
+		&fakeUserRepository{sessionVersions: map[int]int{1: 0, 2: 3}},
+		fakeTransactionManager{},
+		nil,
+		nil,
+	)
+	tests := []struct {
+		name           string
+		userID         int
+		sessionVersion int
+		expectedErr    error
+	}{
+		{
+			name:           "token without session claim",
+			userID:         1,
+			sessionVersion: 0,
+		},
+		{
+			name:           "current session",
+			userID:         2,
+			sessionVersion: 3,
+		},
+		{
+			name:           "session before password change",
+			userID:         2,
+			sessionVersion: 2,
+			expectedErr:    ErrSessionRevoked,
+		},
+		{
+			name:           "erased user",
+			userID:         3,
+			sessionVersion: 0,
+			expectedErr:    ErrSessionRevoked,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := authorization.CheckSession(context.Background(), test.userID, test.sessionVersion)
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec // breached password lists are keyed by SHA-1
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	LoginField    = "login"
+	PasswordField = "password"
+
+	// MaxLoginLength is the length of the login column, longer logins are rejected whatever the policy.
+	MaxLoginLength = 32
+
+	breachedPasswordPrefixLength = 5
+)
+
+// FieldError describes why a field of a request violates the credentials policy.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError lists all violations of the credentials policy found in a request.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldError := range e.Errors {
+		messages[i] = fieldError.Field + " " + fieldError.Message
+	}
+	return "invalid credentials: " + strings.Join(messages, ", ")
+}
+
+// CredentialsPolicy restricts logins and passwords, zero values disable the corresponding checks.
+// Empty logins and passwords are never accepted.
+type CredentialsPolicy struct {
+	// LoginPattern is a regular expression logins must match, e.g. ^[a-zA-Z0-9._-]+$.
+	LoginPattern      *regexp.Regexp
+	LoginMinLength    int
+	LoginMaxLength    int
+	PasswordMinLength int
+	// PasswordMinCharacterClasses is the number of classes out of lowercase letters, uppercase letters,
+	// digits and other characters a password must contain.
+	PasswordMinCharacterClasses int
+}
+
+// BreachedPasswords is a k-anonymity list of SHA-1 hashes of leaked passwords.
+type BreachedPasswords interface {
+	// Range returns uppercase hex suffixes of the hashes starting with the 5 character prefix.
+	Range(ctx context.Context, prefix string) ([]string, error)
+}
+
+func (r *Authorization) validateLogin(login string) []FieldError {
+	policy := r.config.Credentials
+	maxLength := MaxLoginLength
+	if policy.LoginMaxLength > 0 {
+		maxLength = min(policy.LoginMaxLength, MaxLoginLength)
+	}
+	length := utf8.RuneCountInString(login)
+	switch {
+	case length == 0:
+		return []FieldError{{Field: LoginField, Message: "is required"}}
+	case length < policy.LoginMinLength:
+		return []FieldError{{
+			Field:   LoginField,
+			Message: fmt.Sprintf("must be at least %d characters long", policy.LoginMinLength),
+		}}
+	case length > maxLength:
+		return []FieldError{{
+			Field:   LoginField,
+			Message: fmt.Sprintf("must be at most %d characters long", maxLength),
+		}}
+	case policy.LoginPattern != nil && !policy.LoginPattern.MatchString(login):
+		return []FieldError{{Field: LoginField, Message: "contains characters that are not allowed"}}
+	}
+	return nil
+}
+
+// validatePassword checks the password against the breached passwords only if it satisfies the rest of the policy.
+func (r *Authorization) validatePassword(ctx context.Context, password string) ([]FieldError, error) {
+	policy := r.config.Credentials
+	res := make([]FieldError, 0)
+	length := utf8.RuneCountInString(password)
+	switch {
+	case length == 0:
+		return []FieldError{{Field: PasswordField, Message: "is required"}}, nil
+	case length < policy.PasswordMinLength:
+		res = append(res, FieldError{
+			Field:   PasswordField,
+			Message: fmt.Sprintf("must be at least %d characters long", policy.PasswordMinLength),
+		})
+	}
+	if characterClasses(password) < policy.PasswordMinCharacterClasses {
+		res = append(res, FieldError{
+			Field: PasswordField,
+			Message: fmt.Sprintf(
+				"must contain at least %d of lowercase letters, uppercase letters, digits and other characters",
+				policy.PasswordMinCharacterClasses,
+			),
+		})
+	}
+	if len(res) > 0 || r.breachedPasswords == nil {
+		return res, nil
+	}
+	breached, err := r.isBreached(ctx, password)
+	if err != nil {
+		return nil, err
+	}
+	if breached {
+		res = append(res, FieldError{Field: PasswordField, Message: "appears in a data breach"})
+	}
+	return res, nil
+}
+
+// isBreached sends only a prefix of the password hash to the list.
+func (r *Authorization) isBreached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password)) //nolint:gosec // breached password lists are keyed by SHA-1
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes, err := r.breachedPasswords.Range(ctx, hash[:breachedPasswordPrefixLength])
+	if err != nil {
+		return false, fmt.Errorf("error checking breached passwords: %w", err)
+	}
+	return slices.Contains(suffixes, hash[breachedPasswordPrefixLength:]), nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	res := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			res++
+		}
+	}
+	return res
+}
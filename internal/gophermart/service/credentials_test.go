@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// breachedPasswordsStub lists "P@ssw0rd1" only.
+type breachedPasswordsStub struct{}
+
+func (breachedPasswordsStub) Range(_ context.Context, prefix string) ([]string, error) {
+	if prefix != "F2A12" {
+		return nil, nil
+	}
+	return []string{"0A3E9C1B7D5F2E8A4C6B9D1F3E5A7C9B2D4", "F187EBB7080BD75AAC9160214E6B1E49F7D"}, nil
+}
+
+func TestCredentialsPolicy(t *testing.T) {
+	authorization := NewAuthorization(
+		AuthorizationConfig{
+			Credentials: CredentialsPolicy{
+				LoginPattern:                regexp.MustCompile(`^[a-zA-Z0-9._-]+$`),
+				LoginMinLength:              3,
+				LoginMaxLength:              16,
+				PasswordMinLength:           8,
+				PasswordMinCharacterClasses: 3,
+			},
+		},
+		nil,
+		nil,
+		nil,
+		breachedPasswordsStub{},
+	)
+	tests := []struct {
+		name     string
+		login    string
+		password string
+		expected []FieldError
+	}{
+		{
+			name:     "empty",
+			expected: []FieldError{{LoginField, "is required"}, {PasswordField, "is required"}},
+		},
+		{
+			name:     "short",
+			login:    "ab",
+			password: "Ab1",
+			expected: []FieldError{
+				{LoginField, "must be at least 3 characters long"},
+				{PasswordField, "must be at least 8 characters long"},
+			},
+		},
+		{
+			name:     "long login",
+			login:    strings.Repeat("a", 17),
+			password: "Secret-password",
+			expected: []FieldError{{LoginField, "must be at most 16 characters long"}},
+		},
+		{
+			name:     "login charset",
+			login:    "user name",
+			password: "Secret-password",
+			expected: []FieldError{{LoginField, "contains characters that are not allowed"}},
+		},
+		{
+			name:     "simple password",
+			login:    "user",
+			password: "password",
+			expected: []FieldError{{
+				PasswordField,
+				"must contain at least 3 of lowercase letters, uppercase letters, digits and other characters",
+			}},
+		},
+		{
+			name:     "breached password",
+			login:    "user",
+			password: "P@ssw0rd1",
+			expected: []FieldError{{PasswordField, "appears in a data breach"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := authorization.Register(context.Background(), test.login, test.password)
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, test.expected, validationErr.Errors)
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-market/internal/gophermart/data"
+	"go-market/pkg/logging"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrHoldNotFound     = errors.New("hold not found")
+	ErrHoldNotActive    = errors.New("hold is not active")
+	ErrOrderAlreadyHeld = errors.New("order already has a hold or a withdrawal")
+	ErrInvalidHoldTTL   = errors.New("invalid hold ttl")
+)
+
+type Hold struct {
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	ClosedAt    time.Time
+	OrderNumber string
+	Program     string
+	Status      data.HoldStatus
+	Amount      decimal.Decimal
+	ID          int64
+}
+
+type HoldsConfig struct {
+	// DefaultTTL is the lifetime of holds created without a ttl.
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+}
+
+type HoldRepository interface {
+	ProgramExists(ctx context.Context, programID string) (bool, error)
+	ReserveUserBalanceIfSufficient(
+		ctx context.Context,
+		userID int,
+		programID string,
+		amount decimal.Decimal,
+	) (decimal.Decimal, error)
+	UnreserveUserBalance(ctx context.Context, userID int, programID string, amount decimal.Decimal) error
+	DebitUserBalanceIfSufficient(
+		ctx context.Context,
+		userID int,
+		programID string,
+		amount decimal.Decimal,
+	) (decimal.Decimal, error)
+	ConsumePointLots(
+		ctx context.Context,
+		userID int,
+		programID string,
+		amount decimal.Decimal,
+		now time.Time,
+	) (decimal.Decimal, error)
+	InsertWithdrawal(ctx context.Context, withdrawal data.Withdrawal) error
+	InsertHold(ctx context.Context, hold data.Hold) (int64, error)
+	GetHoldForUpdate(ctx context.Context, holdID int64) (data.Hold, error)
+	CloseHold(ctx context.Context, holdID int64, status data.HoldStatus, closedAt time.Time) error
+}
+
+// Holds reserves points for a later capture into a withdrawal. Held points are not available
+// for withdrawals and other holds, holds not captured or released before expiration are released automatically.
+type Holds struct {
+	transactionManager TransactionManager
+	repository         HoldRepository
+	logger             *logging.ZapLogger
+	config             HoldsConfig
+}
+
+func NewHolds(
+	config HoldsConfig,
+	transactionManager TransactionManager,
+	repository HoldRepository,
+	logger *logging.ZapLogger,
+) *Holds {
+	return &Holds{
+		config:             config,
+		transactionManager: transactionManager,
+		repository:         repository,
+		logger:             logger,
+	}
+}
+
+// CreateHold reserves amount in programID for ttl, empty programID and zero ttl mean the defaults.
+func (h *Holds) CreateHold(
+	ctx context.Context,
+	userID int,
+	orderNumber string,
+	programID string,
+	amount decimal.Decimal,
+	ttl time.Duration,
+) (Hold, error) {
+	if programID == "" {
+		programID = data.DefaultProgramID
+	}
+	if ttl == 0 {
+		ttl = h.config.DefaultTTL
+	}
+	if ttl < 0 || ttl > h.config.MaxTTL {
+		return Hold{}, fmt.Errorf("%w: must be positive and at most %s", ErrInvalidHoldTTL, h.config.MaxTTL)
+	}
+	exists, err := h.repository.ProgramExists(ctx, programID)
+	if err != nil {
+		return Hold{}, fmt.Errorf("checking program failed: %w", err)
+	}
+	if !exists {
+		return Hold{}, ErrUnknownProgram
+	}
+	now := time.Now().UTC()
+	hold := data.Hold{
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+		OrderNumber: orderNumber,
+		ProgramID:   programID,
+		Status:      data.ActiveHoldStatus,
+		Amount:      amount,
+		UserID:      userID,
+	}
+	err = h.transactionManager.DoWithTransaction(ctx, func(ctx context.Context) error {
+		_, err := h.repository.ReserveUserBalanceIfSufficient(ctx, userID, programID, amount)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrInsufficientBalance):
+				return ErrNotEnoughBalance
+			default:
+				return fmt.Errorf("reserving user balance failed: %w", err)
+			}
+		}
+		hold.ID, err = h.repository.InsertHold(ctx, hold)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrUniqueConstraintViolation):
+				return ErrOrderAlreadyHeld
+			default:
+				return fmt.Errorf("inserting hold failed: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Hold{}, err //nolint:wrapcheck // unnecessary
+	}
+	h.logger.DebugCtx(ctx, "hold created", zap.Int64("holdID", hold.ID), zap.String("amount", amount.String()))
+	return toHold(hold), nil
+}
+
+// CaptureHold turns an active hold into a withdrawal of the held amount.
+// Fails with ErrNotEnoughBalance when the held points expired in the meantime.
+func (h *Holds) CaptureHold(ctx context.Context, userID int, holdID int64) (Hold, error) {
+	var res Hold
+	err := h.transactionManager.DoWithTransaction(ctx, func(ctx context.Context) error {
+		hold, err := h.getActiveHold(ctx, userID, holdID)
+		if err != nil {
+			return err
+		}
+		err = h.repository.UnreserveUserBalance(ctx, userID, hold.ProgramID, hold.Amount)
+		if err != nil {
+			return fmt.Errorf("unreserving user balance failed: %w", err)
+		}
+		_, err = h.repository.DebitUserBalanceIfSufficient(ctx, userID, hold.ProgramID, hold.Amount)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrInsufficientBalance):
+				return ErrNotEnoughBalance
+			default:
+				return fmt.Errorf("debiting user balance failed: %w", err)
+			}
+		}
+		now := time.Now().UTC()
+		consumed, err := h.repository.ConsumePointLots(ctx, userID, hold.ProgramID, hold.Amount, now)
+		if err != nil {
+			return fmt.Errorf("consuming points lots failed: %w", err)
+		}
+		if !consumed.Equal(hold.Amount) {
+			return ErrNotEnoughBalance
+		}
+		err = h.repository.InsertWithdrawal(ctx, data.Withdrawal{
+			ProcessTime: now,
+			OrderNumber: hold.OrderNumber,
+			ProgramID:   hold.ProgramID,
+			Amount:      hold.Amount,
+			UserID:      userID,
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrUniqueConstraintViolation):
+				return ErrOrderAlreadyHeld
+			default:
+				return fmt.Errorf("inserting withdrawal failed: %w", err)
+			}
+		}
+		res, err = h.closeHold(ctx, hold, data.CapturedHoldStatus, now)
+		return err
+	})
+	if err != nil {
+		return Hold{}, err //nolint:wrapcheck // unnecessary
+	}
+	return res, nil
+}
+
+// ReleaseHold returns the held amount of an active hold to the available balance.
+func (h *Holds) ReleaseHold(ctx context.Context, userID int, holdID int64) (Hold, error) {
+	var res Hold
+	err := h.transactionManager.DoWithTransaction(ctx, func(ctx context.Context) error {
+		hold, err := h.getActiveHold(ctx, userID, holdID)
+		if err != nil {
+			return err
+		}
+		err = h.repository.UnreserveUserBalance(ctx, userID, hold.ProgramID, hold.Amount)
+		if err != nil {
+			return fmt.Errorf("unreserving user balance failed: %w", err)
+		}
+		res, err = h.closeHold(ctx, hold, data.ReleasedHoldStatus, time.Now().UTC())
+		return err
+	})
+	if err != nil {
+		return Hold{}, err //nolint:wrapcheck // unnecessary
+	}
+	return res, nil
+}
+
+// getActiveHold locks the hold, holds of other users are reported as not found.
+func (h *Holds) getActiveHold(ctx context.Context, userID int, holdID int64) (data.Hold, error) {
+	hold, err := h.repository.GetHoldForUpdate(ctx, holdID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrNotFound):
+			return data.Hold{}, ErrHoldNotFound
+		default:
+			return data.Hold{}, fmt.Errorf("getting hold failed: %w", err)
+		}
+	}
+	if hold.UserID != userID {
+		return data.Hold{}, ErrHoldNotFound
+	}
+	// expired holds may wait for the expiry monitor, they are closed already for the user
+	if hold.Status != data.ActiveHoldStatus || !time.Now().Before(hold.ExpiresAt) {
+		return data.Hold{}, ErrHoldNotActive
+	}
+	return hold, nil
+}
+
+func (h *Holds) closeHold(ctx context.Context, hold data.Hold, status data.HoldStatus, now time.Time) (Hold, error) {
+	err := h.repository.CloseHold(ctx, hold.ID, status, now)
+	if err != nil {
+		return Hold{}, fmt.Errorf("closing hold failed: %w", err)
+	}
+	hold.Status = status
+	hold.ClosedAt = now
+	h.logger.DebugCtx(ctx, "hold closed", zap.Int64("holdID", hold.ID), zap.String("status", string(status)))
+	return toHold(hold), nil
+}
+
+func toHold(hold data.Hold) Hold {
+	return Hold{
+		CreatedAt:   hold.CreatedAt,
+		ExpiresAt:   hold.ExpiresAt,
+		ClosedAt:    hold.ClosedAt,
+		OrderNumber: hold.OrderNumber,
+		Program:     hold.ProgramID,
+		Status:      hold.Status,
+		Amount:      hold.Amount,
+		ID:          hold.ID,
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-market/internal/gophermart/data"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHoldsLifecycle checks held points are unavailable until the hold is released or captured.
+func TestHoldsLifecycle(t *testing.T) {
+	repository, transactionManager, logger := newTestRepository(t)
+	wallet := NewWallet(WalletConfig{}, transactionManager, repository, logger)
+	holds := NewHolds(HoldsConfig{DefaultTTL: time.Minute, MaxTTL: time.Hour}, transactionManager, repository, logger)
+
+	ctx := context.Background()
+	userID, _ := newTestUserWithPoints(t, repository, decimal.NewFromInt(100))
+
+	released, err := holds.CreateHold(ctx, userID, fmt.Sprintf("%d-1", userID), "", decimal.NewFromInt(60), 0)
+	require.NoError(t, err)
+	_, err = holds.CreateHold(ctx, userID, fmt.Sprintf("%d-2", userID), "", decimal.NewFromInt(60), 0)
+	require.ErrorIs(t, err, ErrNotEnoughBalance)
+	err = wallet.Withdraw(ctx, userID, fmt.Sprintf("%d-3", userID), "", decimal.NewFromInt(60))
+	require.ErrorIs(t, err, ErrNotEnoughBalance)
+
+	info, err := wallet.GetUserBalanceInfo(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(40).Equal(info.Balance), "balance %s", info.Balance)
+	assert.True(t, decimal.NewFromInt(60).Equal(info.OnHold), "on hold %s", info.OnHold)
+
+	_, err = holds.ReleaseHold(ctx, userID, released.ID)
+	require.NoError(t, err)
+	_, err = holds.ReleaseHold(ctx, userID, released.ID)
+	require.ErrorIs(t, err, ErrHoldNotActive)
+
+	captured, err := holds.CreateHold(ctx, userID, fmt.Sprintf("%d-4", userID), "", decimal.NewFromInt(70), 0)
+	require.NoError(t, err)
+	_, err = holds.CaptureHold(ctx, userID+1, captured.ID)
+	require.ErrorIs(t, err, ErrHoldNotFound)
+	_, err = holds.CaptureHold(ctx, userID, captured.ID)
+	require.NoError(t, err)
+
+	info, err = wallet.GetUserBalanceInfo(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(30).Equal(info.Balance), "balance %s", info.Balance)
+	assert.True(t, info.OnHold.IsZero(), "on hold %s", info.OnHold)
+	assert.True(t, decimal.NewFromInt(70).Equal(info.Withdrawals), "withdrawals %s", info.Withdrawals)
+}
+
+type fakeHoldRepository struct {
+	HoldRepository
+	hold       data.Hold
+	unreserved decimal.Decimal
+	closed     data.HoldStatus
+}
+
+func (r *fakeHoldRepository) ProgramExists(context.Context, string) (bool, error) {
+	return true, nil
+}
+
+func (r *fakeHoldRepository) GetHoldForUpdate(_ context.Context, holdID int64) (data.Hold, error) {
+	if holdID != r.hold.ID {
+		return data.Hold{}, data.ErrNotFound
+	}
+	return r.hold, nil
+}
+
+func (r *fakeHoldRepository) UnreserveUserBalance(_ context.Context, _ int, _ string, amount decimal.Decimal) error {
+	r.unreserved = r.unreserved.Add(amount)
+	return nil
+}
+
+func (r *fakeHoldRepository) CloseHold(_ context.Context, _ int64, status data.HoldStatus, _ time.Time) error {
+	r.closed = status
+	return nil
+}
+
+func TestCreateHoldTTL(t *testing.T) {
+	holds := NewHolds(
+		HoldsConfig{DefaultTTL: time.Minute, MaxTTL: time.Hour},
+		fakeTransactionManager{},
+		&fakeHoldRepository{},
+		newTestLogger(t),
+	)
+	for _, ttl := range []time.Duration{-time.Second, time.Hour + time.Second} {
+		_, err := holds.CreateHold(context.Background(), 1, "12345678903", "", decimal.NewFromInt(10), ttl)
+		assert.ErrorIs(t, err, ErrInvalidHoldTTL, "ttl %s", ttl)
+	}
+}
+
+func TestReleaseHold(t *testing.T) {
+	const (
+		userID = 1
+		holdID = 7
+	)
+	activeHold := data.Hold{
+		ExpiresAt: time.Now().Add(time.Minute),
+		ProgramID: data.DefaultProgramID,
+		Status:    data.ActiveHoldStatus,
+		Amount:    decimal.NewFromInt(10),
+		UserID:    userID,
+		ID:        holdID,
+	}
+	expiredHold := activeHold
+	expiredHold.ExpiresAt = time.Now().Add(-time.Second)
+	capturedHold := activeHold
+	capturedHold.Status = data.CapturedHoldStatus
+	otherUserHold := activeHold
+	otherUserHold.UserID = userID + 1
+
+	tests := []struct {
+		name        string
+		hold        data.Hold
+		holdID      int64
+		expectedErr error
+	}{
+		{
+			name:   "released",
+			hold:   activeHold,
+			holdID: holdID,
+		},
+		{
+			name:        "unknown hold",
+			hold:        activeHold,
+			holdID:      holdID + 1,
+			expectedErr: ErrHoldNotFound,
+		},
+		{
+			name:        "hold of another user",
+			hold:        otherUserHold,
+			holdID:      holdID,
+			expectedErr: ErrHoldNotFound,
+		},
+		{
+			name:        "expired hold",
+			hold:        expiredHold,
+			holdID:      holdID,
+			expectedErr: ErrHoldNotActive,
+		},
+		{
+			name:        "captured hold",
+			hold:        capturedHold,
+			holdID:      holdID,
+			expectedErr: ErrHoldNotActive,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := &fakeHoldRepository{hold: test.hold}
+			holds := NewHolds(HoldsConfig{}, fakeTransactionManager{}, repository, newTestLogger(t))
+
+			hold, err := holds.ReleaseHold(context.Background(), userID, test.holdID)
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+				assert.True(t, repository.unreserved.IsZero())
+				assert.Empty(t, repository.closed)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, data.ReleasedHoldStatus, hold.Status)
+			assert.Equal(t, data.ReleasedHoldStatus, repository.closed)
+			assert.True(t, test.hold.Amount.Equal(repository.unreserved))
+		})
+	}
+}
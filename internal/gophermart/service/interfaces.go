@@ -1,7 +1,10 @@
 package service
 
-import "context"
+import (
+	"context"
+	"go-market/pkg/pgxstorage"
+)
 
 type TransactionManager interface {
-	DoWithTransaction(ctx context.Context, f func(ctx context.Context) error) error
+	DoWithTransaction(ctx context.Context, f func(ctx context.Context) error, opts ...pgxstorage.TxOption) error
 }
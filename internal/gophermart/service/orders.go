@@ -14,13 +14,17 @@ import (
 var (
 	ErrOrderRegisteredByAnotherUser = errors.New("order is already registered by another user")
 	ErrOrderRegistered              = errors.New("order is already registered")
+	ErrUnknownProgram               = errors.New("unknown loyalty program")
 )
 
 type Order struct {
 	UploadedAt time.Time
 	Number     string
+	Program    string
 	Status     clientprotocol.OrderStatus
 	Accrual    decimal.Decimal
+	// AdjustedAccrual is the corrected accrual of a processed order, invalid if never corrected.
+	AdjustedAccrual decimal.NullDecimal
 }
 
 type Orders struct {
@@ -41,13 +45,18 @@ func NewOrders(transactionManager TransactionManager, orderRepository OrderRepos
 	}
 }
 
-func (o *Orders) RegisterOrder(ctx context.Context, userID int, orderNumber string) error {
+// RegisterOrder registers the order for accrual in programID, empty programID means the default program.
+func (o *Orders) RegisterOrder(ctx context.Context, userID int, orderNumber string, programID string) error {
+	if programID == "" {
+		programID = data.DefaultProgramID
+	}
 	order := &data.Order{
 		UserID:      userID,
 		OrderNumber: orderNumber,
+		ProgramID:   programID,
 		Status:      data.NewStatus,
 		Accrual:     decimal.Zero,
-		UploadTime:  time.Now(),
+		UploadTime:  time.Now().UTC(),
 	}
 	err := o.orderRepository.InsertOrder(ctx, order)
 	if err != nil {
@@ -61,6 +70,8 @@ func (o *Orders) RegisterOrder(ctx context.Context, userID int, orderNumber stri
 				return ErrOrderRegistered
 			}
 			return ErrOrderRegisteredByAnotherUser
+		case errors.Is(err, data.ErrForeignKeyViolation):
+			return ErrUnknownProgram
 		default:
 			return fmt.Errorf("error inserting order: %w", err)
 		}
@@ -81,6 +92,7 @@ func (o *Orders) GetAllOrders(ctx context.Context, userID int) ([]Order, error)
 		}
 		res[i] = Order{
 			Number:     order.OrderNumber,
+			Program:    order.ProgramID,
 			Status:     protocolStatus,
 			Accrual:    order.Accrual,
 			UploadedAt: order.UploadTime,
@@ -95,7 +107,7 @@ func convert(status data.Status) (clientprotocol.OrderStatus, error) {
 		return clientprotocol.New, nil
 	case data.InvalidStatus:
 		return clientprotocol.Invalid, nil
-	case data.ProcessingStatus:
+	case data.ProcessingStatus, data.StuckStatus:
 		return clientprotocol.Processing, nil
 	case data.ProcessedStatus:
 		return clientprotocol.Processed, nil
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-market/internal/gophermart/data"
+	"go-market/internal/gophermart/data/database"
+	"go-market/internal/gophermart/data/dbrepository"
+	"go-market/pkg/logging"
+	"go-market/pkg/pgxstorage"
+	"go-market/pkg/timeutils"
+	"math/rand/v2"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+const testDatabaseURIEnv = "TEST_DATABASE_URI"
+
+// newTestRepository connects to the database from TEST_DATABASE_URI, the test is skipped when it is not set.
+func newTestRepository(
+	t *testing.T,
+) (*dbrepository.DBRepository, *pgxstorage.TransactionsManager, *logging.ZapLogger) {
+	t.Helper()
+
+	return newTestRepositoryWithRetryPolicy(t, timeutils.RetryPolicy{MaxAttempts: 10})
+}
+
+// newTestRepositoryWithRetryPolicy is newTestRepository with transactions retried by transactionRetryPolicy.
+func newTestRepositoryWithRetryPolicy(
+	t *testing.T,
+	transactionRetryPolicy timeutils.RetryPolicy,
+) (*dbrepository.DBRepository, *pgxstorage.TransactionsManager, *logging.ZapLogger) {
+	t.Helper()
+
+	dsn, ok := os.LookupEnv(testDatabaseURIEnv)
+	if !ok {
+		t.Skipf("%s is not set", testDatabaseURIEnv)
+	}
+	logger := newTestLogger(t)
+	retryPolicy := timeutils.RetryPolicy{MaxAttempts: 1}
+	storage, err := pgxstorage.New(
+		database.NewPgxDatabaseFactory(database.Config{ConnectionString: dsn, RetryPolicy: retryPolicy}),
+		retryPolicy,
+		pgxstorage.ReplicaConfig{},
+	)
+	require.NoError(t, err)
+	t.Cleanup(storage.Close)
+	return dbrepository.New(storage, logger),
+		pgxstorage.NewTransactionsManager(storage, transactionRetryPolicy),
+		logger
+}
+
+// newTestUser inserts a user with a random login.
+func newTestUser(t *testing.T, repository *dbrepository.DBRepository) (userID int, login string) {
+	t.Helper()
+
+	login = fmt.Sprintf("test-%d", rand.Int32())
+	userID, err := repository.InsertUser(context.Background(), login, "password")
+	require.NoError(t, err)
+	return userID, login
+}
+
+// newTestUserWithPoints inserts a user with a random login and amount points in the default program.
+func newTestUserWithPoints(
+	t *testing.T,
+	repository *dbrepository.DBRepository,
+	amount decimal.Decimal,
+) (userID int, login string) {
+	t.Helper()
+
+	userID, login = newTestUser(t, repository)
+	creditTestPoints(t, repository, userID, "", amount)
+	return userID, login
+}
+
+// newTestOrder inserts a processed order of the user with accrual in the default program, the user is not credited.
+func newTestOrder(
+	t *testing.T,
+	repository *dbrepository.DBRepository,
+	userID int,
+	accrual decimal.Decimal,
+	uploadTime time.Time,
+) (orderNumber string) {
+	t.Helper()
+
+	orderNumber = fmt.Sprintf("%d-order-%d", userID, rand.Int32())
+	err := repository.InsertOrder(context.Background(), &data.Order{
+		OrderNumber: orderNumber,
+		ProgramID:   data.DefaultProgramID,
+		Status:      data.ProcessedStatus,
+		UserID:      userID,
+		Accrual:     accrual,
+		UploadTime:  uploadTime,
+	})
+	require.NoError(t, err)
+	return orderNumber
+}
+
+// creditTestPoints credits the user with a lot of amount points in the default program,
+// the lot refers to the order with orderNumber unless it is empty.
+func creditTestPoints(
+	t *testing.T,
+	repository *dbrepository.DBRepository,
+	userID int,
+	orderNumber string,
+	amount decimal.Decimal,
+) {
+	t.Helper()
+
+	ctx := context.Background()
+	_, err := repository.IncrementUserBalance(ctx, userID, data.DefaultProgramID, amount)
+	require.NoError(t, err)
+	err = repository.InsertPointLot(ctx, data.PointLot{
+		CreditedAt:  time.Now().UTC(),
+		OrderNumber: orderNumber,
+		ProgramID:   data.DefaultProgramID,
+		Amount:      amount,
+		UserID:      userID,
+	})
+	require.NoError(t, err)
+}
+
+func newTestLogger(t *testing.T) *logging.ZapLogger {
+	t.Helper()
+
+	logger, err := logging.NewZapLogger(zapcore.ErrorLevel)
+	require.NoError(t, err)
+	return logger
+}
+
+// fakeTransactionManager runs the function in place, for tests against fake repositories.
+type fakeTransactionManager struct{}
+
+func (fakeTransactionManager) DoWithTransaction(
+	ctx context.Context,
+	f func(ctx context.Context) error,
+	_ ...pgxstorage.TxOption,
+) error {
+	return f(ctx)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-market/internal/gophermart/data"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidStatementRange = errors.New("statement range end is not after its start")
+)
+
+type StatementEntry struct {
+	OccurredAt time.Time
+	Kind       data.StatementEntryKind
+	Reference  string
+	Amount     decimal.Decimal
+	Balance    decimal.Decimal
+}
+
+// StatementFilter selects entries of Program from From inclusive to To exclusive, empty Program means
+// the default program, zero From or To leave the range open and zero Limit means no limit.
+type StatementFilter struct {
+	From    time.Time
+	To      time.Time
+	Program string
+	Limit   int
+	Offset  int
+}
+
+type StatementRepository interface {
+	ProgramExists(ctx context.Context, programID string) (bool, error)
+	StreamUserStatement(
+		ctx context.Context,
+		userID int,
+		filter data.StatementFilter,
+		yield func(entry data.StatementEntry) error,
+	) error
+}
+
+// Statement lists accruals, adjustments, withdrawals, expirations and transfers of a program
+// in one chronological list with the balance after every entry.
+type Statement struct {
+	repository StatementRepository
+}
+
+func NewStatement(repository StatementRepository) *Statement {
+	return &Statement{
+		repository: repository,
+	}
+}
+
+// StreamStatement passes the entries to yield the oldest first without keeping them in memory.
+func (s *Statement) StreamStatement(
+	ctx context.Context,
+	userID int,
+	filter StatementFilter,
+	yield func(entry StatementEntry) error,
+) error {
+	if filter.Program == "" {
+		filter.Program = data.DefaultProgramID
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.To.After(filter.From) {
+		return ErrInvalidStatementRange
+	}
+	exists, err := s.repository.ProgramExists(ctx, filter.Program)
+	if err != nil {
+		return fmt.Errorf("checking program failed: %w", err)
+	}
+	if !exists {
+		return ErrUnknownProgram
+	}
+	dataFilter := data.StatementFilter{
+		From:      filter.From,
+		To:        filter.To,
+		ProgramID: filter.Program,
+		Limit:     filter.Limit,
+		Offset:    filter.Offset,
+	}
+	err = s.repository.StreamUserStatement(ctx, userID, dataFilter, func(entry data.StatementEntry) error {
+		return yield(StatementEntry{
+			OccurredAt: entry.OccurredAt,
+			Kind:       entry.Kind,
+			Reference:  entry.Reference,
+			Amount:     entry.Amount,
+			Balance:    entry.Balance,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("streaming user statement failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-market/internal/gophermart/data"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStatement checks the running balance is kept across pages.
+func TestStatement(t *testing.T) {
+	repository, transactionManager, logger := newTestRepository(t)
+	wallet := NewWallet(WalletConfig{}, transactionManager, repository, logger)
+	statement := NewStatement(repository)
+
+	ctx := context.Background()
+	userID, _ := newTestUser(t, repository)
+	orderNumber := newTestOrder(t, repository, userID, decimal.NewFromInt(100), time.Now().UTC().Add(-time.Minute))
+	creditTestPoints(t, repository, userID, orderNumber, decimal.NewFromInt(100))
+	withdrawalOrder := fmt.Sprintf("%d-withdrawal", userID)
+	err := wallet.Withdraw(ctx, userID, withdrawalOrder, "", decimal.NewFromInt(30))
+	require.NoError(t, err)
+
+	var entries []StatementEntry
+	collect := func(entry StatementEntry) error {
+		entries = append(entries, entry)
+		return nil
+	}
+	err = statement.StreamStatement(ctx, userID, StatementFilter{}, collect)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, data.AccrualStatementEntry, entries[0].Kind)
+	assert.Equal(t, orderNumber, entries[0].Reference)
+	assert.True(t, decimal.NewFromInt(100).Equal(entries[0].Balance), "balance %s", entries[0].Balance)
+	assert.Equal(t, data.WithdrawalStatementEntry, entries[1].Kind)
+	assert.True(t, decimal.NewFromInt(-30).Equal(entries[1].Amount), "amount %s", entries[1].Amount)
+	assert.True(t, decimal.NewFromInt(70).Equal(entries[1].Balance), "balance %s", entries[1].Balance)
+
+	entries = nil
+	err = statement.StreamStatement(ctx, userID, StatementFilter{Limit: 1, Offset: 1}, collect)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, withdrawalOrder, entries[0].Reference)
+	assert.True(t, decimal.NewFromInt(70).Equal(entries[0].Balance), "balance %s", entries[0].Balance)
+
+	err = statement.StreamStatement(ctx, userID, StatementFilter{Program: "unknown"}, collect)
+	require.ErrorIs(t, err, ErrUnknownProgram)
+}
+
+// TestStatementAccrualDate checks an accrual is dated by its crediting, so a withdrawal made between the upload
+// and the processing of an order comes before the accrual of the order.
+func TestStatementAccrualDate(t *testing.T) {
+	repository, transactionManager, logger := newTestRepository(t)
+	wallet := NewWallet(WalletConfig{}, transactionManager, repository, logger)
+	statement := NewStatement(repository)
+
+	ctx := context.Background()
+	userID, _ := newTestUser(t, repository)
+	creditedOrder := newTestOrder(t, repository, userID, decimal.NewFromInt(100), time.Now().UTC().Add(-2*time.Hour))
+	creditTestPoints(t, repository, userID, creditedOrder, decimal.NewFromInt(100))
+	pendingOrder := newTestOrder(t, repository, userID, decimal.NewFromInt(50), time.Now().UTC().Add(-time.Hour))
+	withdrawalOrder := fmt.Sprintf("%d-withdrawal", userID)
+	err := wallet.Withdraw(ctx, userID, withdrawalOrder, "", decimal.NewFromInt(80))
+	require.NoError(t, err)
+	creditTestPoints(t, repository, userID, pendingOrder, decimal.NewFromInt(50))
+
+	var entries []StatementEntry
+	err = statement.StreamStatement(ctx, userID, StatementFilter{}, func(entry StatementEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	expected := []struct {
+		reference string
+		balance   decimal.Decimal
+	}{
+		{reference: creditedOrder, balance: decimal.NewFromInt(100)},
+		{reference: withdrawalOrder, balance: decimal.NewFromInt(20)},
+		{reference: pendingOrder, balance: decimal.NewFromInt(70)},
+	}
+	for i, entry := range entries {
+		assert.Equal(t, expected[i].reference, entry.Reference)
+		assert.True(t, expected[i].balance.Equal(entry.Balance), "balance %s", entry.Balance)
+	}
+}
+
+type fakeStatementRepository struct {
+	StatementRepository
+	filter data.StatementFilter
+}
+
+func (r *fakeStatementRepository) ProgramExists(_ context.Context, programID string) (bool, error) {
+	return programID == data.DefaultProgramID, nil
+}
+
+func (r *fakeStatementRepository) StreamUserStatement(
+	_ context.Context,
+	_ int,
+	filter data.StatementFilter,
+	_ func(entry data.StatementEntry) error,
+) error {
+	r.filter = filter
+	return nil
+}
+
+func TestStreamStatementFilter(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		filter      StatementFilter
+		expectedErr error
+	}{
+		{
+			name:   "default program",
+			filter: StatementFilter{From: from, To: from.AddDate(0, 1, 0), Limit: 10, Offset: 20},
+		},
+		{
+			name:   "open range",
+			filter: StatementFilter{From: from},
+		},
+		{
+			name:        "empty range",
+			filter:      StatementFilter{From: from, To: from},
+			expectedErr: ErrInvalidStatementRange,
+		},
+		{
+			name:        "unknown program",
+			filter:      StatementFilter{Program: "unknown"},
+			expectedErr: ErrUnknownProgram,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := &fakeStatementRepository{}
+			statement := NewStatement(repository)
+
+			err := statement.StreamStatement(context.Background(), 1, test.filter, func(StatementEntry) error {
+				return nil
+			})
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, data.StatementFilter{
+				From:      test.filter.From,
+				To:        test.filter.To,
+				ProgramID: data.DefaultProgramID,
+				Limit:     test.filter.Limit,
+				Offset:    test.filter.Offset,
+			}, repository.filter)
+		})
+	}
+}
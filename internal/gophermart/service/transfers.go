@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-market/internal/gophermart/data"
+	"go-market/pkg/logging"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRecipientNotFound     = errors.New("recipient not found")
+	ErrSelfTransfer          = errors.New("transfer to oneself")
+	ErrTransferLimitExceeded = errors.New("daily transfer limit exceeded")
+	ErrTransferNotFound      = errors.New("transfer not found")
+	ErrTransferNotPending    = errors.New("transfer is not pending")
+)
+
+type TransferDirection string
+
+const (
+	IncomingTransfer = TransferDirection("in")
+	OutgoingTransfer = TransferDirection("out")
+)
+
+// Transfer is a transfer as seen by one of its sides, Counterparty is the login of the other side.
+type Transfer struct {
+	CreatedAt    time.Time
+	ClosedAt     time.Time
+	Direction    TransferDirection
+	Counterparty string
+	Program      string
+	Status       data.TransferStatus
+	Amount       decimal.Decimal
+	ID           int64
+}
+
+type TransfersConfig struct {
+	// DailyLimit is the amount a user may send per program within a UTC day, zero means no limit.
+	DailyLimit decimal.Decimal
+}
+
+type TransferRepository interface {
+	ProgramExists(ctx context.Context, programID string) (bool, error)
+	GetUserIDByLogin(ctx context.Context, login string) (int, error)
+	LockUsers(ctx context.Context, userIDs ...int) error
+	GetUserTransfersSum(ctx context.Context, userID int, programID string, since time.Time) (decimal.Decimal, error)
+	ReserveUserBalanceIfSufficient(
+		ctx context.Context,
+		userID int,
+		programID string,
+		amount decimal.Decimal,
+	) (decimal.Decimal, error)
+	UnreserveUserBalance(ctx context.Context, userID int, programID string, amount decimal.Decimal) error
+	DebitUserBalanceIfSufficient(
+		ctx context.Context,
+		userID int,
+		programID string,
+		amount decimal.Decimal,
+	) (decimal.Decimal, error)
+	IncrementUserBalance(ctx context.Context, userID int, programID string, delta decimal.Decimal) (decimal.Decimal, error)
+	MovePointLots(
+		ctx context.Context,
+		senderID int,
+		recipientID int,
+		programID string,
+		amount decimal.Decimal,
+		now time.Time,
+	) (decimal.Decimal, error)
+	InsertTransfer(ctx context.Context, transfer data.Transfer) (int64, error)
+	GetTransferForUpdate(ctx context.Context, transferID int64) (data.Transfer, error)
+	CloseTransfer(ctx context.Context, transferID int64, status data.TransferStatus, closedAt time.Time) error
+	GetAllUserTransfers(ctx context.Context, userID int) ([]data.Transfer, error)
+}
+
+// Transfers moves points between users. A transfer requiring the recipient to accept it keeps the points
+// on hold of the sender until the recipient accepts or declines it or the sender cancels it.
+type Transfers struct {
+	transactionManager TransactionManager
+	repository         TransferRepository
+	logger             *logging.ZapLogger
+	config             TransfersConfig
+}
+
+func NewTransfers(
+	config TransfersConfig,
+	transactionManager TransactionManager,
+	repository TransferRepository,
+	logger *logging.ZapLogger,
+) *Transfers {
+	return &Transfers{
+		config:             config,
+		transactionManager: transactionManager,
+		repository:         repository,
+		logger:             logger,
+	}
+}
+
+// Transfer sends amount in programID to the user with recipientLogin, empty programID means the default program.
+// With requireAccept the transfer stays pending until the recipient accepts it.
+func (t *Transfers) Transfer(
+	ctx context.Context,
+	senderID int,
+	recipientLogin string,
+	programID string,
+	amount decimal.Decimal,
+	requireAccept bool,
+) (Transfer, error) {
+	if programID == "" {
+		programID = data.DefaultProgramID
+	}
+	exists, err := t.repository.ProgramExists(ctx, programID)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("checking program failed: %w", err)
+	}
+	if !exists {
+		return Transfer{}, ErrUnknownProgram
+	}
+	recipientID, err := t.getRecipientID(ctx, recipientLogin)
+	if err != nil {
+		return Transfer{}, err
+	}
+	if recipientID == senderID {
+		return Transfer{}, ErrSelfTransfer
+	}
+	transfer := data.Transfer{
+		RecipientLogin: recipientLogin,
+		ProgramID:      programID,
+		Amount:         amount,
+		SenderID:       senderID,
+		RecipientID:    recipientID,
+	}
+	err = t.transactionManager.DoWithTransaction(ctx, func(ctx context.Context) error {
+		// both users are locked first, so concurrent transfers between them wait instead of deadlocking
+		// and transfers of the sender are counted against the limit one at a time
+		err := t.repository.LockUsers(ctx, senderID, recipientID)
+		if err != nil {
+			return fmt.Errorf("locking users failed: %w", err)
+		}
+		// the recipient is looked up again under the lock, it may have been deleted or renamed since the first lookup
+		lockedRecipientID, err := t.getRecipientID(ctx, recipientLogin)
+		if err != nil {
+			return err
+		}
+		if lockedRecipientID != recipientID {
+			return ErrRecipientNotFound
+		}
+		now := time.Now().UTC()
+		if err := t.checkDailyLimit(ctx, senderID, programID, amount, now); err != nil {
+			return err
+		}
+		transfer.CreatedAt = now
+		if requireAccept {
+			_, err = t.repository.ReserveUserBalanceIfSufficient(ctx, senderID, programID, amount)
+			if err != nil {
+				switch {
+				case errors.Is(err, data.ErrInsufficientBalance):
+					return ErrNotEnoughBalance
+				default:
+					return fmt.Errorf("reserving user balance failed: %w", err)
+				}
+			}
+			transfer.Status = data.PendingTransferStatus
+		} else {
+			if err := t.movePoints(ctx, transfer, now); err != nil {
+				return err
+			}
+			transfer.Status = data.CompletedTransferStatus
+			transfer.ClosedAt = now
+		}
+		transfer.ID, err = t.repository.InsertTransfer(ctx, transfer)
+		if err != nil {
+			return fmt.Errorf("inserting transfer failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return Transfer{}, err //nolint:wrapcheck // unnecessary
+	}
+	t.logger.DebugCtx(
+		ctx,
+		"transfer created",
+		zap.Int64("transferID", transfer.ID),
+		zap.String("status", string(transfer.Status)),
+		zap.String("amount", amount.String()),
+	)
+	return toTransfer(transfer, senderID), nil
+}
+
+// AcceptTransfer moves the points of a pending transfer to the recipient.
+// Fails with ErrNotEnoughBalance when the transferred points expired in the meantime.
+func (t *Transfers) AcceptTransfer(ctx context.Context, userID int, transferID int64) (Transfer, error) {
+	return t.closeTransfer(ctx, userID, transferID, data.CompletedTransferStatus)
+}
+
+// DeclineTransfer returns the points of a pending transfer to the sender on behalf of the recipient.
+func (t *Transfers) DeclineTransfer(ctx context.Context, userID int, transferID int64) (Transfer, error) {
+	return t.closeTransfer(ctx, userID, transferID, data.DeclinedTransferStatus)
+}
+
+// CancelTransfer returns the points of a pending transfer to the sender on behalf of the sender.
+func (t *Transfers) CancelTransfer(ctx context.Context, userID int, transferID int64) (Transfer, error) {
+	return t.closeTransfer(ctx, userID, transferID, data.CancelledTransferStatus)
+}
+
+func (t *Transfers) GetAllUserTransfers(ctx context.Context, userID int) ([]Transfer, error) {
+	transfers, err := t.repository.GetAllUserTransfers(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting user transfers failed: %w", err)
+	}
+	res := make([]Transfer, len(transfers))
+	for i, transfer := range transfers {
+		res[i] = toTransfer(transfer, userID)
+	}
+	return res, nil
+}
+
+// closeTransfer closes a pending transfer with status, only the sender may cancel
+// and only the recipient may accept or decline it.
+func (t *Transfers) closeTransfer(
+	ctx context.Context,
+	userID int,
+	transferID int64,
+	status data.TransferStatus,
+) (Transfer, error) {
+	var res Transfer
+	err := t.transactionManager.DoWithTransaction(ctx, func(ctx context.Context) error {
+		transfer, err := t.repository.GetTransferForUpdate(ctx, transferID)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrNotFound):
+				return ErrTransferNotFound
+			default:
+				return fmt.Errorf("getting transfer failed: %w", err)
+			}
+		}
+		allowedUserID := transfer.RecipientID
+		if status == data.CancelledTransferStatus {
+			allowedUserID = transfer.SenderID
+		}
+		if userID != allowedUserID {
+			return ErrTransferNotFound
+		}
+		if transfer.Status != data.PendingTransferStatus {
+			return ErrTransferNotPending
+		}
+		err = t.repository.LockUsers(ctx, transfer.SenderID, transfer.RecipientID)
+		if err != nil {
+			return fmt.Errorf("locking users failed: %w", err)
+		}
+		err = t.repository.UnreserveUserBalance(ctx, transfer.SenderID, transfer.ProgramID, transfer.Amount)
+		if err != nil {
+			return fmt.Errorf("unreserving user balance failed: %w", err)
+		}
+		now := time.Now().UTC()
+		if status == data.CompletedTransferStatus {
+			if err := t.movePoints(ctx, transfer, now); err != nil {
+				return err
+			}
+		}
+		err = t.repository.CloseTransfer(ctx, transfer.ID, status, now)
+		if err != nil {
+			return fmt.Errorf("closing transfer failed: %w", err)
+		}
+		transfer.Status = status
+		transfer.ClosedAt = now
+		res = toTransfer(transfer, userID)
+		return nil
+	})
+	if err != nil {
+		return Transfer{}, err //nolint:wrapcheck // unnecessary
+	}
+	t.logger.DebugCtx(ctx, "transfer closed", zap.Int64("transferID", transferID), zap.String("status", string(status)))
+	return res, nil
+}
+
+func (t *Transfers) getRecipientID(ctx context.Context, recipientLogin string) (int, error) {
+	recipientID, err := t.repository.GetUserIDByLogin(ctx, recipientLogin)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrNotFound):
+			return 0, ErrRecipientNotFound
+		default:
+			return 0, fmt.Errorf("getting recipient failed: %w", err)
+		}
+	}
+	return recipientID, nil
+}
+
+func (t *Transfers) checkDailyLimit(
+	ctx context.Context,
+	senderID int,
+	programID string,
+	amount decimal.Decimal,
+	now time.Time,
+) error {
+	if t.config.DailyLimit.IsZero() {
+		return nil
+	}
+	dayStart := now.Truncate(24 * time.Hour)
+	sent, err := t.repository.GetUserTransfersSum(ctx, senderID, programID, dayStart)
+	if err != nil {
+		return fmt.Errorf("getting user transfers sum failed: %w", err)
+	}
+	if sent.Add(amount).GreaterThan(t.config.DailyLimit) {
+		return ErrTransferLimitExceeded
+	}
+	return nil
+}
+
+// movePoints debits the sender and credits the recipient with the oldest sender lots.
+func (t *Transfers) movePoints(ctx context.Context, transfer data.Transfer, now time.Time) error {
+	_, err := t.repository.DebitUserBalanceIfSufficient(ctx, transfer.SenderID, transfer.ProgramID, transfer.Amount)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrInsufficientBalance):
+			return ErrNotEnoughBalance
+		default:
+			return fmt.Errorf("debiting user balance failed: %w", err)
+		}
+	}
+	// the recipient is credited before the lots are moved, so that they cover a negative recipient balance
+	_, err = t.repository.IncrementUserBalance(ctx, transfer.RecipientID, transfer.ProgramID, transfer.Amount)
+	if err != nil {
+		return fmt.Errorf("crediting recipient balance failed: %w", err)
+	}
+	moved, err := t.repository.MovePointLots(
+		ctx,
+		transfer.SenderID,
+		transfer.RecipientID,
+		transfer.ProgramID,
+		transfer.Amount,
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("moving points lots failed: %w", err)
+	}
+	if !moved.Equal(transfer.Amount) {
+		return ErrNotEnoughBalance
+	}
+	return nil
+}
+
+func toTransfer(transfer data.Transfer, userID int) Transfer {
+	res := Transfer{
+		CreatedAt: transfer.CreatedAt,
+		ClosedAt:  transfer.ClosedAt,
+		Program:   transfer.ProgramID,
+		Status:    transfer.Status,
+		Amount:    transfer.Amount,
+		ID:        transfer.ID,
+	}
+	if transfer.SenderID == userID {
+		res.Direction = OutgoingTransfer
+		res.Counterparty = transfer.RecipientLogin
+	} else {
+		res.Direction = IncomingTransfer
+		res.Counterparty = transfer.SenderLogin
+	}
+	return res
+}
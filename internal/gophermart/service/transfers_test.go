@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"go-market/internal/gophermart/data"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTransfers sends points both ways at once and checks no points are lost and the limit holds.
+func TestTransfers(t *testing.T) {
+	repository, transactionManager, logger := newTestRepository(t)
+	wallet := NewWallet(WalletConfig{}, transactionManager, repository, logger)
+	transfers := NewTransfers(
+		TransfersConfig{DailyLimit: decimal.NewFromInt(100)},
+		transactionManager,
+		repository,
+		logger,
+	)
+
+	ctx := context.Background()
+	logins := make([]string, 2)
+	userIDs := make([]int, 2)
+	for i := range userIDs {
+		userIDs[i], logins[i] = newTestUserWithPoints(t, repository, decimal.NewFromInt(100))
+	}
+
+	wg := &sync.WaitGroup{}
+	for range 5 {
+		for i := range userIDs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := transfers.Transfer(ctx, userIDs[i], logins[1-i], "", decimal.NewFromInt(10), false)
+				assert.NoError(t, err)
+			}()
+		}
+	}
+	wg.Wait()
+
+	_, err := transfers.Transfer(ctx, userIDs[0], logins[0], "", decimal.NewFromInt(1), false)
+	require.ErrorIs(t, err, ErrSelfTransfer)
+	pending, err := transfers.Transfer(ctx, userIDs[0], logins[1], "", decimal.NewFromInt(30), true)
+	require.NoError(t, err)
+	_, err = transfers.Transfer(ctx, userIDs[0], logins[1], "", decimal.NewFromInt(30), false)
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+	_, err = transfers.AcceptTransfer(ctx, userIDs[0], pending.ID)
+	require.ErrorIs(t, err, ErrTransferNotFound)
+	_, err = transfers.AcceptTransfer(ctx, userIDs[1], pending.ID)
+	require.NoError(t, err)
+	_, err = transfers.CancelTransfer(ctx, userIDs[0], pending.ID)
+	require.ErrorIs(t, err, ErrTransferNotPending)
+
+	expected := []decimal.Decimal{decimal.NewFromInt(70), decimal.NewFromInt(130)}
+	for i, userID := range userIDs {
+		info, err := wallet.GetUserBalanceInfo(ctx, userID)
+		require.NoError(t, err)
+		assert.True(t, expected[i].Equal(info.Balance), "balance %s", info.Balance)
+	}
+	history, err := transfers.GetAllUserTransfers(ctx, userIDs[1])
+	require.NoError(t, err)
+	assert.Len(t, history, 11)
+}
+
+type fakeTransferRepository struct {
+	TransferRepository
+	since time.Time
+	sent  decimal.Decimal
+	// recipientIDs are the answers to the recipient lookups in order, zero means the recipient is not found
+	recipientIDs []int
+	inserted     bool
+}
+
+func (r *fakeTransferRepository) ProgramExists(context.Context, string) (bool, error) {
+	return true, nil
+}
+
+func (r *fakeTransferRepository) GetUserIDByLogin(context.Context, string) (int, error) {
+	recipientID := r.recipientIDs[0]
+	r.recipientIDs = r.recipientIDs[1:]
+	if recipientID == 0 {
+		return 0, data.ErrNotFound
+	}
+	return recipientID, nil
+}
+
+func (r *fakeTransferRepository) LockUsers(context.Context, ...int) error {
+	return nil
+}
+
+func (r *fakeTransferRepository) InsertTransfer(context.Context, data.Transfer) (int64, error) {
+	r.inserted = true
+	return 1, nil
+}
+
+func (r *fakeTransferRepository) GetUserTransfersSum(
+	_ context.Context,
+	_ int,
+	_ string,
+	since time.Time,
+) (decimal.Decimal, error) {
+	r.since = since
+	return r.sent, nil
+}
+
+func TestCheckDailyLimit(t *testing.T) {
+	now := time.Date(2024, 5, 17, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name        string
+		limit       decimal.Decimal
+		sent        decimal.Decimal
+		amount      decimal.Decimal
+		expectedErr error
+	}{
+		{
+			name:   "no limit",
+			limit:  decimal.Zero,
+			sent:   decimal.NewFromInt(1000),
+			amount: decimal.NewFromInt(1000),
+		},
+		{
+			name:   "below the limit",
+			limit:  decimal.NewFromInt(100),
+			sent:   decimal.NewFromInt(30),
+			amount: decimal.NewFromInt(50),
+		},
+		{
+			name:   "up to the limit",
+			limit:  decimal.NewFromInt(100),
+			sent:   decimal.NewFromInt(30),
+			amount: decimal.NewFromInt(70),
+		},
+		{
+			name:        "over the limit",
+			limit:       decimal.NewFromInt(100),
+			sent:        decimal.NewFromInt(30),
+			amount:      decimal.RequireFromString("70.01"),
+			expectedErr: ErrTransferLimitExceeded,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := &fakeTransferRepository{sent: test.sent}
+			transfers := NewTransfers(
+				TransfersConfig{DailyLimit: test.limit},
+				fakeTransactionManager{},
+				repository,
+				newTestLogger(t),
+			)
+
+			err := transfers.checkDailyLimit(context.Background(), 1, data.DefaultProgramID, test.amount, now)
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			if !test.limit.IsZero() {
+				assert.Equal(t, time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC), repository.since)
+			}
+		})
+	}
+}
+
+// TestTransferRecipientChangedBeforeLock checks the recipient found before the transaction is confirmed under the lock.
+func TestTransferRecipientChangedBeforeLock(t *testing.T) {
+	tests := []struct {
+		name         string
+		recipientIDs []int
+	}{
+		{
+			name:         "recipient deleted",
+			recipientIDs: []int{2, 0},
+		},
+		{
+			name:         "login taken by another user",
+			recipientIDs: []int{2, 3},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := &fakeTransferRepository{recipientIDs: test.recipientIDs}
+			transfers := NewTransfers(TransfersConfig{}, fakeTransactionManager{}, repository, newTestLogger(t))
+
+			_, err := transfers.Transfer(context.Background(), 1, "recipient", "", decimal.NewFromInt(10), true)
+			require.ErrorIs(t, err, ErrRecipientNotFound)
+			assert.Empty(t, repository.recipientIDs)
+			assert.False(t, repository.inserted)
+		})
+	}
+}
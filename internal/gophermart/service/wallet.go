@@ -16,33 +16,81 @@ var (
 	ErrNotEnoughBalance = errors.New("not enough balance")
 )
 
+// BalanceInfo holds the default program totals and every program the user has in Programs.
+// Balance is the available amount, points reserved by holds are reported in OnHold.
 type BalanceInfo struct {
+	ExpiringAt  time.Time
 	Balance     decimal.Decimal
+	OnHold      decimal.Decimal
 	Withdrawals decimal.Decimal
+	Expiring    decimal.Decimal
+	Programs    []ProgramBalance
+}
+
+// ProgramBalance holds totals of one program, Expiring points expire within WalletConfig.ExpiryNotice,
+// the first of them at ExpiringAt.
+type ProgramBalance struct {
+	ExpiringAt  time.Time
+	Program     string
+	Name        string
+	Balance     decimal.Decimal
+	OnHold      decimal.Decimal
+	Withdrawals decimal.Decimal
+	Expiring    decimal.Decimal
 }
 
 type Withdrawal struct {
 	ProcessTime time.Time
 	OrderNumber string
+	Program     string
 	Amount      decimal.Decimal
 }
 
 type BalanceRepository interface {
-	GetUserBalance(ctx context.Context, userID int) (balance decimal.Decimal, err error)
-	SetUserBalance(ctx context.Context, userID int, value decimal.Decimal) error
-	GetTotalUserWithdraw(ctx context.Context, userID int) (value decimal.Decimal, err error)
+	GetUserBalances(
+		ctx context.Context,
+		userID int,
+		requiredProgramID string,
+		expiringBefore time.Time,
+	) ([]data.ProgramBalance, error)
+	ProgramExists(ctx context.Context, programID string) (bool, error)
+	DebitUserBalanceIfSufficient(
+		ctx context.Context,
+		userID int,
+		programID string,
+		amount decimal.Decimal,
+	) (decimal.Decimal, error)
+	ConsumePointLots(
+		ctx context.Context,
+		userID int,
+		programID string,
+		amount decimal.Decimal,
+		now time.Time,
+	) (decimal.Decimal, error)
 	InsertWithdrawal(ctx context.Context, withdrawal data.Withdrawal) error
 	GetAllUserWithdrawals(ctx context.Context, userID int) ([]data.Withdrawal, error)
 }
 
+type WalletConfig struct {
+	// ExpiryNotice is how long before expiration points are reported as expiring.
+	ExpiryNotice time.Duration
+}
+
 type Wallet struct {
 	transactionManager TransactionManager
 	repository         BalanceRepository
 	logger             *logging.ZapLogger
+	config             WalletConfig
 }
 
-func NewWallet(transactionManager TransactionManager, repository BalanceRepository, logger *logging.ZapLogger) *Wallet {
+func NewWallet(
+	config WalletConfig,
+	transactionManager TransactionManager,
+	repository BalanceRepository,
+	logger *logging.ZapLogger,
+) *Wallet {
 	return &Wallet{
+		config:             config,
 		transactionManager: transactionManager,
 		repository:         repository,
 		logger:             logger,
@@ -50,53 +98,87 @@ func NewWallet(transactionManager TransactionManager, repository BalanceReposito
 }
 
 func (w *Wallet) GetUserBalanceInfo(ctx context.Context, userID int) (BalanceInfo, error) {
-	res := BalanceInfo{}
-	err := w.transactionManager.DoWithTransaction(ctx, func(ctx context.Context) error {
-		balance, err := w.repository.GetUserBalance(ctx, userID)
-		if err != nil {
-			return fmt.Errorf("getting user balance failed: %w", err)
+	expiringBefore := time.Now().UTC().Add(w.config.ExpiryNotice)
+	balances, err := w.repository.GetUserBalances(ctx, userID, data.DefaultProgramID, expiringBefore)
+	if err != nil {
+		return BalanceInfo{}, fmt.Errorf("getting user balances failed: %w", err)
+	}
+	res := BalanceInfo{
+		Programs: make([]ProgramBalance, len(balances)),
+	}
+	for i, balance := range balances {
+		res.Programs[i] = ProgramBalance{
+			Program:     balance.ProgramID,
+			Name:        balance.ProgramName,
+			Balance:     balance.Balance.Sub(balance.OnHold),
+			OnHold:      balance.OnHold,
+			Withdrawals: balance.Withdrawals,
+			Expiring:    balance.Expiring,
+			ExpiringAt:  balance.ExpiringAt,
 		}
-		res.Balance = balance
-		withdrawals, err := w.repository.GetTotalUserWithdraw(ctx, userID)
-		if err != nil {
-			return fmt.Errorf("getting total user withdrawals failed: %w", err)
+		if balance.ProgramID == data.DefaultProgramID {
+			res.Balance = res.Programs[i].Balance
+			res.OnHold = balance.OnHold
+			res.Withdrawals = balance.Withdrawals
+			res.Expiring = balance.Expiring
+			res.ExpiringAt = balance.ExpiringAt
 		}
-		res.Withdrawals = withdrawals
-		return nil
-	})
-	if err != nil {
-		return BalanceInfo{}, err //nolint:wrapcheck // unnecessary
 	}
 	return res, nil
 }
 
-func (w *Wallet) Withdraw(ctx context.Context, userID int, orderNumber string, amount decimal.Decimal) error {
+// Withdraw debits amount from the user balance in programID, empty programID means the default program.
+func (w *Wallet) Withdraw(
+	ctx context.Context,
+	userID int,
+	orderNumber string,
+	programID string,
+	amount decimal.Decimal,
+) error {
+	if programID == "" {
+		programID = data.DefaultProgramID
+	}
 	w.logger.DebugCtx(
 		ctx,
 		"withdraw",
 		zap.Int("userID", userID),
 		zap.String("orderNumber", orderNumber),
+		zap.String("program", programID),
 		zap.String("amount", amount.String()),
 	)
+	exists, err := w.repository.ProgramExists(ctx, programID)
+	if err != nil {
+		return fmt.Errorf("checking program failed: %w", err)
+	}
+	if !exists {
+		return ErrUnknownProgram
+	}
 	//nolint:wrapcheck // wrapping unnecessary
 	return w.transactionManager.DoWithTransaction(ctx, func(ctx context.Context) error {
-		balance, err := w.repository.GetUserBalance(ctx, userID)
+		_, err := w.repository.DebitUserBalanceIfSufficient(ctx, userID, programID, amount)
 		if err != nil {
-			return fmt.Errorf("getting user balance failed: %w", err)
-		}
-		if balance.LessThan(amount) {
-			return ErrNotEnoughBalance
+			switch {
+			case errors.Is(err, data.ErrInsufficientBalance):
+				return ErrNotEnoughBalance
+			default:
+				return fmt.Errorf("debiting user balance failed: %w", err)
+			}
 		}
-		newBalance := balance.Sub(amount)
-		err = w.repository.SetUserBalance(ctx, userID, newBalance)
+		now := time.Now().UTC()
+		consumed, err := w.repository.ConsumePointLots(ctx, userID, programID, amount, now)
 		if err != nil {
-			return fmt.Errorf("setting user balance failed: %w", err)
+			return fmt.Errorf("consuming points lots failed: %w", err)
+		}
+		// the balance may still hold points of lots expired but not yet written off
+		if !consumed.Equal(amount) {
+			return ErrNotEnoughBalance
 		}
 		err = w.repository.InsertWithdrawal(ctx, data.Withdrawal{
 			OrderNumber: orderNumber,
+			ProgramID:   programID,
 			Amount:      amount,
 			UserID:      userID,
-			ProcessTime: time.Now(),
+			ProcessTime: now,
 		})
 		if err != nil {
 			return fmt.Errorf("inserting withdrawal failed: %w", err)
@@ -114,6 +196,7 @@ func (w *Wallet) GetAllUserWithdrawals(ctx context.Context, userID int) ([]Withd
 	for i, withdrawal := range withdrawals {
 		res[i] = Withdrawal{
 			OrderNumber: withdrawal.OrderNumber,
+			Program:     withdrawal.ProgramID,
 			Amount:      withdrawal.Amount,
 			ProcessTime: withdrawal.ProcessTime,
 		}
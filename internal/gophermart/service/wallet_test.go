@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-market/internal/gophermart/data"
+	"go-market/pkg/timeutils"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWalletWithdrawConcurrently hammers one balance with parallel withdrawals and checks it never goes negative.
+func TestWalletWithdrawConcurrently(t *testing.T) {
+	repository, transactionManager, logger := newTestRepositoryWithRetryPolicy(t, timeutils.RetryPolicy{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     50 * time.Millisecond,
+		Multiplier:      2,
+		Jitter:          timeutils.FullJitter,
+		MaxAttempts:     100,
+	})
+	wallet := NewWallet(WalletConfig{}, transactionManager, repository, logger)
+
+	ctx := context.Background()
+	userID, _ := newTestUser(t, repository)
+	for range 2 {
+		creditTestPoints(t, repository, userID, "", decimal.NewFromInt(50))
+	}
+
+	const withdrawalsCount = 50
+	amount := decimal.NewFromInt(10)
+	succeeded := &atomic.Int32{}
+	rejected := &atomic.Int32{}
+	wg := &sync.WaitGroup{}
+	for i := range withdrawalsCount {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := wallet.Withdraw(ctx, userID, fmt.Sprintf("%d-%d", userID, i), "", amount)
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case errors.Is(err, ErrNotEnoughBalance):
+				rejected.Add(1)
+			default:
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(10), succeeded.Load())
+	assert.Equal(t, int32(withdrawalsCount-10), rejected.Load())
+
+	info, err := wallet.GetUserBalanceInfo(ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, info.Balance.IsZero(), "balance %s", info.Balance)
+	assert.True(t, decimal.NewFromInt(100).Equal(info.Withdrawals), "withdrawals %s", info.Withdrawals)
+}
+
+type fakeBalanceRepository struct {
+	BalanceRepository
+	programExists bool
+	debitErr      error
+	consumed      decimal.Decimal
+	withdrawals   []data.Withdrawal
+}
+
+func (r *fakeBalanceRepository) ProgramExists(context.Context, string) (bool, error) {
+	return r.programExists, nil
+}
+
+func (r *fakeBalanceRepository) DebitUserBalanceIfSufficient(
+	_ context.Context,
+	_ int,
+	_ string,
+	amount decimal.Decimal,
+) (decimal.Decimal, error) {
+	return amount, r.debitErr
+}
+
+func (r *fakeBalanceRepository) ConsumePointLots(
+	context.Context,
+	int,
+	string,
+	decimal.Decimal,
+	time.Time,
+) (decimal.Decimal, error) {
+	return r.consumed, nil
+}
+
+func (r *fakeBalanceRepository) InsertWithdrawal(_ context.Context, withdrawal data.Withdrawal) error {
+	r.withdrawals = append(r.withdrawals, withdrawal)
+	return nil
+}
+
+func TestWalletWithdraw(t *testing.T) {
+	amount := decimal.NewFromInt(10)
+	tests := []struct {
+		name        string
+		repository  *fakeBalanceRepository
+		programID   string
+		expectedErr error
+	}{
+		{
+			name:       "withdrawn from default program",
+			repository: &fakeBalanceRepository{programExists: true, consumed: amount},
+		},
+		{
+			name:        "unknown program",
+			repository:  &fakeBalanceRepository{},
+			programID:   "unknown",
+			expectedErr: ErrUnknownProgram,
+		},
+		{
+			name: "insufficient balance",
+			repository: &fakeBalanceRepository{
+				programExists: true,
+				debitErr:      data.ErrInsufficientBalance,
+			},
+			expectedErr: ErrNotEnoughBalance,
+		},
+		{
+			name:        "point lots expired",
+			repository:  &fakeBalanceRepository{programExists: true, consumed: decimal.NewFromInt(4)},
+			expectedErr: ErrNotEnoughBalance,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wallet := NewWallet(WalletConfig{}, fakeTransactionManager{}, test.repository, newTestLogger(t))
+
+			err := wallet.Withdraw(context.Background(), 1, "12345678903", test.programID, amount)
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+				assert.Empty(t, test.repository.withdrawals)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, test.repository.withdrawals, 1)
+			assert.Equal(t, data.DefaultProgramID, test.repository.withdrawals[0].ProgramID)
+			assert.True(t, amount.Equal(test.repository.withdrawals[0].Amount))
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package aimd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+)
+
+type Outcome int
+
+const (
+	// Success grows the limit additively.
+	Success Outcome = iota
+	// Overload shrinks the limit multiplicatively.
+	Overload
+	// Ignored leaves the limit as is.
+	Ignored
+)
+
+type Config struct {
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	// BackoffRatio is the multiplier applied to the limit on overload, in range (0, 1).
+	BackoffRatio float64
+}
+
+// Limiter is a concurrency limiter with additive-increase/multiplicative-decrease limit adjustment.
+type Limiter struct {
+	released chan struct{}
+	mux      *sync.Mutex
+	cfg      Config
+	limit    float64
+	inFlight int
+}
+
+func New(cfg Config) *Limiter {
+	cfg.MinLimit = max(cfg.MinLimit, 1)
+	cfg.MaxLimit = max(cfg.MaxLimit, cfg.MinLimit)
+	return &Limiter{
+		cfg:      cfg,
+		limit:    float64(min(max(cfg.InitialLimit, cfg.MinLimit), cfg.MaxLimit)),
+		released: make(chan struct{}),
+		mux:      &sync.Mutex{},
+	}
+}
+
+// Acquire blocks until the number of requests in flight drops below the current limit.
+func (l *Limiter) Acquire(ctx context.Context) error {
+	for {
+		l.mux.Lock()
+		if l.inFlight < l.currentLimit() {
+			l.inFlight++
+			l.mux.Unlock()
+			return nil
+		}
+		released := l.released
+		l.mux.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("acquire canceled: %w", ctx.Err())
+		case <-released:
+		}
+	}
+}
+
+// Release returns the slot taken by Acquire and adjusts the limit according to the request outcome.
+func (l *Limiter) Release(outcome Outcome) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	l.inFlight--
+	switch outcome {
+	case Success:
+		l.limit = math.Min(l.limit+1/l.limit, float64(l.cfg.MaxLimit))
+	case Overload:
+		l.limit = math.Max(l.limit*l.cfg.BackoffRatio, float64(l.cfg.MinLimit))
+	case Ignored:
+	}
+
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+func (l *Limiter) Limit() int {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.currentLimit()
+}
+
+func (l *Limiter) InFlight() int {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.inFlight
+}
+
+func (l *Limiter) currentLimit() int {
+	return int(l.limit)
+}
@@ -0,0 +1,107 @@
+package aimd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterRelease(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           Config
+		outcomes      []Outcome
+		expectedLimit int
+	}{
+		{
+			name:          "initial limit clamped to min",
+			cfg:           Config{InitialLimit: 0, MaxLimit: 10},
+			expectedLimit: 1,
+		},
+		{
+			name:          "initial limit clamped to max",
+			cfg:           Config{InitialLimit: 20, MinLimit: 1, MaxLimit: 10},
+			expectedLimit: 10,
+		},
+		{
+			name:          "additive increase",
+			cfg:           Config{InitialLimit: 1, MinLimit: 1, MaxLimit: 10},
+			outcomes:      []Outcome{Success},
+			expectedLimit: 2,
+		},
+		{
+			// 1 -> 2 -> 2.5 -> 2.9 -> 3.24, about one step per limit successes
+			name:          "additive increase per window",
+			cfg:           Config{InitialLimit: 1, MinLimit: 1, MaxLimit: 10},
+			outcomes:      []Outcome{Success, Success, Success, Success},
+			expectedLimit: 3,
+		},
+		{
+			name:          "increase capped at max",
+			cfg:           Config{InitialLimit: 3, MinLimit: 1, MaxLimit: 3},
+			outcomes:      []Outcome{Success, Success, Success},
+			expectedLimit: 3,
+		},
+		{
+			name:          "multiplicative decrease",
+			cfg:           Config{InitialLimit: 8, MinLimit: 1, MaxLimit: 10, BackoffRatio: 0.5},
+			outcomes:      []Outcome{Overload},
+			expectedLimit: 4,
+		},
+		{
+			name:          "decrease floored at min",
+			cfg:           Config{InitialLimit: 8, MinLimit: 3, MaxLimit: 10, BackoffRatio: 0.5},
+			outcomes:      []Outcome{Overload, Overload, Overload},
+			expectedLimit: 3,
+		},
+		{
+			name:          "ignored",
+			cfg:           Config{InitialLimit: 5, MinLimit: 1, MaxLimit: 10, BackoffRatio: 0.5},
+			outcomes:      []Outcome{Ignored, Ignored},
+			expectedLimit: 5,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			limiter := New(test.cfg)
+			for _, outcome := range test.outcomes {
+				require.NoError(t, limiter.Acquire(context.Background()))
+				limiter.Release(outcome)
+			}
+			assert.Equal(t, test.expectedLimit, limiter.Limit())
+			assert.Zero(t, limiter.InFlight())
+		})
+	}
+}
+
+func TestLimiterAcquireCanceled(t *testing.T) {
+	limiter := New(Config{InitialLimit: 1, MinLimit: 1, MaxLimit: 1})
+	require.NoError(t, limiter.Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := limiter.Acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, limiter.InFlight())
+}
+
+func TestLimiterAcquireWaitsForRelease(t *testing.T) {
+	limiter := New(Config{InitialLimit: 1, MinLimit: 1, MaxLimit: 1})
+	require.NoError(t, limiter.Acquire(context.Background()))
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- limiter.Acquire(context.Background())
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired over the limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+	limiter.Release(Ignored)
+	require.NoError(t, <-acquired)
+	assert.Equal(t, 1, limiter.InFlight())
+}
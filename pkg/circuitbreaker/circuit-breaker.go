@@ -0,0 +1,214 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrOpen = errors.New("circuit breaker is open")
+)
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+type Outcome int
+
+const (
+	// Success reports that the remote side answered properly.
+	Success Outcome = iota
+	// Failure reports an error or a timeout caused by the remote side.
+	Failure
+	// Ignored reports that the request tells nothing about the remote side health,
+	// e.g. it was canceled by the caller.
+	Ignored
+)
+
+type Config struct {
+	// Window is the period after which closed state statistics are reset.
+	Window time.Duration
+	// MinRequests is the number of requests in the window required before the breaker may open.
+	MinRequests int
+	// FailureRatio is the share of failed requests in the window that opens the breaker.
+	FailureRatio float64
+	// OpenTimeout is the time the breaker stays open before probing the remote side.
+	OpenTimeout time.Duration
+	// HalfOpenMaxRequests is the number of probe requests that must succeed to close the breaker.
+	HalfOpenMaxRequests int
+}
+
+type CircuitBreaker struct {
+	onStateChange     func(from, to State)
+	openUntil         time.Time
+	windowStart       time.Time
+	mux               *sync.Mutex
+	cfg               Config
+	state             State
+	generation        uint64
+	requests          int
+	failures          int
+	halfOpenInFlight  int
+	halfOpenSuccesses int
+}
+
+// New creates a closed circuit breaker. onStateChange is optional and is called under the breaker lock.
+func New(cfg Config, onStateChange func(from, to State)) *CircuitBreaker {
+	return &CircuitBreaker{
+		cfg:           cfg,
+		onStateChange: onStateChange,
+		state:         Closed,
+		windowStart:   time.Now(),
+		mux:           &sync.Mutex{},
+	}
+}
+
+// Allow reserves a request slot. The returned function must be called exactly once with the request outcome.
+func (cb *CircuitBreaker) Allow() (report func(Outcome), err error) {
+	cb.mux.Lock()
+	defer cb.mux.Unlock()
+
+	now := time.Now()
+	cb.refresh(now)
+
+	switch cb.state {
+	case Open:
+		return nil, ErrOpen
+	case HalfOpen:
+		if cb.halfOpenInFlight+cb.halfOpenSuccesses >= cb.halfOpenMaxRequests() {
+			return nil, ErrOpen
+		}
+		cb.halfOpenInFlight++
+	case Closed:
+		cb.requests++
+	}
+
+	generation := cb.generation
+	once := &sync.Once{}
+	return func(outcome Outcome) {
+		once.Do(func() {
+			cb.report(generation, outcome)
+		})
+	}, nil
+}
+
+func (cb *CircuitBreaker) State() State {
+	cb.mux.Lock()
+	defer cb.mux.Unlock()
+	cb.refresh(time.Now())
+	return cb.state
+}
+
+// OpenUntil returns the moment the open breaker starts letting probe requests through.
+// Zero time is returned when the breaker is not open.
+func (cb *CircuitBreaker) OpenUntil() time.Time {
+	cb.mux.Lock()
+	defer cb.mux.Unlock()
+	cb.refresh(time.Now())
+	if cb.state != Open {
+		return time.Time{}
+	}
+	return cb.openUntil
+}
+
+func (cb *CircuitBreaker) report(generation uint64, outcome Outcome) {
+	cb.mux.Lock()
+	defer cb.mux.Unlock()
+
+	now := time.Now()
+	cb.refresh(now)
+
+	if generation != cb.generation {
+		return
+	}
+
+	switch cb.state {
+	case Closed:
+		switch outcome {
+		case Success:
+		case Failure:
+			cb.failures++
+			if cb.requests >= cb.cfg.MinRequests &&
+				float64(cb.failures) >= cb.cfg.FailureRatio*float64(cb.requests) {
+				cb.setState(Open, now)
+			}
+		case Ignored:
+			cb.requests--
+		}
+	case HalfOpen:
+		cb.halfOpenInFlight--
+		switch outcome {
+		case Success:
+			cb.halfOpenSuccesses++
+			if cb.halfOpenSuccesses >= cb.halfOpenMaxRequests() {
+				cb.setState(Closed, now)
+			}
+		case Failure:
+			cb.setState(Open, now)
+		case Ignored:
+		}
+	case Open:
+	}
+}
+
+func (cb *CircuitBreaker) refresh(now time.Time) {
+	switch cb.state {
+	case Closed:
+		if cb.cfg.Window > 0 && now.Sub(cb.windowStart) >= cb.cfg.Window {
+			cb.resetCounters(now)
+		}
+	case Open:
+		if !now.Before(cb.openUntil) {
+			cb.setState(HalfOpen, now)
+		}
+	case HalfOpen:
+	}
+}
+
+func (cb *CircuitBreaker) setState(state State, now time.Time) {
+	if cb.state == state {
+		return
+	}
+	prev := cb.state
+	cb.state = state
+	cb.resetCounters(now)
+	if state == Open {
+		cb.openUntil = now.Add(cb.cfg.OpenTimeout)
+	}
+	if cb.onStateChange != nil {
+		cb.onStateChange(prev, state)
+	}
+}
+
+func (cb *CircuitBreaker) resetCounters(now time.Time) {
+	cb.generation++
+	cb.windowStart = now
+	cb.requests = 0
+	cb.failures = 0
+	cb.halfOpenInFlight = 0
+	cb.halfOpenSuccesses = 0
+}
+
+func (cb *CircuitBreaker) halfOpenMaxRequests() int {
+	if cb.cfg.HalfOpenMaxRequests <= 0 {
+		return 1
+	}
+	return cb.cfg.HalfOpenMaxRequests
+}
@@ -0,0 +1,89 @@
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	cfg := Config{
+		Window:              time.Minute,
+		MinRequests:         4,
+		FailureRatio:        0.5,
+		OpenTimeout:         50 * time.Millisecond,
+		HalfOpenMaxRequests: 1,
+	}
+
+	tests := []struct {
+		name     string
+		outcomes []Outcome
+		expected State
+	}{
+		{
+			name:     "successes keep closed",
+			outcomes: []Outcome{Success, Success, Success, Success},
+			expected: Closed,
+		},
+		{
+			name:     "not enough requests keeps closed",
+			outcomes: []Outcome{Failure, Failure, Failure},
+			expected: Closed,
+		},
+		{
+			name:     "failure ratio opens",
+			outcomes: []Outcome{Success, Failure, Success, Failure},
+			expected: Open,
+		},
+		{
+			name:     "ignored outcomes are not counted",
+			outcomes: []Outcome{Ignored, Ignored, Failure, Failure},
+			expected: Closed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cb := New(cfg, nil)
+			for _, outcome := range test.outcomes {
+				report, err := cb.Allow()
+				require.NoError(t, err)
+				report(outcome)
+			}
+			assert.Equal(t, test.expected, cb.State())
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	cfg := Config{
+		MinRequests:         1,
+		FailureRatio:        1,
+		OpenTimeout:         20 * time.Millisecond,
+		HalfOpenMaxRequests: 1,
+	}
+	cb := New(cfg, nil)
+
+	report, err := cb.Allow()
+	require.NoError(t, err)
+	report(Failure)
+	assert.Equal(t, Open, cb.State())
+	_, err = cb.Allow()
+	assert.ErrorIs(t, err, ErrOpen)
+
+	time.Sleep(cfg.OpenTimeout)
+	assert.Equal(t, HalfOpen, cb.State())
+	report, err = cb.Allow()
+	require.NoError(t, err)
+	_, err = cb.Allow()
+	assert.ErrorIs(t, err, ErrOpen, "only one probe is allowed")
+	report(Failure)
+	assert.Equal(t, Open, cb.State())
+
+	time.Sleep(cfg.OpenTimeout)
+	report, err = cb.Allow()
+	require.NoError(t, err)
+	report(Success)
+	assert.Equal(t, Closed, cb.State())
+}
@@ -0,0 +1,123 @@
+// Package hashlist looks up hashes in large sorted files without loading them into memory.
+package hashlist
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// PrefixLength is the length of hash prefixes queried by Range.
+const PrefixLength = 5
+
+const readerBufferSize = 512
+
+// HashList is a file of uppercase hex hashes sorted in ascending order, one per line, optionally followed by
+// a colon and a count, as the Pwned Passwords list is distributed.
+// It is safe for concurrent use.
+type HashList struct {
+	file *os.File
+	size int64
+}
+
+func Open(path string) (*HashList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hash list: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to stat hash list: %w", err)
+	}
+	return &HashList{
+		file: file,
+		size: info.Size(),
+	}, nil
+}
+
+func (l *HashList) Close() error {
+	return l.file.Close() //nolint:wrapcheck // unnecessary
+}
+
+// Range returns the suffixes of the hashes starting with prefix, so that callers never reveal full hashes
+// and the list may be replaced with a remote k-anonymity range API.
+func (l *HashList) Range(_ context.Context, prefix string) ([]string, error) {
+	if len(prefix) != PrefixLength {
+		return nil, fmt.Errorf("prefix must be %d characters long", PrefixLength)
+	}
+	prefix = strings.ToUpper(prefix)
+	start, err := l.search(prefix)
+	if err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(io.NewSectionReader(l.file, start, l.size-start))
+	res := make([]string, 0)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read hash list: %w", err)
+		}
+		hash := lineHash(line)
+		if !strings.HasPrefix(hash, prefix) {
+			return res, nil
+		}
+		res = append(res, hash[PrefixLength:])
+		if err != nil {
+			return res, nil
+		}
+	}
+}
+
+// search returns the offset of the first line with a hash not less than prefix, binary searching the file.
+func (l *HashList) search(prefix string) (int64, error) {
+	lo, hi := int64(0), l.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, line, err := l.lineAt(mid)
+		if err != nil {
+			return 0, err
+		}
+		if start >= hi || lineHash(line) >= prefix {
+			hi = mid
+			continue
+		}
+		lo = start + int64(len(line))
+	}
+	start, _, err := l.lineAt(lo)
+	return start, err
+}
+
+// lineAt returns the first line starting at offset or after it with its offset, the line keeps its newline.
+func (l *HashList) lineAt(offset int64) (int64, string, error) {
+	start := offset
+	if offset > 0 {
+		// the line containing the previous byte ends at the first newline, unless it is the newline
+		start--
+	}
+	reader := bufio.NewReaderSize(io.NewSectionReader(l.file, start, l.size-start), readerBufferSize)
+	if offset > 0 {
+		skipped, err := reader.ReadString('\n')
+		start += int64(len(skipped))
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return l.size, "", nil
+			}
+			return 0, "", fmt.Errorf("failed to read hash list: %w", err)
+		}
+	}
+	line, err := reader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, "", fmt.Errorf("failed to read hash list: %w", err)
+	}
+	return start, line, nil
+}
+
+func lineHash(line string) string {
+	hash, _, _ := strings.Cut(strings.TrimRight(line, "\r\n"), ":")
+	return hash
+}
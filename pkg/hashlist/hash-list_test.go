@@ -0,0 +1,74 @@
+package hashlist
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRange(t *testing.T) {
+	lines := []string{
+		"0000000A0E4D5A8C2F3D1F2D6A8E5B2C1F3E4D5A:3",
+		"00000B1C2D3E4F5A6B7C8D9E0F1A2B3C4D5E6F7A:4",
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9659365",
+		"5BAA6D8D5E0AD2E5B5B02D5D47C0FF0F0C6E2E13:2",
+		"5BAA71F6F2A9F5A2C1A8B8AB2EE21A2F7AF4B1E2:1",
+		"FFFFFFFEE791CBAC0F6305CAF0CEE06BBE131160:2",
+	}
+	tests := []struct {
+		name     string
+		content  string
+		prefix   string
+		expected []string
+	}{
+		{
+			name:     "several matches",
+			content:  strings.Join(lines, "\n"),
+			prefix:   "5baa6",
+			expected: []string{"1E4C9B93F3F0682250B6CF8331B7EE68FD8", "D8D5E0AD2E5B5B02D5D47C0FF0F0C6E2E13"},
+		},
+		{
+			name:     "first lines",
+			content:  strings.Join(lines, "\r\n") + "\r\n",
+			prefix:   "00000",
+			expected: []string{"00A0E4D5A8C2F3D1F2D6A8E5B2C1F3E4D5A", "B1C2D3E4F5A6B7C8D9E0F1A2B3C4D5E6F7A"},
+		},
+		{
+			name:     "last line",
+			content:  strings.Join(lines, "\n"),
+			prefix:   "FFFFF",
+			expected: []string{"FFEE791CBAC0F6305CAF0CEE06BBE131160"},
+		},
+		{
+			name:     "no matches",
+			content:  strings.Join(lines, "\n") + "\n",
+			prefix:   "5BAA8",
+			expected: []string{},
+		},
+		{
+			name:     "empty file",
+			content:  "",
+			prefix:   "5BAA6",
+			expected: []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "hashes.txt")
+			require.NoError(t, os.WriteFile(path, []byte(test.content), 0o600))
+			list, err := Open(path)
+			require.NoError(t, err)
+			defer func() {
+				_ = list.Close()
+			}()
+			suffixes, err := list.Range(context.Background(), test.prefix)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, suffixes)
+		})
+	}
+}
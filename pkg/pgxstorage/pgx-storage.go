@@ -5,7 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"go-market/pkg/timeutils"
-	"time"
+	"strconv"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -16,38 +16,62 @@ type contextKey int
 
 const (
 	transactionKey contextKey = iota
+	replicaKey
 )
 
 var errNoTransaction = errors.New("no transaction")
 
 type DBFactory interface {
 	Create() (*pgxpool.Pool, error)
+	// CreateReplica returns nil when no replica is configured.
+	CreateReplica() (*pgxpool.Pool, error)
 }
 
 type DBStorage struct {
-	pool               *pgxpool.Pool
-	retryAttemptDelays []time.Duration
+	pool        *pgxpool.Pool
+	replica     *replica
+	retryPolicy timeutils.RetryPolicy
 }
 
-func New(dbFactory DBFactory, retryAttemptDelays []time.Duration) (*DBStorage, error) {
+func New(dbFactory DBFactory, retryPolicy timeutils.RetryPolicy, replicaConfig ReplicaConfig) (*DBStorage, error) {
 	db, err := dbFactory.Create()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create database: %w", err)
 	}
-	return &DBStorage{
-		pool:               db,
-		retryAttemptDelays: retryAttemptDelays,
-	}, nil
+	replicaDB, err := dbFactory.CreateReplica()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create replica database: %w", err)
+	}
+	storage := &DBStorage{
+		pool:        db,
+		retryPolicy: retryPolicy,
+	}
+	if replicaDB != nil {
+		storage.replica = newReplica(replicaDB, replicaConfig)
+	}
+	return storage, nil
 }
 
 func (s *DBStorage) Close() {
 	s.pool.Close()
+	if s.replica != nil {
+		s.replica.pool.Close()
+	}
+}
+
+// readPool returns the replica pool when the read allows it and the replica is configured and not lagging.
+func (s *DBStorage) readPool(ctx context.Context) *pgxpool.Pool {
+	if s.replica == nil || !isReplicaAllowed(ctx) || !s.replica.usable(ctx) {
+		return s.pool
+	}
+	return s.replica.pool
 }
 
 func (s *DBStorage) Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
 	return queryInternal[pgconn.CommandTag](
 		ctx,
-		s.retryAttemptDelays,
+		s.retryPolicy,
 		func(ctx context.Context) (pgconn.CommandTag, error) {
 			return s.pool.Exec(ctx, query, args...)
 		},
@@ -60,9 +84,9 @@ func (s *DBStorage) Exec(ctx context.Context, query string, args ...any) (pgconn
 func (s *DBStorage) QueryRow(ctx context.Context, query string, args ...any) (pgx.Row, error) {
 	return queryInternal[pgx.Row](
 		ctx,
-		s.retryAttemptDelays,
+		s.retryPolicy,
 		func(ctx context.Context) (pgx.Row, error) {
-			return s.pool.QueryRow(ctx, query, args...), nil
+			return s.readPool(ctx).QueryRow(ctx, query, args...), nil
 		},
 		func(ctx context.Context, tx pgx.Tx) (pgx.Row, error) {
 			return tx.QueryRow(ctx, query, args...), nil
@@ -73,9 +97,9 @@ func (s *DBStorage) QueryRow(ctx context.Context, query string, args ...any) (pg
 func (s *DBStorage) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
 	return queryInternal[pgx.Rows](
 		ctx,
-		s.retryAttemptDelays,
+		s.retryPolicy,
 		func(ctx context.Context) (pgx.Rows, error) {
-			return s.pool.Query(ctx, query, args...)
+			return s.readPool(ctx).Query(ctx, query, args...)
 		},
 		func(ctx context.Context, tx pgx.Tx) (pgx.Rows, error) {
 			return tx.Query(ctx, query, args...)
@@ -93,7 +117,7 @@ func (s *DBStorage) QueryValue(ctx context.Context, query string, args []any, de
 
 func queryInternal[T any](
 	ctx context.Context,
-	retryAttemptDelays []time.Duration,
+	retryPolicy timeutils.RetryPolicy,
 	woTx func(context.Context) (T, error),
 	withTx func(context.Context, pgx.Tx) (T, error),
 ) (T, error) {
@@ -103,7 +127,7 @@ func queryInternal[T any](
 		case errors.Is(err, errNoTransaction):
 			return queryWithRetry[T](
 				ctx,
-				retryAttemptDelays,
+				retryPolicy,
 				woTx,
 			)
 		default:
@@ -113,7 +137,7 @@ func queryInternal[T any](
 	}
 	return queryWithRetry[T](
 		ctx,
-		retryAttemptDelays,
+		retryPolicy,
 		func(ctx context.Context) (T, error) {
 			return withTx(ctx, tx)
 		},
@@ -122,12 +146,12 @@ func queryInternal[T any](
 
 func queryWithRetry[T any](
 	ctx context.Context,
-	retryAttemptDelays []time.Duration,
+	retryPolicy timeutils.RetryPolicy,
 	query func(context.Context) (T, error),
 ) (T, error) {
 	return timeutils.Retry[T](
 		ctx,
-		retryAttemptDelays,
+		retryPolicy,
 		query,
 		func(_ T, err error) bool {
 			return needRetry(err)
@@ -147,15 +171,73 @@ func needRetry(err error) bool {
 	return false
 }
 
-func (s *DBStorage) withTransaction(ctx context.Context) (context.Context, pgx.Tx, error) {
-	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead})
-	if err != nil {
-		return nil, nil, fmt.Errorf("transaction begin failed: %w", err)
+func (s *DBStorage) withTransaction(ctx context.Context, options txOptions) (context.Context, pgx.Tx, error) {
+	var tx pgx.Tx
+	parent, err := getTransaction(ctx)
+	switch {
+	case err == nil:
+		tx, err = parent.Begin(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("savepoint creation failed: %w", err)
+		}
+		if options.statementTimeout > 0 {
+			tx, err = newStatementTimeoutSavepoint(ctx, tx)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	case errors.Is(err, errNoTransaction):
+		tx, err = s.pool.BeginTx(ctx, options.pgx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("transaction begin failed: %w", err)
+		}
+	default:
+		return nil, nil, err
+	}
+	if options.statementTimeout > 0 {
+		err = setStatementTimeout(ctx, tx, strconv.FormatInt(options.statementTimeout.Milliseconds(), 10))
+		if err != nil {
+			rollbackErr := tx.Rollback(context.Background())
+			return nil, nil, errors.Join(fmt.Errorf("setting statement timeout failed: %w", err), rollbackErr)
+		}
 	}
 	ctxWithTransaction := context.WithValue(ctx, transactionKey, tx)
 	return ctxWithTransaction, tx, nil
 }
 
+func setStatementTimeout(ctx context.Context, tx pgx.Tx, timeout string) error {
+	_, err := tx.Exec(ctx, "SELECT set_config('statement_timeout', $1, true)", timeout)
+	return err //nolint:wrapcheck // unnecessary
+}
+
+// statementTimeoutSavepoint restores the statement timeout of the enclosing transaction on release,
+// as a setting made in a released savepoint stays for the rest of the transaction.
+// A rolled back savepoint reverts the setting by itself.
+type statementTimeoutSavepoint struct {
+	pgx.Tx
+	previousTimeout string
+}
+
+func newStatementTimeoutSavepoint(ctx context.Context, tx pgx.Tx) (*statementTimeoutSavepoint, error) {
+	var previousTimeout string
+	err := tx.QueryRow(ctx, "SELECT current_setting('statement_timeout')").Scan(&previousTimeout)
+	if err != nil {
+		rollbackErr := tx.Rollback(context.Background())
+		return nil, errors.Join(fmt.Errorf("getting statement timeout failed: %w", err), rollbackErr)
+	}
+	return &statementTimeoutSavepoint{
+		Tx:              tx,
+		previousTimeout: previousTimeout,
+	}, nil
+}
+
+func (s *statementTimeoutSavepoint) Commit(ctx context.Context) error {
+	if err := setStatementTimeout(ctx, s.Tx, s.previousTimeout); err != nil {
+		return fmt.Errorf("restoring statement timeout failed: %w", err)
+	}
+	return s.Tx.Commit(ctx) //nolint:wrapcheck // unnecessary
+}
+
 func getTransaction(ctx context.Context) (pgx.Tx, error) {
 	txVal := ctx.Value(transactionKey)
 	if txVal == nil {
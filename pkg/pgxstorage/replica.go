@@ -0,0 +1,86 @@
+package pgxstorage
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/sync/singleflight"
+)
+
+const replicaLagCheckTimeout = time.Second
+
+const selectReplicaLagQuery = `
+SELECT CASE
+           WHEN NOT pg_is_in_recovery() THEN 0
+           WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+           ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+           END::float8`
+
+type ReplicaConfig struct {
+	// MaxLag is the replication lag above which reads fall back to the primary.
+	MaxLag time.Duration
+	// LagCheckPeriod is the time a measured lag is trusted before it is checked again.
+	LagCheckPeriod time.Duration
+}
+
+// WithReplica lets reads made with the returned context be served by the replica while it is not lagging.
+// Reads go to the primary by default, the hint is for read-only queries that tolerate slightly stale data.
+func WithReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaKey, true)
+}
+
+func isReplicaAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(replicaKey).(bool)
+	return allowed
+}
+
+type replica struct {
+	pool      *pgxpool.Pool
+	cfg       ReplicaConfig
+	measure   func(ctx context.Context) (time.Duration, error)
+	checks    *singleflight.Group
+	healthy   *atomic.Bool
+	checkedAt *atomic.Int64
+}
+
+func newReplica(pool *pgxpool.Pool, cfg ReplicaConfig) *replica {
+	r := &replica{
+		pool:      pool,
+		cfg:       cfg,
+		checks:    &singleflight.Group{},
+		healthy:   &atomic.Bool{},
+		checkedAt: &atomic.Int64{},
+	}
+	r.measure = r.measureLag
+	return r
+}
+
+// usable reports whether the replica lag is within the limit, the result is cached for LagCheckPeriod.
+// Concurrent callers finding the cache stale share one lag check instead of queueing for their own.
+func (r *replica) usable(ctx context.Context) bool {
+	if time.Since(time.Unix(0, r.checkedAt.Load())) < r.cfg.LagCheckPeriod {
+		return r.healthy.Load()
+	}
+	res, _, _ := r.checks.Do("lag", func() (any, error) {
+		// the check is shared, so it must not be cut short by the caller that happened to start it
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), replicaLagCheckTimeout)
+		defer cancel()
+		lag, err := r.measure(ctx)
+		healthy := err == nil && lag <= r.cfg.MaxLag
+		r.healthy.Store(healthy)
+		r.checkedAt.Store(time.Now().UnixNano())
+		return healthy, nil
+	})
+	healthy, _ := res.(bool)
+	return healthy
+}
+
+func (r *replica) measureLag(ctx context.Context) (time.Duration, error) {
+	var lagSeconds float64
+	if err := r.pool.QueryRow(ctx, selectReplicaLagQuery).Scan(&lagSeconds); err != nil {
+		return 0, err //nolint:wrapcheck // unnecessary
+	}
+	return time.Duration(lagSeconds * float64(time.Second)), nil
+}
@@ -0,0 +1,145 @@
+package pgxstorage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLazyPool creates a pool that never connects unless queried.
+func newLazyPool(t *testing.T, host string) *pgxpool.Pool {
+	t.Helper()
+
+	pool, err := pgxpool.New(context.Background(), "postgres://user@"+host+"/db")
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func newTestReplica(
+	t *testing.T,
+	measure func(ctx context.Context) (time.Duration, error),
+) (*DBStorage, *atomic.Int32) {
+	t.Helper()
+
+	checks := &atomic.Int32{}
+	r := newReplica(newLazyPool(t, "replica"), ReplicaConfig{MaxLag: 5 * time.Second, LagCheckPeriod: time.Hour})
+	r.measure = func(ctx context.Context) (time.Duration, error) {
+		checks.Add(1)
+		return measure(ctx)
+	}
+	return &DBStorage{
+		pool:    newLazyPool(t, "primary"),
+		replica: r,
+	}, checks
+}
+
+func TestReadPool(t *testing.T) {
+	tests := []struct {
+		name            string
+		lag             time.Duration
+		lagErr          error
+		primaryRead     bool
+		expectedReplica bool
+		expectedChecks  int32
+	}{
+		{
+			name:            "replica in sync",
+			expectedReplica: true,
+			expectedChecks:  1,
+		},
+		{
+			name:            "replica lag within the limit",
+			lag:             5 * time.Second,
+			expectedReplica: true,
+			expectedChecks:  1,
+		},
+		{
+			name:           "replica lagging",
+			lag:            6 * time.Second,
+			expectedChecks: 1,
+		},
+		{
+			name:           "replica unavailable",
+			lagErr:         errors.New("connection refused"),
+			expectedChecks: 1,
+		},
+		{
+			name:        "read without the replica hint",
+			primaryRead: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			storage, checks := newTestReplica(t, func(context.Context) (time.Duration, error) {
+				return test.lag, test.lagErr
+			})
+			ctx := context.Background()
+			if !test.primaryRead {
+				ctx = WithReplica(ctx)
+			}
+
+			// the second read is served from the cached lag
+			for range 2 {
+				if test.expectedReplica {
+					assert.Same(t, storage.replica.pool, storage.readPool(ctx))
+				} else {
+					assert.Same(t, storage.pool, storage.readPool(ctx))
+				}
+			}
+			assert.Equal(t, test.expectedChecks, checks.Load())
+		})
+	}
+}
+
+func TestReadPoolWithoutReplica(t *testing.T) {
+	storage := &DBStorage{pool: newLazyPool(t, "primary")}
+
+	assert.Same(t, storage.pool, storage.readPool(WithReplica(context.Background())))
+}
+
+// TestReplicaLagCheckShared checks concurrent readers wait for one lag check and are not blocked by each other
+// once the lag is known.
+func TestReplicaLagCheckShared(t *testing.T) {
+	release := make(chan struct{})
+	storage, checks := newTestReplica(t, func(context.Context) (time.Duration, error) {
+		<-release
+		return 0, nil
+	})
+
+	const readersCount = 10
+	wg := &sync.WaitGroup{}
+	for range readersCount {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Same(t, storage.replica.pool, storage.readPool(WithReplica(context.Background())))
+		}()
+	}
+	require.Eventually(t, func() bool {
+		return checks.Load() == 1
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), checks.Load())
+	assert.Same(t, storage.replica.pool, storage.readPool(WithReplica(context.Background())))
+	assert.Equal(t, int32(1), checks.Load())
+}
+
+func TestReplicaLagCheckOutlivesCaller(t *testing.T) {
+	storage, _ := newTestReplica(t, func(ctx context.Context) (time.Duration, error) {
+		return 0, ctx.Err()
+	})
+	ctx, cancel := context.WithCancel(WithReplica(context.Background()))
+	cancel()
+
+	assert.Same(t, storage.replica.pool, storage.readPool(ctx))
+}
@@ -0,0 +1,60 @@
+package pgxstorage
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type TxOption func(*txOptions)
+
+type txOptions struct {
+	pgx              pgx.TxOptions
+	statementTimeout time.Duration
+	maxAttempts      int
+}
+
+func newTxOptions(opts []TxOption) txOptions {
+	res := txOptions{
+		pgx: pgx.TxOptions{IsoLevel: pgx.RepeatableRead},
+	}
+	for _, opt := range opts {
+		opt(&res)
+	}
+	return res
+}
+
+// WithIsolationLevel overrides the default RepeatableRead isolation level.
+func WithIsolationLevel(level pgx.TxIsoLevel) TxOption {
+	return func(o *txOptions) {
+		o.pgx.IsoLevel = level
+	}
+}
+
+func WithReadOnly() TxOption {
+	return func(o *txOptions) {
+		o.pgx.AccessMode = pgx.ReadOnly
+	}
+}
+
+// WithDeferrable makes a serializable read-only transaction wait for a safe snapshot instead of failing.
+func WithDeferrable() TxOption {
+	return func(o *txOptions) {
+		o.pgx.DeferrableMode = pgx.Deferrable
+	}
+}
+
+// WithStatementTimeout limits every statement of the transaction.
+func WithStatementTimeout(timeout time.Duration) TxOption {
+	return func(o *txOptions) {
+		o.statementTimeout = timeout
+	}
+}
+
+// WithSerializationRetry overrides the manager's maximum number of attempts on serialization failures.
+// One attempt disables retrying.
+func WithSerializationRetry(maxAttempts int) TxOption {
+	return func(o *txOptions) {
+		o.maxAttempts = maxAttempts
+	}
+}
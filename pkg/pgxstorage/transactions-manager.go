@@ -2,24 +2,65 @@ package pgxstorage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"go-market/pkg/timeutils"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
 )
 
 type TransactionsManager struct {
-	storage *DBStorage
+	storage     *DBStorage
+	retryPolicy timeutils.RetryPolicy
 }
 
-func NewTransactionsManager(storage *DBStorage) *TransactionsManager {
+// NewTransactionsManager creates a manager retrying whole transactions on serialization failures with retryPolicy.
+func NewTransactionsManager(storage *DBStorage, retryPolicy timeutils.RetryPolicy) *TransactionsManager {
 	return &TransactionsManager{
-		storage: storage,
+		storage:     storage,
+		retryPolicy: retryPolicy,
 	}
 }
 
+// DoWithTransaction runs f in a transaction. When ctx already holds a transaction,
+// f runs in a savepoint of it and options other than statement timeout are ignored.
 func (tm *TransactionsManager) DoWithTransaction(
 	ctx context.Context,
 	f func(ctx context.Context) error,
+	opts ...TxOption,
 ) error {
-	ctxWithTransaction, tx, err := tm.storage.withTransaction(ctx)
+	options := newTxOptions(opts)
+	if _, err := getTransaction(ctx); err == nil {
+		return tm.doWithTransaction(ctx, f, options)
+	}
+	retryPolicy := tm.retryPolicy
+	if options.maxAttempts > 0 {
+		retryPolicy.MaxAttempts = options.maxAttempts
+	}
+	_, err := timeutils.Retry[struct{}](
+		ctx,
+		retryPolicy,
+		func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, tm.doWithTransaction(ctx, f, options)
+		},
+		func(_ struct{}, err error) bool {
+			return isSerializationFailure(err)
+		},
+	)
+	return err //nolint:wrapcheck // unnecessary
+}
+
+func (tm *TransactionsManager) doWithTransaction(
+	ctx context.Context,
+	f func(ctx context.Context) error,
+	options txOptions,
+) error {
+	ctxWithTransaction, tx, err := tm.storage.withTransaction(ctx, options)
 	if err != nil {
 		return err
 	}
@@ -41,3 +82,11 @@ func (tm *TransactionsManager) DoWithTransaction(
 	}
 	return nil
 }
+
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == serializationFailureCode || pgErr.Code == deadlockDetectedCode
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+package pgxstorage
+
+import (
+	"context"
+	"errors"
+	"go-market/pkg/timeutils"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDatabaseURIEnv = "TEST_DATABASE_URI"
+
+var errTest = errors.New("test error")
+
+type testDBFactory struct {
+	dsn string
+}
+
+func (f testDBFactory) Create() (*pgxpool.Pool, error) {
+	return pgxpool.New(context.Background(), f.dsn) //nolint:wrapcheck // unnecessary
+}
+
+func (f testDBFactory) CreateReplica() (*pgxpool.Pool, error) {
+	return nil, nil //nolint:nilnil // replica is optional
+}
+
+func newTestStorage(t *testing.T) *DBStorage {
+	t.Helper()
+
+	dsn, ok := os.LookupEnv(testDatabaseURIEnv)
+	if !ok {
+		t.Skipf("%s is not set", testDatabaseURIEnv)
+	}
+	storage, err := New(testDBFactory{dsn: dsn}, timeutils.RetryPolicy{MaxAttempts: 1}, ReplicaConfig{})
+	require.NoError(t, err)
+	t.Cleanup(storage.Close)
+	return storage
+}
+
+func showSetting(ctx context.Context, t *testing.T, storage *DBStorage, name string) string {
+	t.Helper()
+
+	var value string
+	require.NoError(t, storage.QueryValue(ctx, "SELECT current_setting($1)", []any{name}, []any{&value}))
+	return value
+}
+
+func TestTxOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []TxOption
+		expected txOptions
+	}{
+		{
+			name:     "defaults",
+			expected: txOptions{pgx: pgx.TxOptions{IsoLevel: pgx.RepeatableRead}},
+		},
+		{
+			name: "all options",
+			opts: []TxOption{
+				WithIsolationLevel(pgx.Serializable),
+				WithReadOnly(),
+				WithDeferrable(),
+				WithStatementTimeout(time.Second),
+				WithSerializationRetry(1),
+			},
+			expected: txOptions{
+				pgx: pgx.TxOptions{
+					IsoLevel:       pgx.Serializable,
+					AccessMode:     pgx.ReadOnly,
+					DeferrableMode: pgx.Deferrable,
+				},
+				statementTimeout: time.Second,
+				maxAttempts:      1,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, newTxOptions(test.opts))
+		})
+	}
+}
+
+func TestTransactionOptions(t *testing.T) {
+	storage := newTestStorage(t)
+	tm := NewTransactionsManager(storage, timeutils.RetryPolicy{MaxAttempts: 1})
+
+	tests := []struct {
+		name     string
+		opts     []TxOption
+		setting  string
+		expected string
+	}{
+		{
+			name:     "default isolation level",
+			setting:  "transaction_isolation",
+			expected: "repeatable read",
+		},
+		{
+			name:     "isolation level",
+			opts:     []TxOption{WithIsolationLevel(pgx.Serializable)},
+			setting:  "transaction_isolation",
+			expected: "serializable",
+		},
+		{
+			name:     "read only",
+			opts:     []TxOption{WithReadOnly()},
+			setting:  "transaction_read_only",
+			expected: "on",
+		},
+		{
+			name:     "deferrable",
+			opts:     []TxOption{WithIsolationLevel(pgx.Serializable), WithReadOnly(), WithDeferrable()},
+			setting:  "transaction_deferrable",
+			expected: "on",
+		},
+		{
+			name:     "statement timeout",
+			opts:     []TxOption{WithStatementTimeout(1500 * time.Millisecond)},
+			setting:  "statement_timeout",
+			expected: "1500ms",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := tm.DoWithTransaction(context.Background(), func(ctx context.Context) error {
+				assert.Equal(t, test.expected, showSetting(ctx, t, storage, test.setting))
+				return nil
+			}, test.opts...)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSerializationRetry(t *testing.T) {
+	storage := newTestStorage(t)
+	tm := NewTransactionsManager(storage, timeutils.RetryPolicy{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+		Multiplier:      1,
+		MaxAttempts:     3,
+	})
+
+	tests := []struct {
+		name             string
+		opts             []TxOption
+		expectedAttempts int
+	}{
+		{
+			name:             "manager policy",
+			expectedAttempts: 3,
+		},
+		{
+			name:             "retrying disabled",
+			opts:             []TxOption{WithSerializationRetry(1)},
+			expectedAttempts: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			err := tm.DoWithTransaction(context.Background(), func(context.Context) error {
+				attempts++
+				return &pgconn.PgError{Code: serializationFailureCode}
+			}, test.opts...)
+			require.Error(t, err)
+			assert.True(t, isSerializationFailure(err))
+			assert.Equal(t, test.expectedAttempts, attempts)
+		})
+	}
+}
+
+// TestSavepoints checks a failed nested transaction rolls back only its own savepoint.
+func TestSavepoints(t *testing.T) {
+	storage := newTestStorage(t)
+	tm := NewTransactionsManager(storage, timeutils.RetryPolicy{MaxAttempts: 1})
+
+	err := tm.DoWithTransaction(context.Background(), func(ctx context.Context) error {
+		_, err := storage.Exec(ctx, "CREATE TEMPORARY TABLE savepoints (value INTEGER) ON COMMIT DROP")
+		require.NoError(t, err)
+		_, err = storage.Exec(ctx, "INSERT INTO savepoints VALUES (1)")
+		require.NoError(t, err)
+
+		err = tm.DoWithTransaction(ctx, func(ctx context.Context) error {
+			_, err := storage.Exec(ctx, "INSERT INTO savepoints VALUES (2)")
+			require.NoError(t, err)
+			return tm.DoWithTransaction(ctx, func(ctx context.Context) error {
+				_, err := storage.Exec(ctx, "INSERT INTO savepoints VALUES (3)")
+				require.NoError(t, err)
+				return errTest
+			})
+		})
+		require.ErrorIs(t, err, errTest)
+
+		err = tm.DoWithTransaction(ctx, func(ctx context.Context) error {
+			_, err := storage.Exec(ctx, "INSERT INTO savepoints VALUES (4)")
+			return err //nolint:wrapcheck // unnecessary
+		})
+		require.NoError(t, err)
+
+		rows, err := storage.Query(ctx, "SELECT value FROM savepoints ORDER BY value")
+		require.NoError(t, err)
+		values, err := pgx.CollectRows(rows, pgx.RowTo[int32])
+		require.NoError(t, err)
+		assert.Equal(t, []int32{1, 4}, values)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// TestNestedStatementTimeout checks the timeout of a savepoint does not outlive it.
+func TestNestedStatementTimeout(t *testing.T) {
+	storage := newTestStorage(t)
+	tm := NewTransactionsManager(storage, timeutils.RetryPolicy{MaxAttempts: 1})
+
+	err := tm.DoWithTransaction(context.Background(), func(ctx context.Context) error {
+		err := tm.DoWithTransaction(ctx, func(ctx context.Context) error {
+			assert.Equal(t, "1s", showSetting(ctx, t, storage, "statement_timeout"))
+			return nil
+		}, WithStatementTimeout(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, "5s", showSetting(ctx, t, storage, "statement_timeout"))
+
+		err = tm.DoWithTransaction(ctx, func(context.Context) error {
+			return errTest
+		}, WithStatementTimeout(time.Second))
+		require.ErrorIs(t, err, errTest)
+		assert.Equal(t, "5s", showSetting(ctx, t, storage, "statement_timeout"))
+		return nil
+	}, WithStatementTimeout(5*time.Second))
+	require.NoError(t, err)
+}
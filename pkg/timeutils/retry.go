@@ -0,0 +1,205 @@
+package timeutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+var (
+	ErrAllAttemptsFailed    = errors.New("all attempts failed")
+	ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+	errRetryableResult = errors.New("result requires retry")
+)
+
+type Jitter int
+
+const (
+	NoJitter Jitter = iota
+	// FullJitter picks a random delay between zero and the exponential delay.
+	FullJitter
+	// DecorrelatedJitter picks a random delay between the initial interval and three times the previous delay.
+	DecorrelatedJitter
+)
+
+type RetryPolicy struct {
+	// Budget is optional and may be shared between policies to cap the total retry ratio.
+	Budget          *RetryBudget
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	// MaxElapsedTime stops retrying when the next attempt would start later, zero means no limit.
+	MaxElapsedTime time.Duration
+	Multiplier     float64
+	Jitter         Jitter
+	// MaxAttempts includes the first attempt, zero means no limit.
+	MaxAttempts int
+}
+
+// RetryError is returned when Retry gives up. It wraps both the reason and the last attempt error.
+type RetryError struct {
+	Reason   error
+	Err      error
+	Attempts int
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v after %d attempt(s): %v", e.Reason, e.Attempts, e.Err)
+}
+
+func (e *RetryError) Unwrap() []error {
+	return []error{e.Reason, e.Err}
+}
+
+// Retry calls function until needRetry reports false for its result or the policy gives up.
+// On give up the last result is returned together with a *RetryError, function is not called at all
+// when ctx is already done.
+func Retry[T any](
+	ctx context.Context,
+	policy RetryPolicy,
+	function func(context.Context) (T, error),
+	needRetry func(T, error) bool,
+) (T, error) {
+	if err := ctx.Err(); err != nil {
+		var res T
+		return res, &RetryError{Reason: err, Err: err}
+	}
+	start := time.Now()
+	backoff := policy.NewBackoff()
+	policy.Budget.deposit()
+	for attempt := 1; ; attempt++ {
+		res, err := function(ctx)
+		if !needRetry(res, err) {
+			return res, err
+		}
+		if err == nil {
+			err = errRetryableResult
+		}
+		giveUp := func(reason error) (T, error) {
+			return res, &RetryError{Reason: reason, Err: err, Attempts: attempt}
+		}
+
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return giveUp(ErrAllAttemptsFailed)
+		}
+		delay := backoff.Next()
+		if policy.MaxElapsedTime > 0 && time.Since(start)+delay > policy.MaxElapsedTime {
+			return giveUp(ErrAllAttemptsFailed)
+		}
+		if !policy.Budget.withdraw() {
+			return giveUp(ErrRetryBudgetExhausted)
+		}
+		if sleepErr := SleepCtx(ctx, delay); sleepErr != nil {
+			return giveUp(sleepErr)
+		}
+	}
+}
+
+// Backoff produces successive retry delays of a policy.
+type Backoff struct {
+	policy  RetryPolicy
+	prev    time.Duration
+	attempt int
+}
+
+func (p RetryPolicy) NewBackoff() *Backoff {
+	return &Backoff{
+		policy: p,
+	}
+}
+
+func (b *Backoff) Next() time.Duration {
+	p := b.policy
+	var delay time.Duration
+	switch p.Jitter {
+	case DecorrelatedJitter:
+		upper := max(3*b.prev, p.InitialInterval)
+		delay = p.InitialInterval + randDuration(upper-p.InitialInterval)
+	case FullJitter:
+		delay = randDuration(p.exponential(b.attempt))
+	case NoJitter:
+		delay = p.exponential(b.attempt)
+	}
+	if p.MaxInterval > 0 {
+		delay = min(delay, p.MaxInterval)
+	}
+	b.prev = delay
+	b.attempt++
+	return delay
+}
+
+// Delay returns the delay before retry number attempt (starting with zero) without keeping state.
+// Decorrelated jitter is approximated with full jitter here.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.exponential(attempt)
+	if p.Jitter != NoJitter {
+		delay = randDuration(delay)
+	}
+	if p.MaxInterval > 0 {
+		delay = min(delay, p.MaxInterval)
+	}
+	return delay
+}
+
+func (p RetryPolicy) exponential(attempt int) time.Duration {
+	multiplier := max(p.Multiplier, 1)
+	delay := float64(p.InitialInterval) * math.Pow(multiplier, float64(attempt))
+	if p.MaxInterval > 0 {
+		delay = math.Min(delay, float64(p.MaxInterval))
+	}
+	if delay >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+func randDuration(upper time.Duration) time.Duration {
+	if upper <= 0 {
+		return 0
+	}
+	return rand.N(upper + 1) //nolint:gosec // jitter does not need a secure random
+}
+
+// RetryBudget caps retries to a share of calls. Every call deposits ratio tokens, every retry takes one token.
+type RetryBudget struct {
+	mux       *sync.Mutex
+	ratio     float64
+	maxTokens float64
+	tokens    float64
+}
+
+// NewRetryBudget creates a full budget allowing retries for ratio of calls with maxTokens retries in a burst.
+func NewRetryBudget(ratio float64, maxTokens int) *RetryBudget {
+	return &RetryBudget{
+		mux:       &sync.Mutex{},
+		ratio:     ratio,
+		maxTokens: float64(maxTokens),
+		tokens:    float64(maxTokens),
+	}
+}
+
+func (b *RetryBudget) deposit() {
+	if b == nil {
+		return
+	}
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.tokens = math.Min(b.tokens+b.ratio, b.maxTokens)
+}
+
+func (b *RetryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,132 @@
+package timeutils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTest = errors.New("test error")
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name             string
+		policy           RetryPolicy
+		failures         int
+		expectedAttempts int
+		expectedReason   error
+	}{
+		{
+			name:             "success on first attempt",
+			policy:           RetryPolicy{MaxAttempts: 3},
+			failures:         0,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "success after retries",
+			policy:           RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond, Multiplier: 2},
+			failures:         2,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "all attempts failed",
+			policy:           RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond, Jitter: FullJitter},
+			failures:         5,
+			expectedAttempts: 3,
+			expectedReason:   ErrAllAttemptsFailed,
+		},
+		{
+			name:             "max elapsed time",
+			policy:           RetryPolicy{InitialInterval: 10 * time.Millisecond, MaxElapsedTime: 15 * time.Millisecond},
+			failures:         5,
+			expectedAttempts: 2,
+			expectedReason:   ErrAllAttemptsFailed,
+		},
+		{
+			name:             "budget exhausted",
+			policy:           RetryPolicy{MaxAttempts: 5, Budget: NewRetryBudget(0.1, 1)},
+			failures:         5,
+			expectedAttempts: 2,
+			expectedReason:   ErrRetryBudgetExhausted,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			res, err := Retry[int](
+				context.Background(),
+				test.policy,
+				func(context.Context) (int, error) {
+					attempts++
+					if attempts <= test.failures {
+						return 0, errTest
+					}
+					return attempts, nil
+				},
+				func(_ int, err error) bool {
+					return err != nil
+				},
+			)
+			assert.Equal(t, test.expectedAttempts, attempts)
+			if test.expectedReason == nil {
+				require.NoError(t, err)
+				assert.Equal(t, test.expectedAttempts, res)
+				return
+			}
+			assert.ErrorIs(t, err, test.expectedReason)
+			assert.ErrorIs(t, err, errTest)
+			var retryErr *RetryError
+			require.ErrorAs(t, err, &retryErr)
+			assert.Equal(t, test.expectedAttempts, retryErr.Attempts)
+		})
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	_, err := Retry[int](
+		ctx,
+		RetryPolicy{MaxAttempts: 3},
+		func(context.Context) (int, error) {
+			attempts++
+			return 0, nil
+		},
+		func(_ int, err error) bool {
+			return err != nil
+		},
+	)
+	assert.Zero(t, attempts)
+	require.ErrorIs(t, err, context.Canceled)
+	var retryErr *RetryError
+	require.ErrorAs(t, err, &retryErr)
+	assert.Zero(t, retryErr.Attempts)
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     time.Second,
+		Multiplier:      2,
+	}
+
+	backoff := policy.NewBackoff()
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for _, delay := range expected {
+		assert.Equal(t, delay*time.Millisecond, backoff.Next())
+	}
+
+	policy.Jitter = DecorrelatedJitter
+	backoff = policy.NewBackoff()
+	for range 10 {
+		delay := backoff.Next()
+		assert.GreaterOrEqual(t, delay, policy.InitialInterval)
+		assert.LessOrEqual(t, delay, policy.MaxInterval)
+	}
+}